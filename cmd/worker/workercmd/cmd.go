@@ -3,9 +3,8 @@ package workercmd
 import (
 	"context"
 	"fmt"
+	"time"
 
-	"github.com/artefactual-sdps/temporal-activities/bagcreate"
-	"github.com/artefactual-sdps/temporal-activities/bucketdelete"
 	"github.com/artefactual-sdps/temporal-activities/bucketupload"
 	"github.com/go-logr/logr"
 	"go.artefactual.dev/tools/bucket"
@@ -19,7 +18,9 @@ import (
 	_ "gocloud.dev/blob/fileblob"
 
 	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/clamav"
 	"github.com/artefactual-sdps/cva-enduro-workflows/internal/config"
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/formatid"
 	"github.com/artefactual-sdps/cva-enduro-workflows/internal/workflows"
 )
 
@@ -31,6 +32,15 @@ type Main struct {
 	ingestBucket   *blob.Bucket
 	temporalWorker temporalsdk_worker.Worker
 	temporalClient temporalsdk_client.Client
+
+	// vanDocsLoc is the time zone used to parse VanDocs dates.
+	vanDocsLoc *time.Location
+
+	// malwareScanner scans SIP files for malware, if enabled.
+	malwareScanner activities.Scanner
+
+	// formatIdentifier identifies SIP file formats, if enabled.
+	formatIdentifier *formatid.Identifier
 }
 
 func NewMain(logger logr.Logger, cfg config.Config) *Main {
@@ -41,6 +51,13 @@ func NewMain(logger logr.Logger, cfg config.Config) *Main {
 }
 
 func (m *Main) Run(ctx context.Context) error {
+	loc, err := m.cfg.VanDocs.Location()
+	if err != nil {
+		m.logger.Error(err, "Unable to load the VanDocs time zone.")
+		return err
+	}
+	m.vanDocsLoc = loc
+
 	c, err := temporalsdk_client.Dial(temporalsdk_client.Options{
 		HostPort:  m.cfg.Temporal.Address,
 		Namespace: m.cfg.Temporal.Namespace,
@@ -68,6 +85,24 @@ func (m *Main) Run(ctx context.Context) error {
 	}
 	m.ingestBucket = b
 
+	if m.cfg.Preprocessing.MalwareScan.Enabled {
+		s, err := clamav.NewClient(m.cfg.Preprocessing.MalwareScan.Address, m.cfg.Preprocessing.MalwareScan.FileTimeout)
+		if err != nil {
+			m.logger.Error(err, "Unable to create the ClamAV client.")
+			return err
+		}
+		m.malwareScanner = s
+	}
+
+	if m.cfg.Preprocessing.IdentifyFormats.Enabled {
+		id, err := formatid.Load(m.cfg.Preprocessing.IdentifyFormats.SignatureFile)
+		if err != nil {
+			m.logger.Error(err, "Unable to load the format signatures.")
+			return err
+		}
+		m.formatIdentifier = id
+	}
+
 	m.registerPreprocessingWorkflow()
 	m.registerPostbatchWorkflow()
 
@@ -103,14 +138,81 @@ func (m *Main) registerPreprocessingWorkflow() {
 		temporalsdk_workflow.RegisterOptions{Name: m.cfg.Preprocessing.WorkflowName},
 	)
 
+	m.temporalWorker.RegisterActivityWithOptions(
+		activities.NewValidateStructure().Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.ValidateStructureName},
+	)
+
+	m.temporalWorker.RegisterActivityWithOptions(
+		activities.NewValidateContainerMD(m.cfg.Preprocessing.ValidateContainerMD).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.ValidateContainerMDName},
+	)
+
+	if m.cfg.Preprocessing.VerifyChecksums.Enabled {
+		m.temporalWorker.RegisterActivityWithOptions(
+			activities.NewVerifyChecksums(m.cfg.Preprocessing.VerifyChecksums).Execute,
+			temporalsdk_activity.RegisterOptions{Name: activities.VerifyChecksumsName},
+		)
+	}
+
+	if m.cfg.Preprocessing.MalwareScan.Enabled {
+		m.temporalWorker.RegisterActivityWithOptions(
+			activities.NewScanMalware(m.malwareScanner, m.cfg.Preprocessing.MalwareScan).Execute,
+			temporalsdk_activity.RegisterOptions{Name: activities.ScanMalwareName},
+		)
+	}
+
+	if m.cfg.Preprocessing.IdentifyFormats.Enabled {
+		m.temporalWorker.RegisterActivityWithOptions(
+			activities.NewIdentifyFormats(m.formatIdentifier, m.cfg.Preprocessing.IdentifyFormats).Execute,
+			temporalsdk_activity.RegisterOptions{Name: activities.IdentifyFormatsName},
+		)
+	}
+
+	if m.cfg.Preprocessing.ScanPII.Enabled {
+		m.temporalWorker.RegisterActivityWithOptions(
+			activities.NewScanPII(m.cfg.Preprocessing.ScanPII).Execute,
+			temporalsdk_activity.RegisterOptions{Name: activities.ScanPIIName},
+		)
+	}
+
+	if m.cfg.Preprocessing.LegalHold.Enabled {
+		m.temporalWorker.RegisterActivityWithOptions(
+			activities.NewCheckLegalHold(m.cfg.Preprocessing.SharedPath, m.cfg.Preprocessing.LegalHold).Execute,
+			temporalsdk_activity.RegisterOptions{Name: activities.CheckLegalHoldName},
+		)
+	}
+
+	if m.cfg.Preprocessing.Retention.Enabled {
+		m.temporalWorker.RegisterActivityWithOptions(
+			activities.NewCheckRetention(m.vanDocsLoc, m.cfg.Preprocessing.Retention).Execute,
+			temporalsdk_activity.RegisterOptions{Name: activities.CheckRetentionName},
+		)
+	}
+
+	m.temporalWorker.RegisterActivityWithOptions(
+		activities.NewCreateDCMetadata(m.vanDocsLoc, m.cfg.Preprocessing.DCMetadata).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CreateDCMetadataName},
+	)
+
+	m.temporalWorker.RegisterActivityWithOptions(
+		activities.NewCreateSIPCSV(m.ingestBucket, m.vanDocsLoc, m.cfg.Postbatch.CreateCSV).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CreateSIPCSVName},
+	)
+
+	m.temporalWorker.RegisterActivityWithOptions(
+		activities.NewCreateInventory(m.ingestBucket).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CreateInventoryName},
+	)
+
 	m.temporalWorker.RegisterActivityWithOptions(
 		bucketupload.New(m.ingestBucket).Execute,
 		temporalsdk_activity.RegisterOptions{Name: bucketupload.Name},
 	)
 
 	m.temporalWorker.RegisterActivityWithOptions(
-		bagcreate.New(m.cfg.Preprocessing.BagCreate).Execute,
-		temporalsdk_activity.RegisterOptions{Name: bagcreate.Name},
+		activities.NewCreateBag(m.vanDocsLoc, m.cfg.Preprocessing.BagCreate).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CreateBagName},
 	)
 }
 
@@ -121,12 +223,32 @@ func (m *Main) registerPostbatchWorkflow() {
 	)
 
 	m.temporalWorker.RegisterActivityWithOptions(
-		activities.NewCreateCSV(m.ingestBucket).Execute,
+		activities.NewCreateCSV(m.ingestBucket, m.vanDocsLoc, m.cfg.Postbatch.CreateCSV).Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.CreateCSVName},
 	)
 
 	m.temporalWorker.RegisterActivityWithOptions(
-		bucketdelete.New(m.ingestBucket).Execute,
-		temporalsdk_activity.RegisterOptions{Name: bucketdelete.Name},
+		activities.NewCreateDigitalObjectCSV(m.ingestBucket, m.cfg.Postbatch.DigitalObjectCSV).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CreateDigitalObjectCSVName},
+	)
+
+	m.temporalWorker.RegisterActivityWithOptions(
+		activities.NewCreateEAD(
+			m.ingestBucket,
+			m.vanDocsLoc,
+			m.cfg.Postbatch.CreateCSV,
+			m.cfg.Postbatch.EAD,
+		).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CreateEADName},
+	)
+
+	m.temporalWorker.RegisterActivityWithOptions(
+		activities.NewCreateBatchReport(m.ingestBucket, m.vanDocsLoc).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CreateBatchReportName},
+	)
+
+	m.temporalWorker.RegisterActivityWithOptions(
+		activities.NewDeleteSIPFiles(m.ingestBucket).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.DeleteSIPFilesName},
 	)
 }
@@ -0,0 +1,246 @@
+package activities
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const CheckLegalHoldName string = "check-legal-hold-activity"
+
+// Legal hold actions.
+const (
+	// LegalHoldActionFail fails the SIP preprocessing with a content error.
+	LegalHoldActionFail string = "fail"
+
+	// LegalHoldActionQuarantine moves the SIP to the quarantine directory for
+	// review.
+	LegalHoldActionQuarantine string = "quarantine"
+)
+
+// legalHoldQuarantineDir is the default quarantine directory, relative to the
+// shared path.
+const legalHoldQuarantineDir string = "quarantine"
+
+type LegalHoldConfig struct {
+	// Enabled adds the legal hold check of the SIP ContainerMetadata.xml file
+	// to the preprocessing workflow.
+	Enabled bool
+
+	// Dispositions lists Disposition field values, compared
+	// case-insensitively, that put a SIP on hold like the HasHolds field,
+	// e.g. "Litigation hold".
+	Dispositions []string
+
+	// Action sets what happens to a SIP on hold: "fail" or "quarantine"
+	// (default: "fail").
+	Action string
+
+	// QuarantineDir is the directory, relative to the preprocessing shared
+	// path, where SIPs on hold are moved in "quarantine" mode (default:
+	// "quarantine").
+	QuarantineDir string
+}
+
+func (c LegalHoldConfig) Validate() error {
+	var errs error
+
+	actions := []string{"", LegalHoldActionFail, LegalHoldActionQuarantine}
+	if !slices.Contains(actions, c.Action) {
+		errs = errors.Join(errs, fmt.Errorf(
+			"Preprocessing.LegalHold.Action: unknown value %q, must be %q or %q",
+			c.Action, LegalHoldActionFail, LegalHoldActionQuarantine,
+		))
+	}
+	if c.QuarantineDir != "" && !filepath.IsLocal(c.QuarantineDir) {
+		errs = errors.Join(errs, fmt.Errorf(
+			"Preprocessing.LegalHold.QuarantineDir: %q is not a relative path in the shared path",
+			c.QuarantineDir,
+		))
+	}
+
+	return errs
+}
+
+// action returns the configured action, or LegalHoldActionFail if not set.
+func (c LegalHoldConfig) action() string {
+	if c.Action == "" {
+		return LegalHoldActionFail
+	}
+	return c.Action
+}
+
+// quarantineDir returns the configured quarantine directory, or the default
+// if not set.
+func (c LegalHoldConfig) quarantineDir() string {
+	if c.QuarantineDir == "" {
+		return legalHoldQuarantineDir
+	}
+	return c.QuarantineDir
+}
+
+// CheckLegalHold is an activity that checks whether the SIP is on a legal
+// hold, i.e. its ContainerMetadata.xml HasHolds field is true or its
+// Disposition is one of the configured hold dispositions. SIPs on hold must
+// not be transferred to the archives without review.
+//
+// The check runs before the SIP is validated, so only the HasHolds and
+// Disposition fields are read: a SIP whose ContainerMetadata.xml file is
+// missing or can't be decoded is not on hold, and its problems are reported
+// by the validation activities.
+//
+// In "fail" mode a content error listing the reasons is returned. In
+// "quarantine" mode the SIP directory is moved to the quarantine directory in
+// the shared path, named after the SIP directory and the SIP UUID, and the
+// result reports its new path, so the workflow can stop without bagging the
+// SIP.
+type (
+	CheckLegalHold struct {
+		// sharedPath is the preprocessing shared path, which holds the
+		// quarantine directory.
+		sharedPath string
+
+		cfg LegalHoldConfig
+	}
+	CheckLegalHoldParams struct {
+		// Path is the absolute path of the SIP directory.
+		Path string
+
+		// SIPID is the SIP UUID, which keeps the quarantine paths of SIPs
+		// with the same directory name apart.
+		SIPID uuid.UUID
+	}
+	CheckLegalHoldResult struct {
+		// Reasons lists why the SIP is on hold, empty if it is not.
+		Reasons []string
+
+		// QuarantinePath is the absolute path of the SIP directory moved to
+		// quarantine, empty if the SIP is not on hold.
+		QuarantinePath string
+	}
+)
+
+// NewCheckLegalHold creates a new CheckLegalHold.
+func NewCheckLegalHold(sharedPath string, cfg LegalHoldConfig) *CheckLegalHold {
+	return &CheckLegalHold{
+		sharedPath: sharedPath,
+		cfg:        cfg,
+	}
+}
+
+func (a *CheckLegalHold) Execute(ctx context.Context, params *CheckLegalHoldParams) (*CheckLegalHoldResult, error) {
+	dest := filepath.Join(
+		a.sharedPath,
+		a.cfg.quarantineDir(),
+		fmt.Sprintf("%s_%s", filepath.Base(params.Path), params.SIPID),
+	)
+
+	// The SIP was moved to quarantine by a previous attempt.
+	if a.cfg.action() == LegalHoldActionQuarantine && !exists(params.Path) && exists(dest) {
+		h, err := readHoldFields(dest)
+		if err != nil {
+			return nil, fmt.Errorf("check legal hold: %w", err)
+		}
+		return &CheckLegalHoldResult{Reasons: a.reasons(h), QuarantinePath: dest}, nil
+	}
+
+	h, err := readHoldFields(params.Path)
+	if err != nil {
+		return nil, fmt.Errorf("check legal hold: %w", err)
+	}
+
+	reasons := a.reasons(h)
+	if len(reasons) == 0 {
+		return &CheckLegalHoldResult{Reasons: []string{}}, nil
+	}
+
+	if a.cfg.action() == LegalHoldActionFail {
+		return nil, NewContentError("The SIP is on a legal hold", reasons...)
+	}
+
+	if exists(dest) {
+		return nil, fmt.Errorf("check legal hold: quarantine: %s already exists", dest)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o750); err != nil {
+		return nil, fmt.Errorf("check legal hold: quarantine: %w", err)
+	}
+	if err := os.Rename(params.Path, dest); err != nil {
+		return nil, fmt.Errorf("check legal hold: quarantine: %w", err)
+	}
+
+	return &CheckLegalHoldResult{Reasons: reasons, QuarantinePath: dest}, nil
+}
+
+// holdFields holds the ContainerMetadata.xml fields that put a SIP on hold.
+type holdFields struct {
+	Container struct {
+		HasHolds    string `xml:"HasHolds"`
+		Disposition string `xml:"Disposition"`
+	} `xml:"Container"`
+}
+
+// readHoldFields reads the hold fields of the ContainerMetadata.xml file of
+// the SIP at sipPath. It returns empty fields if the file is missing, is not
+// a regular file or can't be decoded, which the validation activities report.
+func readHoldFields(sipPath string) (*holdFields, error) {
+	var h holdFields
+
+	name := filepath.Join(sipPath, containerMDPath)
+	fi, err := os.Lstat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read hold fields: %w", err)
+	}
+	if !fi.Mode().IsRegular() {
+		return &h, nil
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("read hold fields: %w", err)
+	}
+	defer f.Close()
+
+	if err := xml.NewDecoder(f).Decode(&h); err != nil {
+		return &holdFields{}, nil
+	}
+
+	return &h, nil
+}
+
+// reasons returns why a SIP with the given hold fields is on hold. A HasHolds
+// value that is not a boolean is reported by the validation activities.
+func (a *CheckLegalHold) reasons(h *holdFields) []string {
+	var reasons []string
+	if hasHolds, err := strconv.ParseBool(strings.TrimSpace(h.Container.HasHolds)); err == nil && hasHolds {
+		reasons = append(reasons, "HasHolds is true")
+	}
+
+	d := strings.TrimSpace(h.Container.Disposition)
+	for _, v := range a.cfg.Dispositions {
+		if d != "" && strings.EqualFold(d, strings.TrimSpace(v)) {
+			reasons = append(reasons, fmt.Sprintf("Disposition %q is a legal hold disposition", h.Container.Disposition))
+			break
+		}
+	}
+
+	return reasons
+}
+
+// exists reports whether the named file exists, without following symbolic
+// links.
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+	return !errors.Is(err, fs.ErrNotExist)
+}
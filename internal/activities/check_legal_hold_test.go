@@ -0,0 +1,168 @@
+package activities_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+)
+
+func TestCheckLegalHold_Execute(t *testing.T) {
+	t.Parallel()
+
+	sipID := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	quarantined := "SIP-1_" + sipID.String()
+
+	sip := func(extra string) fs.PathOp {
+		return fs.WithDir("SIP-1",
+			fs.WithDir("content", fs.WithFile("a.pdf", "a")),
+			fs.WithDir("metadata",
+				fs.WithDir("submissionDocumentation",
+					fs.WithFile("ContainerMetadata.xml", sipContainerMetadataXML(containerMDXMLParams{
+						recordNumber: "01-5000-12/2009-01",
+						extra:        extra,
+					})),
+				),
+			),
+		)
+	}
+
+	for _, tc := range []struct {
+		name           string
+		cfg            activities.LegalHoldConfig
+		ops            []fs.PathOp
+		want           *activities.CheckLegalHoldResult
+		wantQuarantine string
+		message        string
+		failures       []string
+		wantErr        string
+	}{
+		{
+			name: "accepts SIPs that are not on hold",
+			cfg:  activities.LegalHoldConfig{Dispositions: []string{"Litigation hold"}},
+			ops:  []fs.PathOp{sip("    <Disposition>Permanent archival</Disposition>\n")},
+			want: &activities.CheckLegalHoldResult{Reasons: []string{}},
+		},
+		{
+			name: "rejects SIPs on hold",
+			cfg:  activities.LegalHoldConfig{Dispositions: []string{"Litigation hold"}},
+			ops: []fs.PathOp{sip("    <Disposition>litigation HOLD</Disposition>\n" +
+				"    <HasHolds>true</HasHolds>\n")},
+			message: "The SIP is on a legal hold",
+			failures: []string{
+				"HasHolds is true",
+				`Disposition "litigation HOLD" is a legal hold disposition`,
+			},
+		},
+		{
+			name: "moves SIPs on hold to quarantine",
+			cfg: activities.LegalHoldConfig{
+				Action:        activities.LegalHoldActionQuarantine,
+				QuarantineDir: "held/sips",
+			},
+			ops:            []fs.PathOp{sip("    <HasHolds>true</HasHolds>\n")},
+			want:           &activities.CheckLegalHoldResult{Reasons: []string{"HasHolds is true"}},
+			wantQuarantine: "held/sips/" + quarantined,
+		},
+		{
+			name: "moves SIPs on hold with metadata errors to quarantine",
+			cfg:  activities.LegalHoldConfig{Action: activities.LegalHoldActionQuarantine},
+			ops: []fs.PathOp{sip("    <HasHolds>true</HasHolds>\n" +
+				"    <DateDueforDestruction>next year</DateDueforDestruction>\n")},
+			want:           &activities.CheckLegalHoldResult{Reasons: []string{"HasHolds is true"}},
+			wantQuarantine: "quarantine/" + quarantined,
+		},
+		{
+			name: "moves SIPs with the name of a quarantined SIP to quarantine",
+			cfg:  activities.LegalHoldConfig{Action: activities.LegalHoldActionQuarantine},
+			ops: []fs.PathOp{
+				sip("    <HasHolds>true</HasHolds>\n"),
+				fs.WithDir("quarantine", fs.WithDir("SIP-1_"+uuid.Nil.String())),
+			},
+			want:           &activities.CheckLegalHoldResult{Reasons: []string{"HasHolds is true"}},
+			wantQuarantine: "quarantine/" + quarantined,
+		},
+		{
+			name: "accepts SIPs without a ContainerMetadata.xml file",
+			cfg:  activities.LegalHoldConfig{Action: activities.LegalHoldActionQuarantine},
+			ops:  []fs.PathOp{fs.WithDir("SIP-1", fs.WithDir("content", fs.WithFile("a.pdf", "a")))},
+			want: &activities.CheckLegalHoldResult{Reasons: []string{}},
+		},
+		{
+			name: "reports SIPs moved to quarantine by a previous attempt",
+			cfg:  activities.LegalHoldConfig{Action: activities.LegalHoldActionQuarantine},
+			ops: []fs.PathOp{
+				fs.WithDir("quarantine", fs.WithDir(quarantined,
+					fs.WithDir("content", fs.WithFile("a.pdf", "a")),
+					fs.WithDir("metadata",
+						fs.WithDir("submissionDocumentation",
+							fs.WithFile("ContainerMetadata.xml", sipContainerMetadataXML(containerMDXMLParams{
+								recordNumber: "01-5000-12/2009-01",
+								extra:        "    <HasHolds>true</HasHolds>\n",
+							})),
+						),
+					),
+				)),
+			},
+			want:           &activities.CheckLegalHoldResult{Reasons: []string{"HasHolds is true"}},
+			wantQuarantine: "quarantine/" + quarantined,
+		},
+		{
+			name: "errors when the quarantine path is taken",
+			cfg:  activities.LegalHoldConfig{Action: activities.LegalHoldActionQuarantine},
+			ops: []fs.PathOp{
+				sip("    <HasHolds>true</HasHolds>\n"),
+				fs.WithDir("quarantine", fs.WithDir(quarantined)),
+			},
+			wantErr: "check legal hold: quarantine: ",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			shared := fs.NewDir(t, "cva-enduro-workflows-test", tc.ops...)
+
+			res, err := activities.NewCheckLegalHold(shared.Path(), tc.cfg).Execute(
+				t.Context(),
+				&activities.CheckLegalHoldParams{Path: shared.Join("SIP-1"), SIPID: sipID},
+			)
+			if tc.failures != nil {
+				assertContentError(t, err, tc.message, tc.failures)
+				return
+			}
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+			if tc.wantQuarantine != "" {
+				tc.want.QuarantinePath = shared.Join(tc.wantQuarantine)
+
+				_, err := os.Stat(shared.Join("SIP-1"))
+				assert.Assert(t, os.IsNotExist(err))
+				_, err = os.Stat(shared.Join(tc.wantQuarantine, "content", "a.pdf"))
+				assert.NilError(t, err)
+			}
+			assert.DeepEqual(t, res, tc.want)
+		})
+	}
+}
+
+func TestLegalHoldConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	assert.NilError(t, activities.LegalHoldConfig{
+		Action:        activities.LegalHoldActionQuarantine,
+		QuarantineDir: "quarantine/holds",
+	}.Validate())
+	assert.Error(t,
+		activities.LegalHoldConfig{Action: "delete", QuarantineDir: "../held"}.Validate(),
+		"Preprocessing.LegalHold.Action: unknown value \"delete\", must be \"fail\" or \"quarantine\"\n"+
+			"Preprocessing.LegalHold.QuarantineDir: \"../held\" is not a relative path in the shared path",
+	)
+}
@@ -0,0 +1,206 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	CheckRetentionName string = "check-retention-activity"
+
+	// Retention policy behaviours when a container is not due for permanent
+	// archival.
+	OnRetentionViolationFail string = "fail"
+	OnRetentionViolationWarn string = "warn"
+)
+
+// DefaultRetentionDispositions are the accepted Disposition values of a
+// retention policy without configured dispositions.
+var DefaultRetentionDispositions = []string{"Permanent archival"}
+
+type RetentionConfig struct {
+	// Enabled adds the retention schedule and disposition check of the SIP
+	// ContainerMetadata.xml file to the preprocessing workflow.
+	Enabled bool
+
+	// Default is the policy of the containers whose retention schedule has
+	// no policy in Schedules.
+	Default RetentionPolicy
+
+	// Schedules are the policies of specific retention schedules.
+	Schedules []RetentionPolicy
+}
+
+// RetentionPolicy sets which containers are due for permanent archival.
+type RetentionPolicy struct {
+	// Schedule is the RetentionSchedule field value the policy applies to,
+	// compared case-insensitively (required, except for the default policy).
+	Schedule string
+
+	// Dispositions lists the accepted Disposition field values, compared
+	// case-insensitively (default: DefaultRetentionDispositions).
+	Dispositions []string
+
+	// Tolerance is how far in the future the DateDueforPermanentArchival
+	// field date can be, e.g. "720h" (default: 0).
+	Tolerance time.Duration
+
+	// OnViolation sets what happens when a container is not due for
+	// permanent archival: "fail" rejects the SIP with a content error, "warn"
+	// reports the problems in the task message (default: "fail").
+	OnViolation string
+}
+
+func (c RetentionConfig) Validate() error {
+	errs := c.Default.validate("Preprocessing.Retention.Default")
+
+	schedules := map[string]bool{}
+	for i, p := range c.Schedules {
+		name := fmt.Sprintf("Preprocessing.Retention.Schedules[%d]", i)
+		key := strings.ToLower(strings.TrimSpace(p.Schedule))
+		if key == "" {
+			errs = errors.Join(errs, fmt.Errorf("%s.Schedule: missing required value", name))
+		} else if schedules[key] {
+			errs = errors.Join(errs, fmt.Errorf("%s.Schedule: duplicate schedule %q", name, p.Schedule))
+		}
+		schedules[key] = true
+
+		errs = errors.Join(errs, p.validate(name))
+	}
+
+	return errs
+}
+
+func (p RetentionPolicy) validate(name string) error {
+	var errs error
+	if p.Tolerance < 0 {
+		errs = errors.Join(errs, fmt.Errorf("%s.Tolerance: %s is negative", name, p.Tolerance))
+	}
+	if !slices.Contains([]string{"", OnRetentionViolationFail, OnRetentionViolationWarn}, p.OnViolation) {
+		errs = errors.Join(errs, fmt.Errorf(
+			"%s.OnViolation: unknown value %q, must be %q or %q",
+			name, p.OnViolation, OnRetentionViolationFail, OnRetentionViolationWarn,
+		))
+	}
+
+	return errs
+}
+
+// policy returns the policy of the given retention schedule, or the default
+// policy if the schedule has none.
+func (c RetentionConfig) policy(schedule string) RetentionPolicy {
+	schedule = strings.TrimSpace(schedule)
+	for _, p := range c.Schedules {
+		if strings.EqualFold(strings.TrimSpace(p.Schedule), schedule) {
+			return p
+		}
+	}
+
+	return c.Default
+}
+
+// dispositions returns the accepted dispositions, or the default dispositions
+// if none are configured.
+func (p RetentionPolicy) dispositions() []string {
+	if len(p.Dispositions) == 0 {
+		return DefaultRetentionDispositions
+	}
+	return p.Dispositions
+}
+
+// CheckRetention is an activity that checks the SIP ContainerMetadata.xml
+// retention fields against the retention policy of its RetentionSchedule, so
+// records slated for destruction are not preserved.
+//
+// A container is not due for permanent archival if its Disposition is not
+// one of the policy dispositions, or if its DateDueforPermanentArchival date
+// is further in the future than the policy tolerance. The problems are
+// reported as a content error, or as warnings in the result if the policy
+// OnViolation is "warn".
+type (
+	CheckRetention struct {
+		// loc is the time zone used to parse VanDocs dates without a time
+		// zone offset.
+		loc *time.Location
+
+		cfg RetentionConfig
+	}
+	CheckRetentionParams struct {
+		// Path is the absolute path of the SIP directory.
+		Path string
+	}
+	CheckRetentionResult struct {
+		// Schedule is the SIP retention schedule.
+		Schedule string
+
+		// Warnings lists the retention policy problems, if the policy
+		// OnViolation is "warn".
+		Warnings []string
+	}
+)
+
+// NewCheckRetention creates a new CheckRetention.
+func NewCheckRetention(loc *time.Location, cfg RetentionConfig) *CheckRetention {
+	return &CheckRetention{
+		loc: loc,
+		cfg: cfg,
+	}
+}
+
+func (a *CheckRetention) Execute(ctx context.Context, params *CheckRetentionParams) (*CheckRetentionResult, error) {
+	md, err := parseSIPContainerMD(params.Path, a.loc)
+	if err != nil {
+		return nil, fmt.Errorf("check retention: %w", err)
+	}
+
+	c := md.Container
+	policy := a.cfg.policy(c.RetentionSchedule)
+
+	var problems []string
+	disposition := strings.TrimSpace(c.Disposition)
+	if !slices.ContainsFunc(policy.dispositions(), func(d string) bool {
+		return strings.EqualFold(strings.TrimSpace(d), disposition)
+	}) {
+		if disposition == "" {
+			problems = append(problems, "Disposition is empty")
+		} else {
+			problems = append(problems, fmt.Sprintf("Disposition %q is not a permanent archival disposition", c.Disposition))
+		}
+	}
+
+	due := c.DateDueforPermanentArchival
+	if !due.IsZero() && due.After(time.Now().Add(policy.Tolerance)) {
+		if policy.Tolerance == 0 {
+			problems = append(problems, fmt.Sprintf(
+				"DateDueforPermanentArchival %s is in the future",
+				due.Format(time.DateOnly),
+			))
+		} else {
+			problems = append(problems, fmt.Sprintf(
+				"DateDueforPermanentArchival %s is more than %s in the future",
+				due.Format(time.DateOnly), policy.Tolerance,
+			))
+		}
+	}
+
+	res := &CheckRetentionResult{Schedule: c.RetentionSchedule, Warnings: []string{}}
+	if len(problems) == 0 {
+		return res, nil
+	}
+
+	if policy.OnViolation == OnRetentionViolationWarn {
+		res.Warnings = problems
+		return res, nil
+	}
+
+	msg := "The SIP is not due for permanent archival"
+	if c.RetentionSchedule != "" {
+		msg += fmt.Sprintf(" under retention schedule %q", c.RetentionSchedule)
+	}
+
+	return nil, NewContentError(msg, problems...)
+}
@@ -0,0 +1,131 @@
+package activities_test
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+)
+
+func TestCheckRetention_Execute(t *testing.T) {
+	t.Parallel()
+
+	nextYear := time.Now().AddDate(1, 0, 0).Format(time.DateOnly)
+	nextWeek := time.Now().AddDate(0, 0, 7).Format(time.DateOnly)
+
+	sip := func(schedule, disposition, due string) []fs.PathOp {
+		extra := "    <RetentionSchedule>" + schedule + "</RetentionSchedule>\n" +
+			"    <Disposition>" + disposition + "</Disposition>\n" +
+			"    <DateDueforPermanentArchival>" + due + "</DateDueforPermanentArchival>\n"
+		return []fs.PathOp{
+			fs.WithDir("metadata",
+				fs.WithDir("submissionDocumentation",
+					fs.WithFile("ContainerMetadata.xml", sipContainerMetadataXML(containerMDXMLParams{
+						recordNumber: "01-5000-12/2009-01",
+						extra:        extra,
+					})),
+				),
+			),
+		}
+	}
+
+	cfg := activities.RetentionConfig{
+		Schedules: []activities.RetentionPolicy{
+			{
+				Schedule:     "ARCS 100-01",
+				Dispositions: []string{"Selective retention", "Full retention"},
+				Tolerance:    30 * 24 * time.Hour,
+			},
+			{
+				Schedule:    "ORCS 200-02",
+				OnViolation: activities.OnRetentionViolationWarn,
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		ops      []fs.PathOp
+		want     *activities.CheckRetentionResult
+		message  string
+		failures []string
+	}{
+		{
+			name: "accepts containers due for permanent archival",
+			ops:  sip("ARCS 999-99", "permanent archival", "2020-01-01"),
+			want: &activities.CheckRetentionResult{Schedule: "ARCS 999-99", Warnings: []string{}},
+		},
+		{
+			name: "applies the policy of the retention schedule",
+			ops:  sip("arcs 100-01", "Full retention", nextWeek),
+			want: &activities.CheckRetentionResult{Schedule: "arcs 100-01", Warnings: []string{}},
+		},
+		{
+			name:    "rejects containers slated for destruction",
+			ops:     sip("ARCS 100-01", "Destruction", nextYear),
+			message: `The SIP is not due for permanent archival under retention schedule "ARCS 100-01"`,
+			failures: []string{
+				`Disposition "Destruction" is not a permanent archival disposition`,
+				"DateDueforPermanentArchival " + nextYear + " is more than 720h0m0s in the future",
+			},
+		},
+		{
+			name:    "rejects containers without a disposition",
+			ops:     sip("", "", nextWeek),
+			message: "The SIP is not due for permanent archival",
+			failures: []string{
+				"Disposition is empty",
+				"DateDueforPermanentArchival " + nextWeek + " is in the future",
+			},
+		},
+		{
+			name: "warns about containers not due for permanent archival",
+			ops:  sip("ORCS 200-02", "Destruction", ""),
+			want: &activities.CheckRetentionResult{
+				Schedule: "ORCS 200-02",
+				Warnings: []string{`Disposition "Destruction" is not a permanent archival disposition`},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := fs.NewDir(t, "cva-enduro-workflows-test", tc.ops...)
+
+			res, err := activities.NewCheckRetention(time.UTC, cfg).Execute(
+				t.Context(),
+				&activities.CheckRetentionParams{Path: dir.Path()},
+			)
+			if tc.failures != nil {
+				assertContentError(t, err, tc.message, tc.failures)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.DeepEqual(t, res, tc.want)
+		})
+	}
+}
+
+func TestRetentionConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	assert.NilError(t, activities.RetentionConfig{}.Validate())
+	assert.Error(t,
+		activities.RetentionConfig{
+			Default: activities.RetentionPolicy{OnViolation: "ignore"},
+			Schedules: []activities.RetentionPolicy{
+				{Schedule: "ARCS 100-01"},
+				{Schedule: "arcs 100-01 ", Tolerance: -time.Hour},
+				{},
+			},
+		}.Validate(),
+		"Preprocessing.Retention.Default.OnViolation: unknown value \"ignore\", must be \"fail\" or \"warn\"\n"+
+			"Preprocessing.Retention.Schedules[1].Schedule: duplicate schedule \"arcs 100-01 \"\n"+
+			"Preprocessing.Retention.Schedules[1].Tolerance: -1h0m0s is negative\n"+
+			"Preprocessing.Retention.Schedules[2].Schedule: missing required value",
+	)
+}
@@ -0,0 +1,79 @@
+package activities
+
+import (
+	"bufio"
+	"crypto/md5"  //#nosec G501 -- MD5 is a supported BagIt checksum algorithm.
+	"crypto/sha1" //#nosec G505 -- SHA-1 is a supported BagIt checksum algorithm.
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+)
+
+// Checksum algorithms.
+const (
+	ChecksumMD5    string = "md5"
+	ChecksumSHA1   string = "sha1"
+	ChecksumSHA256 string = "sha256"
+	ChecksumSHA512 string = "sha512"
+)
+
+// checksumAlgorithms maps the supported checksum algorithms to their hash
+// constructors.
+var checksumAlgorithms = map[string]func() hash.Hash{
+	ChecksumMD5:    md5.New,
+	ChecksumSHA1:   sha1.New,
+	ChecksumSHA256: sha256.New,
+	ChecksumSHA512: sha512.New,
+}
+
+func validateChecksumAlgorithm(name, alg string) error {
+	if _, ok := checksumAlgorithms[alg]; !ok {
+		return fmt.Errorf(
+			"%s: unknown algorithm %q, must be %q, %q, %q or %q",
+			name, alg, ChecksumMD5, ChecksumSHA1, ChecksumSHA256, ChecksumSHA512,
+		)
+	}
+
+	return nil
+}
+
+// hashFile returns the hex encoded checksum of the named file.
+func hashFile(name string, newHash func() hash.Hash, p *fileProgress) (string, error) {
+	sums, err := hashFileAll(name, []func() hash.Hash{newHash}, p)
+	if err != nil {
+		return "", err
+	}
+
+	return sums[0], nil
+}
+
+// hashFileAll returns the hex encoded checksums of the named file, one for
+// each hash constructor in newHashes, reading the file once.
+func hashFileAll(name string, newHashes []func() hash.Hash, p *fileProgress) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hashes := make([]hash.Hash, len(newHashes))
+	writers := []io.Writer{p}
+	for i, newHash := range newHashes {
+		hashes[i] = newHash()
+		writers = append(writers, hashes[i])
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), bufio.NewReader(f)); err != nil {
+		return nil, err
+	}
+
+	sums := make([]string, len(hashes))
+	for i, h := range hashes {
+		sums[i] = hex.EncodeToString(h.Sum(nil))
+	}
+
+	return sums, nil
+}
@@ -0,0 +1,441 @@
+package activities
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/artefactual-sdps/temporal-activities/bagcreate"
+	"github.com/google/uuid"
+	temporalsdk_activity "go.temporal.io/sdk/activity"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/types"
+)
+
+const CreateBagName string = "create-bag-activity"
+
+// bagInfoLabel matches the valid bag-info.txt tag labels.
+var bagInfoLabel = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// reservedBagInfoLabels are the bag-info.txt tags written by the bagcreate
+// library, which can't be set in the configuration.
+var reservedBagInfoLabels = []string{"Bag-Software-Agent", "Bagging-Date", "Payload-Oxum"}
+
+// manifestPathDecoder decodes the BagIt manifest paths.
+var manifestPathDecoder = strings.NewReplacer("%0D", "\r", "%0A", "\n", "%25", "%")
+
+const (
+	// bagTimeoutPerGiB is the default bag creation time allowed for each GiB
+	// of SIP files.
+	bagTimeoutPerGiB = 2 * time.Minute
+
+	// bagHeartbeatInterval is the interval between the heartbeats recorded
+	// while the library creates the bag, if the activity has no heartbeat
+	// timeout.
+	bagHeartbeatInterval = 10 * time.Second
+
+	// minBagLibraryTime is the minimum time allowed for the library to create
+	// the bag before the heartbeats stop.
+	minBagLibraryTime = time.Minute
+)
+
+type CreateBagConfig struct {
+	// ChecksumAlgorithm is the algorithm used for the bag manifests: "md5",
+	// "sha1", "sha256" or "sha512" (default: "sha512"). It is ignored if
+	// ChecksumAlgorithms is set.
+	ChecksumAlgorithm string
+
+	// ChecksumAlgorithms are the algorithms used for the bag manifests, a
+	// manifest and tag manifest is written for each of them. The bag is
+	// created with the first algorithm.
+	ChecksumAlgorithms []string
+
+	// TimeoutPerGiB is the bag creation time allowed for each GiB of SIP
+	// files, added to a 10 minute minimum timeout (default: 2m).
+	TimeoutPerGiB time.Duration
+
+	// BagInfo are static tags added to the bag-info.txt file of every bag,
+	// after the tags derived from the ContainerMetadata.xml file.
+	BagInfo []BagInfoTag
+}
+
+// BagInfoTag is a bag-info.txt tag.
+type BagInfoTag struct {
+	// Label is the tag label, e.g. "Contact-Email" (required).
+	Label string
+
+	// Value is the tag value.
+	Value string
+}
+
+func (c CreateBagConfig) Validate() error {
+	var errs error
+
+	if len(c.ChecksumAlgorithms) == 0 {
+		errs = errors.Join(errs, validateChecksumAlgorithm(
+			"Preprocessing.BagCreate.ChecksumAlgorithm", c.ChecksumAlgorithm,
+		))
+	}
+	for i, alg := range c.ChecksumAlgorithms {
+		errs = errors.Join(errs, validateChecksumAlgorithm(
+			fmt.Sprintf("Preprocessing.BagCreate.ChecksumAlgorithms[%d]", i), alg,
+		))
+		if slices.Contains(c.ChecksumAlgorithms[:i], alg) {
+			errs = errors.Join(errs, fmt.Errorf(
+				"Preprocessing.BagCreate.ChecksumAlgorithms[%d]: duplicate algorithm %q", i, alg,
+			))
+		}
+	}
+	for i, tag := range c.BagInfo {
+		name := fmt.Sprintf("Preprocessing.BagCreate.BagInfo[%d]", i)
+		switch {
+		case !bagInfoLabel.MatchString(tag.Label):
+			errs = errors.Join(errs, fmt.Errorf("%s.Label: invalid label %q", name, tag.Label))
+		case slices.ContainsFunc(reservedBagInfoLabels, func(l string) bool {
+			return strings.EqualFold(l, tag.Label)
+		}):
+			errs = errors.Join(errs, fmt.Errorf("%s.Label: %q is set by the bag creation", name, tag.Label))
+		}
+		if strings.ContainsAny(tag.Value, "\r\n") {
+			errs = errors.Join(errs, fmt.Errorf("%s.Value: must be a single line", name))
+		}
+	}
+	if c.TimeoutPerGiB < 0 {
+		errs = errors.Join(errs, fmt.Errorf(
+			"Preprocessing.BagCreate.TimeoutPerGiB: %s is negative", c.TimeoutPerGiB,
+		))
+	}
+
+	return errs
+}
+
+// algorithms returns the bag manifest checksum algorithms.
+func (c CreateBagConfig) algorithms() []string {
+	if len(c.ChecksumAlgorithms) > 0 {
+		return c.ChecksumAlgorithms
+	}
+
+	return []string{c.ChecksumAlgorithm}
+}
+
+// Timeout returns the bag creation timeout for a SIP of size bytes: 10 minutes
+// plus TimeoutPerGiB for each GiB of SIP files.
+func (c CreateBagConfig) Timeout(size int64) time.Duration {
+	return sizeTimeout(size, c.TimeoutPerGiB, bagTimeoutPerGiB)
+}
+
+// CreateBag is an activity that converts a SIP directory to a BagIt bag in
+// place with the temporal-activities bagcreate activity.
+//
+// The library doesn't report its progress, so the activity first hashes every
+// SIP file with all the configured checksum algorithms, reading each file
+// once, and records a heartbeat with the FileProgress after each file, and at
+// least every 64 MiB while hashing large files. The library then creates the
+// bag with the first algorithm. While it runs the heartbeats go on for twice
+// the time taken by the hashing, or at least a minute, so a hung library is
+// detected by the heartbeat timeout without timing out the bagging of large
+// SIPs.
+//
+// The library manifest is checked against the activity checksums, a payload
+// manifest is added for each other configured algorithm and the tag manifests
+// are rewritten for every algorithm. Besides the tags written by the library,
+// the bag-info.txt file records the provenance of the SIP taken from its
+// ContainerMetadata.xml file (see bagInfo), the batch UUID as the
+// Bag-Group-Identifier, and the configured static tags.
+type (
+	CreateBag struct {
+		// loc is the time zone used to parse VanDocs dates without a time
+		// zone offset.
+		loc *time.Location
+
+		cfg CreateBagConfig
+	}
+	CreateBagParams struct {
+		// Path is the absolute path of the SIP directory.
+		Path string
+
+		// BatchID is the Enduro batch UUID, or uuid.Nil if the SIP is not
+		// part of a batch.
+		BatchID uuid.UUID
+	}
+	CreateBagResult struct {
+		// Path is the absolute path of the bag directory.
+		Path string
+
+		// Files is the number of payload files.
+		Files int
+
+		// Bytes is the total size of the payload files.
+		Bytes int64
+	}
+)
+
+// NewCreateBag creates a new CreateBag.
+func NewCreateBag(loc *time.Location, cfg CreateBagConfig) *CreateBag {
+	return &CreateBag{
+		loc: loc,
+		cfg: cfg,
+	}
+}
+
+func (a *CreateBag) Execute(ctx context.Context, params *CreateBagParams) (*CreateBagResult, error) {
+	algs := a.cfg.algorithms()
+	for _, alg := range algs {
+		if _, ok := checksumAlgorithms[alg]; !ok {
+			return nil, fmt.Errorf("create bag: unknown checksum algorithm %q", alg)
+		}
+	}
+
+	md, err := parseSIPContainerMD(params.Path, a.loc)
+	if err != nil {
+		return nil, fmt.Errorf("create bag: %w", err)
+	}
+
+	p := &fileProgress{ctx: ctx}
+	start := time.Now()
+	sums, err := hashPayload(ctx, params.Path, algs, p)
+	if err != nil {
+		return nil, fmt.Errorf("create bag: %w", err)
+	}
+
+	if err := bagSIP(ctx, params.Path, algs[0], p, max(2*time.Since(start), minBagLibraryTime)); err != nil {
+		return nil, fmt.Errorf("create bag: %w", err)
+	}
+
+	paths, err := checkBagManifest(params.Path, algs[0], sums)
+	if err != nil {
+		return nil, fmt.Errorf("create bag: %w", err)
+	}
+
+	for i, alg := range algs[1:] {
+		var b []byte
+		for _, path := range paths {
+			b = fmt.Appendf(b, "%s  %s\n", sums[manifestPathDecoder.Replace(path)][i+1], path)
+		}
+		name := fmt.Sprintf("manifest-%s.txt", alg)
+		if err := os.WriteFile(filepath.Join(params.Path, name), b, 0o644); err != nil {
+			return nil, fmt.Errorf("create bag: write %s: %w", name, err)
+		}
+	}
+
+	infoPath := filepath.Join(params.Path, "bag-info.txt")
+	info, err := os.ReadFile(infoPath)
+	if err != nil {
+		return nil, fmt.Errorf("create bag: read bag-info.txt: %w", err)
+	}
+	if len(info) > 0 && info[len(info)-1] != '\n' {
+		info = append(info, '\n')
+	}
+	info = append(info, bagInfo(md, params.BatchID, a.cfg.BagInfo)...)
+	if err := os.WriteFile(infoPath, info, 0o644); err != nil {
+		return nil, fmt.Errorf("create bag: write bag-info.txt: %w", err)
+	}
+
+	if err := writeBagTagManifests(params.Path, algs); err != nil {
+		return nil, fmt.Errorf("create bag: %w", err)
+	}
+
+	return &CreateBagResult{
+		Path:  params.Path,
+		Files: p.Files,
+		Bytes: p.Bytes,
+	}, nil
+}
+
+// hashPayload hashes every file in the SIP directory at root, before it is
+// bagged, and returns the checksums of each file for each algorithm in algs,
+// keyed by the file path in the bag, e.g. "data/content/report.pdf".
+func hashPayload(ctx context.Context, root string, algs []string, p *fileProgress) (map[string][]string, error) {
+	newHashes := make([]func() hash.Hash, len(algs))
+	for i, alg := range algs {
+		newHashes[i] = checksumAlgorithms[alg]
+	}
+
+	sums := map[string][]string{}
+	err := filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		// Stop if the activity has been cancelled, e.g. after a heartbeat
+		// timeout.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return err
+		}
+
+		s, err := hashFileAll(name, newHashes, p)
+		if err != nil {
+			return fmt.Errorf("hash %s: %w", rel, err)
+		}
+		sums[path.Join("data", filepath.ToSlash(rel))] = s
+		p.fileDone()
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("hash payload: %w", err)
+	}
+
+	return sums, nil
+}
+
+// bagSIP bags the SIP directory at sipPath in place with the bagcreate
+// activity.
+// It records a heartbeat with the hashing progress p every half heartbeat
+// timeout until the library returns or the budget runs out.
+func bagSIP(ctx context.Context, sipPath, alg string, p *fileProgress, budget time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := bagcreate.New(bagcreate.Config{ChecksumAlgorithm: alg}).Execute(
+			ctx, &bagcreate.Params{SourcePath: sipPath},
+		)
+		done <- err
+	}()
+
+	interval := bagHeartbeatInterval
+	if temporalsdk_activity.IsActivity(ctx) {
+		if t := temporalsdk_activity.GetInfo(ctx).HeartbeatTimeout; t > 0 {
+			interval = t / 2
+		}
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	deadline := time.Now().Add(budget)
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				return fmt.Errorf("bag SIP: %w", err)
+			}
+			return nil
+		case now := <-t.C:
+			// Stop the heartbeats once the budget has run out, so the
+			// heartbeat timeout fails a hung library.
+			if now.Before(deadline) {
+				p.heartbeat()
+			}
+		}
+	}
+}
+
+// checkBagManifest checks that the alg manifest of the bag at root lists the
+// payload files hashed before bagging, with the same checksums, and returns
+// the listed paths, percent-encoded as in the manifest, in lexical order.
+func checkBagManifest(root, alg string, sums map[string][]string) ([]string, error) {
+	name := fmt.Sprintf("manifest-%s.txt", alg)
+	b, err := os.ReadFile(filepath.Join(root, name))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+
+	var paths []string
+	for line := range strings.Lines(string(b)) {
+		sum, p, ok := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+		if !ok {
+			continue
+		}
+		p = strings.TrimLeft(p, " ")
+
+		want, ok := sums[manifestPathDecoder.Replace(p)]
+		if !ok {
+			return nil, fmt.Errorf("check %s: unexpected path %q", name, p)
+		}
+		if !strings.EqualFold(sum, want[0]) {
+			return nil, fmt.Errorf("check %s: checksum mismatch for %q", name, p)
+		}
+		paths = append(paths, p)
+	}
+	if len(paths) != len(sums) {
+		return nil, fmt.Errorf("check %s: %d files listed, %d expected", name, len(paths), len(sums))
+	}
+	slices.Sort(paths)
+
+	return paths, nil
+}
+
+// writeBagTagManifests writes a tag manifest for each algorithm in algs to the
+// bag at root, listing the tag files in the bag root.
+func writeBagTagManifests(root string, algs []string) error {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return fmt.Errorf("read bag directory: %w", err)
+	}
+
+	tagManifests := make([][]byte, len(algs))
+	for _, e := range entries {
+		if !e.Type().IsRegular() || strings.HasPrefix(e.Name(), "tagmanifest-") {
+			continue
+		}
+
+		b, err := os.ReadFile(filepath.Join(root, e.Name()))
+		if err != nil {
+			return fmt.Errorf("read %s: %w", e.Name(), err)
+		}
+		for i, alg := range algs {
+			h := checksumAlgorithms[alg]()
+			h.Write(b)
+			tagManifests[i] = fmt.Appendf(tagManifests[i], "%s  %s\n", hex.EncodeToString(h.Sum(nil)), e.Name())
+		}
+	}
+
+	for i, alg := range algs {
+		name := fmt.Sprintf("tagmanifest-%s.txt", alg)
+		if err := os.WriteFile(filepath.Join(root, name), tagManifests[i], 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// bagInfo returns the bag-info.txt tags added to the tags written by the
+// library. The SIP provenance tags are mapped from the ContainerMetadata.xml
+// file:
+//
+//   - Source-Organization: the Department or OPR field
+//   - External-Identifier: the RecordNumber field
+//   - External-Description: the TitleFreeTextPart field
+//
+// Tags with an empty value are left out, and line breaks in the values are
+// replaced with spaces.
+func bagInfo(md *types.ContainerMD, batchID uuid.UUID, static []BagInfoTag) []byte {
+	tags := []BagInfoTag{
+		{Label: "Source-Organization", Value: md.SourceOrganization()},
+		{Label: "External-Identifier", Value: md.Container.RecordNumber},
+		{Label: "External-Description", Value: md.Title()},
+	}
+	if batchID != uuid.Nil {
+		tags = append(tags, BagInfoTag{Label: "Bag-Group-Identifier", Value: batchID.String()})
+	}
+	tags = append(tags, static...)
+
+	var b []byte
+	for _, tag := range tags {
+		value := strings.Join(strings.Fields(tag.Value), " ")
+		if value == "" {
+			continue
+		}
+		b = fmt.Appendf(b, "%s: %s\n", tag.Label, value)
+	}
+
+	return b
+}
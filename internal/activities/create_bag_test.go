@@ -0,0 +1,286 @@
+package activities_test
+
+import (
+	"crypto/md5" //#nosec G501 -- MD5 is a supported BagIt checksum algorithm.
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	temporalsdk_activity "go.temporal.io/sdk/activity"
+	temporalsdk_converter "go.temporal.io/sdk/converter"
+	temporalsdk_testsuite "go.temporal.io/sdk/testsuite"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+)
+
+func TestCreateBag_Execute(t *testing.T) {
+	t.Parallel()
+
+	containerMD := fs.WithDir("submissionDocumentation",
+		fs.WithFile("ContainerMetadata.xml", sipContainerMetadataXML(containerMDXMLParams{
+			recordNumber:      "01-1000-30/0000007",
+			titleFreeTextPart: "Council minutes",
+		})),
+	)
+	sipOps := []fs.PathOp{
+		fs.WithDir("content",
+			fs.WithFile("a.pdf", "a"),
+			fs.WithDir("sub", fs.WithFile("b.pdf", "bb")),
+		),
+		fs.WithDir("metadata", fs.WithFile("metadata.csv", "ccc"), containerMD),
+	}
+	mdPath := "data/metadata/submissionDocumentation/ContainerMetadata.xml"
+	sha256Manifest := map[string]string{
+		"data/content/a.pdf":         "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb",
+		"data/content/sub/b.pdf":     "3b64db95cb55c763391c707108489ae18b4112d783300de38e033b4c98c3deaf",
+		"data/metadata/metadata.csv": "64daa44ad493ff28a96effab6e77f1732a3d97d83241581b37dbd70a7a4900fe",
+		mdPath:                       "ec57759b913ed1a289843e77dfead8a32278c89b64e59ba0ce3e77db1b0d6968",
+	}
+
+	for _, tc := range []struct {
+		name          string
+		cfg           activities.CreateBagConfig
+		ops           []fs.PathOp
+		batchID       uuid.UUID
+		wantManifests map[string]map[string]string
+		wantOxum      string
+		wantFiles     []string
+		wantTagFiles  []string
+		wantBagInfo   string
+		wantErr       string
+	}{
+		{
+			name: "bags a SIP in place",
+			cfg: activities.CreateBagConfig{
+				ChecksumAlgorithm: activities.ChecksumSHA256,
+				BagInfo: []activities.BagInfoTag{
+					{Label: "Contact-Email", Value: "archives@vancouver.ca"},
+					{Label: "Internal-Sender-Description", Value: ""},
+				},
+			},
+			ops:     sipOps,
+			batchID: uuid.MustParse("223e4567-e89b-12d3-a456-426614174000"),
+			wantBagInfo: "Source-Organization: COV - Office of Custody (OPR)\n" +
+				"External-Identifier: 01-1000-30/0000007\n" +
+				"External-Description: Council minutes\n" +
+				"Bag-Group-Identifier: 223e4567-e89b-12d3-a456-426614174000\n" +
+				"Contact-Email: archives@vancouver.ca\n",
+			wantManifests: map[string]map[string]string{
+				"manifest-sha256.txt": sha256Manifest,
+			},
+			wantOxum: "399.4",
+			wantFiles: []string{
+				"bag-info.txt",
+				"bagit.txt",
+				"data",
+				"manifest-sha256.txt",
+				"tagmanifest-sha256.txt",
+			},
+			wantTagFiles: []string{"bag-info.txt", "bagit.txt", "manifest-sha256.txt"},
+		},
+		{
+			name: "writes a manifest for each checksum algorithm",
+			cfg: activities.CreateBagConfig{
+				ChecksumAlgorithm:  activities.ChecksumSHA512,
+				ChecksumAlgorithms: []string{activities.ChecksumSHA256, activities.ChecksumMD5},
+			},
+			ops: sipOps,
+			wantBagInfo: "Source-Organization: COV - Office of Custody (OPR)\n" +
+				"External-Identifier: 01-1000-30/0000007\n" +
+				"External-Description: Council minutes\n",
+			wantManifests: map[string]map[string]string{
+				"manifest-sha256.txt": sha256Manifest,
+				"manifest-md5.txt": {
+					"data/content/a.pdf":         "0cc175b9c0f1b6a831c399e269772661",
+					"data/content/sub/b.pdf":     "21ad0bd836b90d08f4cf640b4c298e7c",
+					"data/metadata/metadata.csv": "9df62e693988eb4e1e1444ece0578579",
+					mdPath:                       "a015b3a658b14cd45af0478e5b3bfb42",
+				},
+			},
+			wantOxum: "399.4",
+			wantFiles: []string{
+				"bag-info.txt",
+				"bagit.txt",
+				"data",
+				"manifest-md5.txt",
+				"manifest-sha256.txt",
+				"tagmanifest-md5.txt",
+				"tagmanifest-sha256.txt",
+			},
+			wantTagFiles: []string{"bag-info.txt", "bagit.txt", "manifest-md5.txt", "manifest-sha256.txt"},
+		},
+		{
+			name:    "errors when the checksum algorithm is unknown",
+			cfg:     activities.CreateBagConfig{ChecksumAlgorithm: "crc32"},
+			ops:     sipOps,
+			wantErr: `create bag: unknown checksum algorithm "crc32"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := fs.NewDir(t, "cva-enduro-workflows-test", tc.ops...)
+
+			var ts temporalsdk_testsuite.WorkflowTestSuite
+			env := ts.NewTestActivityEnvironment()
+			env.RegisterActivityWithOptions(
+				activities.NewCreateBag(time.UTC, tc.cfg).Execute,
+				temporalsdk_activity.RegisterOptions{Name: activities.CreateBagName},
+			)
+
+			var heartbeats []activities.FileProgress
+			env.SetOnActivityHeartbeatListener(
+				func(_ *temporalsdk_activity.Info, details temporalsdk_converter.EncodedValues) {
+					var p activities.FileProgress
+					assert.NilError(t, details.Get(&p))
+					heartbeats = append(heartbeats, p)
+				},
+			)
+
+			enc, err := env.ExecuteActivity(
+				activities.CreateBagName,
+				&activities.CreateBagParams{Path: dir.Path(), BatchID: tc.batchID},
+			)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+
+			var res activities.CreateBagResult
+			assert.NilError(t, enc.Get(&res))
+			assert.DeepEqual(t, res, activities.CreateBagResult{Path: dir.Path(), Files: 4, Bytes: 399})
+
+			entries, err := os.ReadDir(dir.Path())
+			assert.NilError(t, err)
+			var names []string
+			for _, e := range entries {
+				names = append(names, e.Name())
+			}
+			assert.DeepEqual(t, names, tc.wantFiles)
+
+			for name, want := range tc.wantManifests {
+				assert.DeepEqual(t, readManifest(t, dir.Join(name)), want)
+			}
+
+			for _, name := range tc.wantFiles {
+				alg, ok := strings.CutPrefix(name, "tagmanifest-")
+				if !ok {
+					continue
+				}
+
+				// Every tag file is listed with its checksum.
+				tagManifest := readManifest(t, dir.Join(name))
+				assert.DeepEqual(t, slices.Sorted(maps.Keys(tagManifest)), tc.wantTagFiles)
+				for file, sum := range tagManifest {
+					assert.Equal(t, sum, fileChecksum(t, strings.TrimSuffix(alg, ".txt"), dir.Join(file)), file)
+				}
+			}
+
+			bagInfo, err := os.ReadFile(dir.Join("bag-info.txt"))
+			assert.NilError(t, err)
+			assert.Assert(t, strings.Contains(string(bagInfo), "Payload-Oxum: "+tc.wantOxum+"\n"), string(bagInfo))
+			assert.Assert(t, strings.HasSuffix(string(bagInfo), "\n"+tc.wantBagInfo), string(bagInfo))
+
+			// Heartbeats are throttled, so only check the first one, recorded
+			// after hashing "content/a.pdf" before bagging.
+			assert.Assert(t, len(heartbeats) > 0)
+			assert.DeepEqual(t, heartbeats[0], activities.FileProgress{Files: 1, Bytes: 1})
+		})
+	}
+}
+
+// readManifest parses the named BagIt manifest and returns the checksums by
+// path.
+func readManifest(t *testing.T, name string) map[string]string {
+	t.Helper()
+
+	b, err := os.ReadFile(name)
+	assert.NilError(t, err)
+
+	sums := map[string]string{}
+	for line := range strings.Lines(string(b)) {
+		sum, path, ok := strings.Cut(strings.TrimSpace(line), " ")
+		assert.Assert(t, ok, "invalid manifest line %q", line)
+		sums[strings.TrimSpace(path)] = sum
+	}
+
+	return sums
+}
+
+// fileChecksum returns the hex encoded alg checksum of the named file.
+func fileChecksum(t *testing.T, alg, name string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(name)
+	assert.NilError(t, err)
+
+	var h hash.Hash
+	switch alg {
+	case activities.ChecksumMD5:
+		h = md5.New() //#nosec G401 -- MD5 is a supported BagIt checksum algorithm.
+	case activities.ChecksumSHA256:
+		h = sha256.New()
+	default:
+		t.Fatalf("unexpected algorithm %q", alg)
+	}
+	h.Write(b)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func TestCreateBagConfig_Timeout(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, activities.CreateBagConfig{}.Timeout(0), 10*time.Minute)
+	assert.Equal(t, activities.CreateBagConfig{}.Timeout(5<<30), 20*time.Minute)
+	assert.Equal(t,
+		activities.CreateBagConfig{TimeoutPerGiB: time.Minute}.Timeout(1<<29),
+		10*time.Minute+30*time.Second,
+	)
+}
+
+func TestCreateBagConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	assert.NilError(t, activities.CreateBagConfig{ChecksumAlgorithm: activities.ChecksumSHA512}.Validate())
+	assert.Error(t,
+		activities.CreateBagConfig{ChecksumAlgorithm: "crc32", TimeoutPerGiB: -time.Minute}.Validate(),
+		`Preprocessing.BagCreate.ChecksumAlgorithm: unknown algorithm "crc32", must be "md5", "sha1", "sha256" or "sha512"
+Preprocessing.BagCreate.TimeoutPerGiB: -1m0s is negative`,
+	)
+	assert.NilError(t, activities.CreateBagConfig{
+		ChecksumAlgorithm:  "crc32",
+		ChecksumAlgorithms: []string{activities.ChecksumSHA256, activities.ChecksumSHA512},
+	}.Validate())
+	assert.Error(t,
+		activities.CreateBagConfig{
+			ChecksumAlgorithms: []string{activities.ChecksumSHA256, "crc32", activities.ChecksumSHA256},
+		}.Validate(),
+		`Preprocessing.BagCreate.ChecksumAlgorithms[1]: unknown algorithm "crc32", must be "md5", "sha1", "sha256" or "sha512"
+Preprocessing.BagCreate.ChecksumAlgorithms[2]: duplicate algorithm "sha256"`,
+	)
+	assert.Error(t,
+		activities.CreateBagConfig{
+			ChecksumAlgorithm: activities.ChecksumSHA512,
+			BagInfo: []activities.BagInfoTag{
+				{Label: "Contact-Name", Value: "City Archivist"},
+				{Label: "Contact Name:", Value: "City Archivist"},
+				{Label: "payload-oxum", Value: "1.1"},
+				{Label: "Internal-Sender-Description", Value: "line 1\nline 2"},
+			},
+		}.Validate(),
+		`Preprocessing.BagCreate.BagInfo[1].Label: invalid label "Contact Name:"
+Preprocessing.BagCreate.BagInfo[2].Label: "payload-oxum" is set by the bag creation
+Preprocessing.BagCreate.BagInfo[3].Value: must be a single line`,
+	)
+}
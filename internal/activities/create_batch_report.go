@@ -0,0 +1,232 @@
+package activities
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/artefactual-sdps/enduro/pkg/childwf"
+	"github.com/google/uuid"
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+)
+
+const (
+	CreateBatchReportName string = "create-batch-report-activity"
+
+	// Batch report SIP statuses.
+	SIPStatusPreserved string = "preserved"
+	SIPStatusFailed    string = "failed"
+)
+
+// CreateBatchReport is an activity that creates a summary report listing
+// every SIP in a batch with its outcome, so SIPs that failed preservation, and
+// are left out of the AtoM CSV file, are not missed. The report is written as
+// JSON and as plain text.
+type (
+	CreateBatchReport struct {
+		bucket *blob.Bucket
+
+		// loc is the time zone used to parse VanDocs dates without a time
+		// zone offset.
+		loc *time.Location
+	}
+	CreateBatchReportParams struct {
+		Batch *childwf.PostbatchBatch
+		SIPs  []*childwf.PostbatchSIP
+
+		// CreatedAt is the report creation time.
+		CreatedAt time.Time
+
+		// MetadataErrors lists the SIPs whose ContainerMetadata.xml file or
+		// file inventory could not be read by the AtoM CSV creation (see
+		// CreateCSVResult.MetadataErrors).
+		MetadataErrors []SIPMetadataError
+	}
+	CreateBatchReportResult struct {
+		// JSONKey is the key of the JSON report.
+		JSONKey string
+
+		// TextKey is the key of the plain text report.
+		TextKey string
+	}
+)
+
+// BatchReport is the batch summary report.
+type BatchReport struct {
+	BatchUUID       uuid.UUID         `json:"batchUuid"`
+	BatchIdentifier string            `json:"batchIdentifier,omitempty"`
+	CreatedAt       time.Time         `json:"createdAt"`
+	Preserved       int               `json:"preserved"`
+	Failed          int               `json:"failed"`
+	MetadataErrors  int               `json:"metadataErrors,omitempty"`
+	SIPs            []*BatchReportSIP `json:"sips"`
+}
+
+// BatchReportSIP is the summary of a SIP in a BatchReport.
+type BatchReportSIP struct {
+	// LegacyID is the legacyId of the SIP in the AtoM CSV file.
+	LegacyID   int        `json:"legacyId"`
+	UUID       uuid.UUID  `json:"uuid"`
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	AIPID      *uuid.UUID `json:"aipId,omitempty"`
+	FileCount  int        `json:"fileCount"`
+	Identifier string     `json:"identifier,omitempty"`
+	Title      string     `json:"title,omitempty"`
+	Warnings   []string   `json:"warnings,omitempty"`
+}
+
+var batchReportTmpl = template.Must(template.New("report").Parse(
+	`Batch report: {{if .BatchIdentifier}}{{.BatchIdentifier}} ({{.BatchUUID}}){{else}}{{.BatchUUID}}{{end}}
+Created: {{.CreatedAt.Format "2006-01-02T15:04:05Z07:00"}}
+SIPs: {{len .SIPs}} ({{.Preserved}} preserved, {{.Failed}} failed)
+{{- if .MetadataErrors}}
+Metadata errors: {{.MetadataErrors}} SIPs without metadata in the AtoM CSV file
+{{- end}}
+{{range .SIPs}}
+{{.LegacyID}}. {{.Name}} ({{.UUID}})
+   Status: {{.Status}}
+{{- if .AIPID}}
+   AIP ID: {{.AIPID}}
+{{- end}}
+   Files: {{.FileCount}}
+   Identifier: {{or .Identifier "-"}}
+   Title: {{or .Title "-"}}
+{{- if .Warnings}}
+   Warnings:
+{{- range .Warnings}}
+   - {{.}}
+{{- end}}
+{{- end}}
+{{end}}`,
+))
+
+// NewCreateBatchReport creates a new CreateBatchReport.
+func NewCreateBatchReport(b *blob.Bucket, loc *time.Location) *CreateBatchReport {
+	return &CreateBatchReport{
+		bucket: b,
+		loc:    loc,
+	}
+}
+
+func (a *CreateBatchReport) Execute(
+	ctx context.Context,
+	params *CreateBatchReportParams,
+) (*CreateBatchReportResult, error) {
+	if params.Batch == nil {
+		return nil, fmt.Errorf("create batch report: missing batch")
+	}
+
+	report := &BatchReport{
+		BatchUUID:       params.Batch.UUID,
+		BatchIdentifier: params.Batch.Identifier,
+		CreatedAt:       params.CreatedAt,
+		SIPs:            make([]*BatchReportSIP, 0, len(params.SIPs)),
+	}
+
+	for i, sip := range params.SIPs {
+		s := a.sipSummary(ctx, i+1, sip)
+		for _, e := range params.MetadataErrors {
+			if e.SIPID != sip.UUID {
+				continue
+			}
+			if e.Inventory {
+				s.Warnings = append(s.Warnings,
+					"Unable to read the file inventory, the extent and personal information of the SIP are unknown",
+				)
+				continue
+			}
+
+			report.MetadataErrors++
+			if e.Skipped {
+				s.Warnings = append(s.Warnings, "Metadata error, the SIP is not in the AtoM CSV file")
+			} else {
+				s.Warnings = append(s.Warnings, "Metadata error, the SIP has a placeholder row in the AtoM CSV file")
+			}
+		}
+		if s.Status == SIPStatusPreserved {
+			report.Preserved++
+		} else {
+			report.Failed++
+		}
+		report.SIPs = append(report.SIPs, s)
+	}
+
+	var jsonBuf bytes.Buffer
+	enc := json.NewEncoder(&jsonBuf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return nil, fmt.Errorf("create batch report: encode JSON: %w", err)
+	}
+
+	var textBuf bytes.Buffer
+	if err := batchReportTmpl.Execute(&textBuf, report); err != nil {
+		return nil, fmt.Errorf("create batch report: render text: %w", err)
+	}
+
+	res := &CreateBatchReportResult{
+		JSONKey: batchReportKey(params.Batch, "_report.json"),
+		TextKey: batchReportKey(params.Batch, "_report.txt"),
+	}
+
+	if err := a.bucket.WriteAll(ctx, res.JSONKey, jsonBuf.Bytes(), nil); err != nil {
+		return nil, fmt.Errorf("create batch report: write JSON: %w", err)
+	}
+	if err := a.bucket.WriteAll(ctx, res.TextKey, textBuf.Bytes(), nil); err != nil {
+		return nil, fmt.Errorf("create batch report: write text: %w", err)
+	}
+
+	return res, nil
+}
+
+// sipSummary returns the report summary of sip. Problems reading the SIP
+// metadata are reported as warnings, so they don't prevent the report
+// creation.
+func (a *CreateBatchReport) sipSummary(ctx context.Context, legacyID int, sip *childwf.PostbatchSIP) *BatchReportSIP {
+	s := &BatchReportSIP{
+		LegacyID:  legacyID,
+		UUID:      sip.UUID,
+		Name:      sip.Name,
+		Status:    SIPStatusPreserved,
+		AIPID:     sip.AIPID,
+		FileCount: sip.FileCount,
+	}
+
+	if sip.AIPID == nil || *sip.AIPID == uuid.Nil {
+		s.Status = SIPStatusFailed
+		s.AIPID = nil
+		s.Warnings = append(s.Warnings, "No AIP was stored, the SIP is not in the AtoM CSV file")
+	}
+	if sip.Name == "" {
+		s.Warnings = append(s.Warnings, "Missing SIP name")
+	}
+
+	md, err := parseContainerMetadata(ctx, a.bucket, a.loc, sip.UUID.String())
+	if gcerrors.Code(err) == gcerrors.NotFound {
+		s.Warnings = append(s.Warnings, "Missing ContainerMetadata.xml file")
+		return s
+	}
+	if err != nil {
+		s.Warnings = append(s.Warnings, fmt.Sprintf("Unable to read the ContainerMetadata.xml file: %v", err))
+		return s
+	}
+
+	s.Identifier = md.Identifier()
+	s.Title = md.Title()
+
+	if s.Identifier == "" {
+		s.Warnings = append(s.Warnings, "No identifier: RecordNumber is missing or has no forward slash")
+	}
+	if s.Title == "" {
+		s.Warnings = append(s.Warnings, "No title: TitleFreeTextPart is missing")
+	}
+	if md.QubitParentSlug() == "" {
+		s.Warnings = append(s.Warnings, "No parent description: Classification is missing")
+	}
+
+	return s
+}
@@ -0,0 +1,169 @@
+package activities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/artefactual-sdps/enduro/pkg/childwf"
+	"github.com/google/uuid"
+	"go.artefactual.dev/tools/bucket"
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+)
+
+func TestCreateBatchReport_Execute(t *testing.T) {
+	t.Parallel()
+
+	batchID := uuid.MustParse("33333333-3333-3333-3333-333333333333")
+	sipID1 := uuid.MustParse("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa")
+	sipID2 := uuid.MustParse("bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb")
+	sipID3 := uuid.MustParse("cccccccc-cccc-cccc-cccc-cccccccccccc")
+	aipID1 := uuid.MustParse("11111111-2222-3333-4444-555555555555")
+	aipID3 := uuid.MustParse("33333333-4444-5555-6666-777777777777")
+
+	t.Run("writes JSON and text reports listing every SIP", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := bucket.NewWithConfig(t.Context(), &bucket.Config{URL: "file:///" + t.TempDir()})
+		assert.NilError(t, err)
+		defer b.Close()
+
+		seedContainerMetadataXML(t, b, sipID1, sipContainerMetadataXML(containerMDXMLParams{
+			recordNumber:      "01-5000-12/2009-01",
+			titleFreeTextPart: "Test Title 1",
+		}))
+		seedContainerMetadataXML(t, b, sipID2, sipContainerMetadataXML(containerMDXMLParams{
+			titleFreeTextPart: "Test Title 2",
+		}))
+
+		res, err := activities.NewCreateBatchReport(b, time.UTC).Execute(
+			t.Context(),
+			&activities.CreateBatchReportParams{
+				Batch: &childwf.PostbatchBatch{UUID: batchID, Identifier: "12345", SIPSCount: 3},
+				SIPs: []*childwf.PostbatchSIP{
+					{UUID: sipID1, Name: "Test SIP 1", AIPID: &aipID1, FileCount: 8},
+					{UUID: sipID2, Name: "Test SIP 2"},
+					{UUID: sipID3, Name: "Test SIP 3", AIPID: &aipID3, FileCount: 2},
+				},
+				CreatedAt: time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC),
+				MetadataErrors: []activities.SIPMetadataError{
+					{
+						SIPID:     sipID1,
+						Name:      "Test SIP 1",
+						Error:     "read inventory: aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa_inventory.json: unexpected EOF",
+						Inventory: true,
+					},
+					{
+						SIPID:   sipID3,
+						Name:    "Test SIP 3",
+						Error:   "parse container metadata: new reader: not found",
+						Skipped: true,
+					},
+				},
+			},
+		)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, res, &activities.CreateBatchReportResult{
+			JSONKey: "reports/batch_12345_33333333-3333-3333-3333-333333333333_report.json",
+			TextKey: "reports/batch_12345_33333333-3333-3333-3333-333333333333_report.txt",
+		})
+
+		got, err := b.ReadAll(t.Context(), res.JSONKey)
+		assert.NilError(t, err)
+		assert.Equal(t, string(got), `{
+  "batchUuid": "33333333-3333-3333-3333-333333333333",
+  "batchIdentifier": "12345",
+  "createdAt": "2026-10-17T12:00:00Z",
+  "preserved": 2,
+  "failed": 1,
+  "metadataErrors": 1,
+  "sips": [
+    {
+      "legacyId": 1,
+      "uuid": "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa",
+      "name": "Test SIP 1",
+      "status": "preserved",
+      "aipId": "11111111-2222-3333-4444-555555555555",
+      "fileCount": 8,
+      "identifier": "F2009-01",
+      "title": "Test Title 1",
+      "warnings": [
+        "Unable to read the file inventory, the extent and personal information of the SIP are unknown"
+      ]
+    },
+    {
+      "legacyId": 2,
+      "uuid": "bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb",
+      "name": "Test SIP 2",
+      "status": "failed",
+      "fileCount": 0,
+      "title": "Test Title 2",
+      "warnings": [
+        "No AIP was stored, the SIP is not in the AtoM CSV file",
+        "No identifier: RecordNumber is missing or has no forward slash"
+      ]
+    },
+    {
+      "legacyId": 3,
+      "uuid": "cccccccc-cccc-cccc-cccc-cccccccccccc",
+      "name": "Test SIP 3",
+      "status": "preserved",
+      "aipId": "33333333-4444-5555-6666-777777777777",
+      "fileCount": 2,
+      "warnings": [
+        "Missing ContainerMetadata.xml file",
+        "Metadata error, the SIP is not in the AtoM CSV file"
+      ]
+    }
+  ]
+}
+`)
+
+		got, err = b.ReadAll(t.Context(), res.TextKey)
+		assert.NilError(t, err)
+		assert.Equal(t, string(got), `Batch report: 12345 (33333333-3333-3333-3333-333333333333)
+Created: 2026-10-17T12:00:00Z
+SIPs: 3 (2 preserved, 1 failed)
+Metadata errors: 1 SIPs without metadata in the AtoM CSV file
+
+1. Test SIP 1 (aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa)
+   Status: preserved
+   AIP ID: 11111111-2222-3333-4444-555555555555
+   Files: 8
+   Identifier: F2009-01
+   Title: Test Title 1
+   Warnings:
+   - Unable to read the file inventory, the extent and personal information of the SIP are unknown
+
+2. Test SIP 2 (bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb)
+   Status: failed
+   Files: 0
+   Identifier: -
+   Title: Test Title 2
+   Warnings:
+   - No AIP was stored, the SIP is not in the AtoM CSV file
+   - No identifier: RecordNumber is missing or has no forward slash
+
+3. Test SIP 3 (cccccccc-cccc-cccc-cccc-cccccccccccc)
+   Status: preserved
+   AIP ID: 33333333-4444-5555-6666-777777777777
+   Files: 2
+   Identifier: -
+   Title: -
+   Warnings:
+   - Missing ContainerMetadata.xml file
+   - Metadata error, the SIP is not in the AtoM CSV file
+`)
+	})
+
+	t.Run("errors when no batch provided", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := activities.NewCreateBatchReport(nil, time.UTC).Execute(
+			t.Context(),
+			&activities.CreateBatchReportParams{},
+		)
+		assert.Error(t, err, "create batch report: missing batch")
+	})
+}
@@ -3,9 +3,9 @@ package activities
 import (
 	"context"
 	"encoding/csv"
-	"encoding/xml"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/artefactual-sdps/enduro/pkg/childwf"
 	"github.com/google/uuid"
@@ -18,12 +18,24 @@ const (
 	CreateCSVName string = "create-csv-activity"
 
 	accessConditions string = "This file has not been reviewed for potential FOIPPA restrictions. Access is pending review and may be delayed. See archivist for details."
+
+	// defaultPIIAccessConditions is the default access conditions text of
+	// the SIPs with potential personal information (see
+	// CreateCSVConfig.PIIAccessConditions).
+	defaultPIIAccessConditions string = "This file may contain personal information and has not been reviewed for FOIPPA restrictions. Access is restricted pending review. See archivist for details."
 )
 
 // CreateCSV is an activity that creates an AtoM CSV file for the given SIPs.
+// The CSV columns are configurable (see CreateCSVConfig).
 type (
 	CreateCSV struct {
 		bucket *blob.Bucket
+
+		// loc is the time zone used to parse VanDocs dates without a time
+		// zone offset.
+		loc *time.Location
+
+		cfg CreateCSVConfig
 	}
 	CreateCSVParams struct {
 		Batch *childwf.PostbatchBatch
@@ -31,13 +43,37 @@ type (
 	}
 	CreateCSVResult struct {
 		Key string
+
+		// MetadataErrors lists the SIPs whose ContainerMetadata.xml file or
+		// file inventory could not be read, when the CSV is created despite
+		// the errors (see CreateCSVConfig.OnMetadataError).
+		MetadataErrors []SIPMetadataError
+	}
+
+	// SIPMetadataError describes a SIP whose ContainerMetadata.xml file or
+	// file inventory could not be read.
+	SIPMetadataError struct {
+		SIPID uuid.UUID
+		Name  string
+		Error string
+
+		// Skipped is true if the SIP row was left out of the CSV file, and
+		// false if a placeholder row was written.
+		Skipped bool
+
+		// Inventory is true if the SIP file inventory could not be read. The
+		// SIP row is written with its ContainerMetadata.xml values, but
+		// without its extent and personal information values.
+		Inventory bool
 	}
 )
 
 // NewCreateCSV creates a new CreateCSV.
-func NewCreateCSV(b *blob.Bucket) *CreateCSV {
+func NewCreateCSV(b *blob.Bucket, loc *time.Location, cfg CreateCSVConfig) *CreateCSV {
 	return &CreateCSV{
 		bucket: b,
+		loc:    loc,
+		cfg:    cfg,
 	}
 }
 
@@ -49,14 +85,13 @@ func (a *CreateCSV) Execute(ctx context.Context, params *CreateCSVParams) (*Crea
 		return nil, fmt.Errorf("create CSV: missing batch")
 	}
 
-	// If a batch identifier is not set, use the key "batch_<UUID>.csv".
-	key := fmt.Sprintf("reports/batch_%s.csv", params.Batch.UUID)
-
-	// If a batch identifier is set, use "batch_<identifier>_<UUID>.csv".
-	if params.Batch.Identifier != "" {
-		key = fmt.Sprintf("reports/batch_%s_%s.csv", params.Batch.Identifier, params.Batch.UUID)
+	cols, err := newCSVColumns(a.cfg.columns())
+	if err != nil {
+		return nil, fmt.Errorf("create CSV: %w", err)
 	}
 
+	key := batchReportKey(params.Batch, ".csv")
+
 	bw, err := a.bucket.NewWriter(ctx, key, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create CSV: new writer: %w", err)
@@ -65,33 +100,19 @@ func (a *CreateCSV) Execute(ctx context.Context, params *CreateCSVParams) (*Crea
 
 	cw := csv.NewWriter(bw)
 
+	mode := a.cfg.onMetadataError()
+
 	// Write header.
-	err = cw.Write([]string{
-		"legacyId",
-		"qubitParentSlug",
-		"acquisition",
-		"eventTypes",
-		"eventDates",
-		"eventStartDates",
-		"eventEndDates",
-		"eventActors",
-		"identifier",
-		"alternativeIdentifiers",
-		"alternativeIdentifierLabels",
-		"title",
-		"extentAndMedium",
-		"radGeneralMaterialDesignation",
-		"levelOfDescription",
-		"culture",
-		"publicationStatus",
-		"accessConditions",
-	})
+	header := cols.header()
+	if mode == OnMetadataErrorPlaceholder {
+		header = append(header, metadataWarningColumn)
+	}
+	err = cw.Write(header)
 	if err != nil {
 		return nil, fmt.Errorf("create CSV: write header: %w", err)
 	}
 
-	// TODO: Add an "extentAndMedium" column with the total number of files
-	// in each SIP.
+	res := &CreateCSVResult{Key: key}
 
 	for i, sip := range params.SIPs {
 		if sip.Name == "" {
@@ -101,49 +122,74 @@ func (a *CreateCSV) Execute(ctx context.Context, params *CreateCSVParams) (*Crea
 			continue
 		}
 
-		md, err := a.parseContainerMetadata(ctx, sip.UUID.String())
+		var warnings []string
+		var access *types.AccessRule
+		md, err := parseContainerMetadata(ctx, a.bucket, a.loc, sip.UUID.String())
 		if err != nil {
-			return nil, fmt.Errorf("create CSV: parse container metadata: %w", err)
+			if mode == OnMetadataErrorFail {
+				return nil, fmt.Errorf("create CSV: parse container metadata: %w", err)
+			}
+
+			res.MetadataErrors = append(res.MetadataErrors, SIPMetadataError{
+				SIPID:   sip.UUID,
+				Name:    sip.Name,
+				Error:   err.Error(),
+				Skipped: mode == OnMetadataErrorSkip,
+			})
+			if mode == OnMetadataErrorSkip {
+				continue
+			}
+
+			md = &types.ContainerMD{}
+			warnings = append(warnings, fmt.Sprintf("Unable to read the ContainerMetadata.xml file: %v", err))
+		} else {
+			// Access rules are not applied to placeholder rows, so their
+			// access is left at the defaults.
+			access = accessRule(a.cfg.AccessRules, md)
 		}
 
-		// Alternative identifiers and their labels are split over two columns,
-		// so extract them as separate strings here.
-		altIDs, altLabels := md.AlternativeIdentifiers(*sip.AIPID)
+		// A file inventory that can't be read only affects the extent and
+		// personal information values, so the row is still written with its
+		// ContainerMetadata.xml values, and restricted as a SIP with
+		// personal information.
+		inv, invErr := readInventory(ctx, a.bucket, sip.UUID)
+		if invErr != nil {
+			if mode == OnMetadataErrorFail {
+				return nil, fmt.Errorf("create CSV: SIP %d: %w", i+1, invErr)
+			}
 
-		// Add Creation and Recordkeeping events.
-		events := make([]types.Event, 0, 2)
-		if e := md.CreationEvent(); !e.IsZero() {
-			events = append(events, e)
+			res.MetadataErrors = append(res.MetadataErrors, SIPMetadataError{
+				SIPID:     sip.UUID,
+				Name:      sip.Name,
+				Error:     invErr.Error(),
+				Inventory: true,
+			})
+			warnings = append(warnings, fmt.Sprintf(
+				"Unable to read the file inventory, the extent and personal information are unknown: %v", invErr,
+			))
 		}
-		if e := md.RecordkeepingEvent(); !e.IsZero() {
-			events = append(events, e)
+
+		row, err := cols.row(CSVRow{
+			Index:     i + 1,
+			Batch:     params.Batch,
+			SIP:       sip,
+			MD:        md,
+			Events:    md.DeriveEvents(a.cfg.Events),
+			Inventory: inv,
+			Access:    access,
+
+			piiAccessConditions: a.cfg.PIIAccessConditions,
+			inventoryErr:        invErr != nil,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create CSV: row %d: %w", i+1, err)
 		}
 
-		var extentAndMedium string
-		if sip.FileCount > 0 {
-			extentAndMedium = fmt.Sprintf("%d digital documents", sip.FileCount)
+		if mode == OnMetadataErrorPlaceholder {
+			row = append(row, strings.Join(warnings, "; "))
 		}
 
-		err = cw.Write([]string{
-			fmt.Sprintf("%d", i+1),                        // legacyId
-			md.QubitParentSlug(),                          // qubitParentSlug
-			md.Acquisition(),                              // acquisition
-			joinWithPipe(events, types.Event.GetType),     // eventTypes
-			joinWithPipe(events, types.Event.FormatDates), // eventDates
-			joinWithPipe(events, types.Event.FormatStart), // eventStartDates
-			joinWithPipe(events, types.Event.FormatEnd),   // eventEndDates
-			joinWithPipe(events, types.Event.GetActor),    // eventActors
-			md.Identifier(),                               // identifier
-			strings.Join(altIDs, "|"),                     // alternativeIdentifiers
-			strings.Join(altLabels, "|"),                  // alternativeIdentifierLabels
-			md.Title(),                                    // title
-			extentAndMedium,                               // extentAndMedium
-			"Multiple media",                              // radGeneralMaterialDesignation
-			"File",                                        // levelOfDescription
-			"en",                                          // culture
-			"draft",                                       // publicationStatus
-			accessConditions,                              // accessConditions
-		})
+		err = cw.Write(row)
 		if err != nil {
 			return nil, fmt.Errorf("create CSV: write row %d: %w", i+1, err)
 		}
@@ -154,26 +200,47 @@ func (a *CreateCSV) Execute(ctx context.Context, params *CreateCSVParams) (*Crea
 		return nil, fmt.Errorf("create CSV: flush writer: %w", err)
 	}
 
-	return &CreateCSVResult{Key: key}, nil
+	if err := bw.Close(); err != nil {
+		return nil, fmt.Errorf("create CSV: close writer: %w", err)
+	}
+
+	return res, nil
+}
+
+// batchReportKey returns the ingest bucket key of a batch report file with the
+// given suffix. If a batch identifier is not set the key is
+// "reports/batch_<UUID><suffix>", otherwise it is
+// "reports/batch_<identifier>_<UUID><suffix>".
+func batchReportKey(batch *childwf.PostbatchBatch, suffix string) string {
+	if batch.Identifier == "" {
+		return fmt.Sprintf("reports/batch_%s%s", batch.UUID, suffix)
+	}
+
+	return fmt.Sprintf("reports/batch_%s_%s%s", batch.Identifier, batch.UUID, suffix)
 }
 
 // parseContainerMetadata reads and parses the ContainerMetadata.xml file for
-// the given SIP.
-func (a *CreateCSV) parseContainerMetadata(ctx context.Context, sipUUID string) (*types.ContainerMD, error) {
+// the given SIP from bucket b. Dates without a time zone offset are
+// interpreted in loc.
+func parseContainerMetadata(
+	ctx context.Context,
+	b *blob.Bucket,
+	loc *time.Location,
+	sipUUID string,
+) (*types.ContainerMD, error) {
 	key := fmt.Sprintf("%s_ContainerMetadata.xml", sipUUID)
 
-	r, err := a.bucket.NewReader(ctx, key, nil)
+	r, err := b.NewReader(ctx, key, nil)
 	if err != nil {
 		return nil, fmt.Errorf("parse container metadata: new reader: %w", err)
 	}
 	defer r.Close()
 
-	// decode the "ContainerMetadata.xml" XML data.
-	var md types.ContainerMD
-	if err := xml.NewDecoder(r).Decode(&md); err != nil {
-		return nil, fmt.Errorf("parse container metadata: decode XML: %w", err)
+	md, err := types.ParseContainerMD(r, loc)
+	if err != nil {
+		return nil, fmt.Errorf("parse container metadata: %s: %w", key, err)
 	}
-	return &md, nil
+	return md, nil
 }
 
 // joinWithPipe applies the provided function to each event and joins the
@@ -5,6 +5,7 @@ import (
 	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/artefactual-sdps/enduro/pkg/childwf"
 	"github.com/google/uuid"
@@ -14,6 +15,8 @@ import (
 	"gotest.tools/v3/assert"
 
 	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/enums"
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/types"
 )
 
 // containerMDXMLParams holds the fields used by sipContainerMetadataXML.
@@ -24,11 +27,13 @@ type containerMDXMLParams struct {
 	homeLocation      string
 	dateRegistered    string
 	dateClosed        string
+
+	// extra are additional <Container> child elements.
+	extra string
 }
 
 // sipContainerMetadataXML returns a ContainerMetadata.xml for the given params.
-// Date fields (dateRegistered, dateClosed) are omitted when empty so that the
-// XML decoder does not fail trying to parse an empty string as time.Time.
+// Date fields (dateRegistered, dateClosed) are omitted when empty.
 func sipContainerMetadataXML(p containerMDXMLParams) string {
 	var dateRegistered, dateClosed string
 	if p.dateRegistered != "" {
@@ -45,10 +50,18 @@ func sipContainerMetadataXML(p containerMDXMLParams) string {
     <OPR>COV - Office of Custody (OPR)</OPR>
     <RecordNumber>` + p.recordNumber + `</RecordNumber>
     <TitleFreeTextPart>` + p.titleFreeTextPart + `</TitleFreeTextPart>
-` + dateRegistered + dateClosed + `  </Container>
+` + dateRegistered + dateClosed + p.extra + `  </Container>
 </ContainerMetadata>`
 }
 
+// seedInventory uploads a file inventory for a single SIP UUID into b.
+func seedInventory(t *testing.T, b *blob.Bucket, sipUUID uuid.UUID, inventory string) {
+	t.Helper()
+	key := sipUUID.String() + "_inventory.json"
+	err := b.WriteAll(context.Background(), key, []byte(inventory), nil)
+	assert.NilError(t, err, "seed inventory for SIP %s", sipUUID)
+}
+
 // seedContainerMetadataXML uploads XML content for a single SIP UUID into b.
 func seedContainerMetadataXML(t *testing.T, b *blob.Bucket, sipUUID uuid.UUID, xmlContent string) {
 	t.Helper()
@@ -61,6 +74,9 @@ const (
 	accessConditionsValue = "This file has not been reviewed for potential" +
 		" FOIPPA restrictions. Access is pending review and may be delayed." +
 		" See archivist for details."
+	piiAccessConditionsValue = "This file may contain personal information and" +
+		" has not been reviewed for FOIPPA restrictions. Access is restricted" +
+		" pending review. See archivist for details."
 )
 
 var columns = []string{
@@ -90,11 +106,14 @@ func TestCreateCSV_Execute(t *testing.T) {
 	type test struct {
 		name        string
 		bucketCfg   *bucket.Config
+		cfg         activities.CreateCSVConfig
 		params      *activities.CreateCSVParams
 		setup       func(t *testing.T, b *blob.Bucket)
 		expectedKey string
 		want        string
 		wantErr     string
+
+		wantMetadataErrors []activities.SIPMetadataError
 	}
 
 	batchID := uuid.MustParse("33333333-3333-3333-3333-333333333333")
@@ -102,6 +121,9 @@ func TestCreateCSV_Execute(t *testing.T) {
 	sipID2 := uuid.MustParse("bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb")
 	aipID1 := uuid.MustParse("11111111-2222-3333-4444-555555555555")
 	aipID2 := uuid.MustParse("22222222-3333-4444-5555-666666666666")
+	sipID3 := uuid.MustParse("cccccccc-cccc-cccc-cccc-cccccccccccc")
+	aipID3 := uuid.MustParse("33333333-4444-5555-6666-777777777777")
+	pib := true
 
 	for _, tc := range []test{
 		{
@@ -141,6 +163,20 @@ func TestCreateCSV_Execute(t *testing.T) {
 					dateRegistered:    "2010-02-01T00:00:00Z",
 					dateClosed:        "2015-03-31T00:00:00Z",
 				}))
+				seedInventory(t, b, sipID2, `{
+  "files": 42,
+  "bytes": 118250000,
+  "formats": [
+    {"name": "PDF", "files": 30, "bytes": 98000000},
+    {"name": "DOCX", "files": 12, "bytes": 20250000}
+  ],
+  "pii": {
+    "files": 42,
+    "skipped": 0,
+    "hits": [{"name": "SIN", "matches": 2, "files": 1}],
+    "flagged": [{"path": "content/form.pdf", "hits": {"SIN": 2}}]
+  }
+}`)
 			},
 			expectedKey: "reports/batch_33333333-3333-3333-3333-333333333333.csv",
 			want: strings.Join(columns, ",") +
@@ -176,12 +212,12 @@ func TestCreateCSV_Execute(t *testing.T) {
 				"22222222-3333-4444-5555-666666666666|01-5000-12/2010-02," +
 				"AIP UUID|VanDocs container record number," +
 				"Test Title 2," +
-				"," + // empty extentAndMedium
+				`"42 digital documents (118 MB): 30 PDF, 12 DOCX",` +
 				"Multiple media," +
 				"File," +
 				"en," +
 				"draft," +
-				accessConditionsValue +
+				piiAccessConditionsValue +
 				"\n",
 		},
 		{
@@ -321,6 +357,477 @@ func TestCreateCSV_Execute(t *testing.T) {
 				accessConditionsValue +
 				"\n",
 		},
+		{
+			name:      "writes CSV with VanDocs local dates and an empty closed date",
+			bucketCfg: &bucket.Config{URL: "file:///" + t.TempDir()},
+			params: &activities.CreateCSVParams{
+				Batch: &childwf.PostbatchBatch{UUID: batchID},
+				SIPs: []*childwf.PostbatchSIP{
+					{
+						UUID:  sipID1,
+						Name:  "Test SIP 1",
+						AIPID: &aipID1,
+					},
+				},
+			},
+			setup: func(t *testing.T, b *blob.Bucket) {
+				t.Helper()
+				seedContainerMetadataXML(t, b, sipID1, strings.Replace(
+					sipContainerMetadataXML(containerMDXMLParams{
+						consignment:       "900036",
+						recordNumber:      "01-5000-12/2009-01",
+						titleFreeTextPart: "Test Title 1",
+						dateRegistered:    "2009-12-31 23:30:00",
+					}),
+					"</Container>",
+					"<DateClosed/></Container>",
+					1,
+				))
+			},
+			expectedKey: "reports/batch_33333333-3333-3333-3333-333333333333.csv",
+			want: strings.Join(columns, ",") +
+				"\n" +
+				"1," +
+				"01-5000-12," +
+				"VanDocs transfer: 900036," +
+				"Creation," +
+				"2009-    ," +
+				"2009-12-31," +
+				"NULL," +
+				"NULL," +
+				"F2009-01," +
+				"11111111-2222-3333-4444-555555555555|01-5000-12/2009-01," +
+				"AIP UUID|VanDocs container record number," +
+				"Test Title 1," +
+				"," + // empty extentAndMedium
+				"Multiple media," +
+				"File," +
+				"en," +
+				"draft," +
+				accessConditionsValue +
+				"\n",
+		},
+		{
+			name:      "errors when a ContainerMetadata.xml date can't be parsed",
+			bucketCfg: &bucket.Config{URL: "file:///" + t.TempDir()},
+			params: &activities.CreateCSVParams{
+				Batch: &childwf.PostbatchBatch{UUID: batchID},
+				SIPs: []*childwf.PostbatchSIP{
+					{
+						UUID:  sipID1,
+						Name:  "Test SIP 1",
+						AIPID: &aipID1,
+					},
+				},
+			},
+			setup: func(t *testing.T, b *blob.Bucket) {
+				t.Helper()
+				seedContainerMetadataXML(t, b, sipID1, sipContainerMetadataXML(containerMDXMLParams{
+					dateRegistered: "last year",
+				}))
+			},
+			wantErr: `create CSV: parse container metadata: parse container metadata: aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa_ContainerMetadata.xml: parse dates: DateRegistered: "last year" is not a valid date`,
+		},
+		{
+			name:      "skips SIPs whose ContainerMetadata.xml file can't be read",
+			bucketCfg: &bucket.Config{URL: "file:///" + t.TempDir()},
+			cfg: activities.CreateCSVConfig{
+				Columns: []activities.CSVColumn{
+					{Name: "legacyId", Source: "LegacyID"},
+					{Name: "title", Source: "Title"},
+				},
+				OnMetadataError: activities.OnMetadataErrorSkip,
+			},
+			params: &activities.CreateCSVParams{
+				Batch: &childwf.PostbatchBatch{UUID: batchID},
+				SIPs: []*childwf.PostbatchSIP{
+					{UUID: sipID1, Name: "Test SIP 1", AIPID: &aipID1},
+					{UUID: sipID2, Name: "Test SIP 2", AIPID: &aipID2},
+				},
+			},
+			setup: func(t *testing.T, b *blob.Bucket) {
+				t.Helper()
+				seedContainerMetadataXML(t, b, sipID1, sipContainerMetadataXML(containerMDXMLParams{
+					dateRegistered: "last year",
+				}))
+				seedContainerMetadataXML(t, b, sipID2, sipContainerMetadataXML(containerMDXMLParams{
+					titleFreeTextPart: "Test Title 2",
+				}))
+			},
+			expectedKey: "reports/batch_33333333-3333-3333-3333-333333333333.csv",
+			want:        "legacyId,title\n2,Test Title 2\n",
+			wantMetadataErrors: []activities.SIPMetadataError{
+				{
+					SIPID:   sipID1,
+					Name:    "Test SIP 1",
+					Error:   `parse container metadata: aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa_ContainerMetadata.xml: parse dates: DateRegistered: "last year" is not a valid date`,
+					Skipped: true,
+				},
+			},
+		},
+		{
+			name:      "writes placeholder rows for SIPs whose ContainerMetadata.xml file can't be read",
+			bucketCfg: &bucket.Config{URL: "file:///" + t.TempDir()},
+			cfg: activities.CreateCSVConfig{
+				Columns: []activities.CSVColumn{
+					{Name: "legacyId", Source: "LegacyID"},
+					{Name: "title", Source: "Title"},
+				},
+				OnMetadataError: activities.OnMetadataErrorPlaceholder,
+			},
+			params: &activities.CreateCSVParams{
+				Batch: &childwf.PostbatchBatch{UUID: batchID},
+				SIPs: []*childwf.PostbatchSIP{
+					{UUID: sipID1, Name: "Test SIP 1", AIPID: &aipID1},
+					{UUID: sipID2, Name: "Test SIP 2", AIPID: &aipID2},
+				},
+			},
+			setup: func(t *testing.T, b *blob.Bucket) {
+				t.Helper()
+				seedContainerMetadataXML(t, b, sipID1, sipContainerMetadataXML(containerMDXMLParams{
+					dateRegistered: "last year",
+				}))
+				seedContainerMetadataXML(t, b, sipID2, sipContainerMetadataXML(containerMDXMLParams{
+					titleFreeTextPart: "Test Title 2",
+				}))
+			},
+			expectedKey: "reports/batch_33333333-3333-3333-3333-333333333333.csv",
+			want: "legacyId,title,metadataWarning\n" +
+				`1,,"Unable to read the ContainerMetadata.xml file: parse container metadata: ` +
+				`aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa_ContainerMetadata.xml: parse dates: DateRegistered: ""last year"" is not a valid date"` +
+				"\n" +
+				"2,Test Title 2,\n",
+			wantMetadataErrors: []activities.SIPMetadataError{
+				{
+					SIPID: sipID1,
+					Name:  "Test SIP 1",
+					Error: `parse container metadata: aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa_ContainerMetadata.xml: parse dates: DateRegistered: "last year" is not a valid date`,
+				},
+			},
+		},
+		{
+			name:      "writes CSV with the access of the matching access rules, restricted for SIPs with PII",
+			bucketCfg: &bucket.Config{URL: "file:///" + t.TempDir()},
+			cfg: activities.CreateCSVConfig{
+				Columns: []activities.CSVColumn{
+					{Name: "legacyId", Source: "LegacyID"},
+					{Name: "publicationStatus", Source: "PublicationStatus"},
+					{Name: "accessConditions", Source: "AccessConditions"},
+					{Name: "archivistNote", Source: "RestrictionNote"},
+				},
+				OnMetadataError: activities.OnMetadataErrorPlaceholder,
+				AccessRules: []types.AccessRule{
+					{
+						Name:                    "Personal information bank",
+						PersonalInformationBank: &pib,
+						AccessConditions:        "Closed: personal information bank.",
+						RestrictionNote:         "Review before opening.",
+					},
+					{
+						Name:              "Open",
+						Security:          []string{"unclassified"},
+						PublicationStatus: "published",
+						AccessConditions:  "Open.",
+					},
+					{Name: "Catch-all", PublicationStatus: "published"},
+				},
+			},
+			params: &activities.CreateCSVParams{
+				Batch: &childwf.PostbatchBatch{UUID: batchID},
+				SIPs: []*childwf.PostbatchSIP{
+					{UUID: sipID1, Name: "Test SIP 1", AIPID: &aipID1},
+					{UUID: sipID2, Name: "Test SIP 2", AIPID: &aipID2},
+					{UUID: sipID3, Name: "Test SIP 3", AIPID: &aipID3},
+				},
+			},
+			setup: func(t *testing.T, b *blob.Bucket) {
+				t.Helper()
+				seedContainerMetadataXML(t, b, sipID1, sipContainerMetadataXML(containerMDXMLParams{
+					extra: "    <PersonalInformationBank>true</PersonalInformationBank>\n" +
+						"    <Security>Unclassified</Security>\n",
+				}))
+				seedContainerMetadataXML(t, b, sipID2, sipContainerMetadataXML(containerMDXMLParams{
+					extra: "    <Security>Unclassified</Security>\n",
+				}))
+				seedInventory(t, b, sipID2, `{
+  "files": 1,
+  "bytes": 1,
+  "formats": [],
+  "pii": {"files": 1, "skipped": 0, "hits": [{"name": "SIN", "matches": 1, "files": 1}], "flagged": []}
+}`)
+				seedContainerMetadataXML(t, b, sipID3, sipContainerMetadataXML(containerMDXMLParams{
+					dateRegistered: "last year",
+				}))
+			},
+			expectedKey: "reports/batch_33333333-3333-3333-3333-333333333333.csv",
+			want: "legacyId,publicationStatus,accessConditions,archivistNote,metadataWarning\n" +
+				"1,draft,Closed: personal information bank.,Review before opening.,\n" +
+				"2,draft," + piiAccessConditionsValue + ",,\n" +
+				"3,draft," + accessConditionsValue + `,,"Unable to read the ContainerMetadata.xml file: parse container metadata: ` +
+				`cccccccc-cccc-cccc-cccc-cccccccccccc_ContainerMetadata.xml: parse dates: DateRegistered: ""last year"" is not a valid date"` +
+				"\n",
+			wantMetadataErrors: []activities.SIPMetadataError{
+				{
+					SIPID: sipID3,
+					Name:  "Test SIP 3",
+					Error: `parse container metadata: cccccccc-cccc-cccc-cccc-cccccccccccc_ContainerMetadata.xml: parse dates: DateRegistered: "last year" is not a valid date`,
+				},
+			},
+		},
+		{
+			name:      "writes CSV with the configured access conditions for SIPs with PII",
+			bucketCfg: &bucket.Config{URL: "file:///" + t.TempDir()},
+			cfg: activities.CreateCSVConfig{
+				Columns: []activities.CSVColumn{
+					{Name: "legacyId", Source: "LegacyID"},
+					{Name: "publicationStatus", Source: "PublicationStatus"},
+					{Name: "accessConditions", Source: "AccessConditions"},
+				},
+				AccessRules: []types.AccessRule{
+					{Name: "Open", PublicationStatus: "published", AccessConditions: "Open."},
+				},
+				PIIAccessConditions: "Restricted: personal information.",
+			},
+			params: &activities.CreateCSVParams{
+				Batch: &childwf.PostbatchBatch{UUID: batchID},
+				SIPs: []*childwf.PostbatchSIP{
+					{UUID: sipID1, Name: "Test SIP 1", AIPID: &aipID1},
+					{UUID: sipID2, Name: "Test SIP 2", AIPID: &aipID2},
+				},
+			},
+			setup: func(t *testing.T, b *blob.Bucket) {
+				t.Helper()
+				seedContainerMetadataXML(t, b, sipID1, sipContainerMetadataXML(containerMDXMLParams{}))
+				seedContainerMetadataXML(t, b, sipID2, sipContainerMetadataXML(containerMDXMLParams{}))
+				seedInventory(t, b, sipID2, `{
+  "files": 1,
+  "bytes": 1,
+  "formats": [],
+  "pii": {"files": 1, "skipped": 0, "hits": [{"name": "SIN", "matches": 1, "files": 1}], "flagged": []}
+}`)
+			},
+			expectedKey: "reports/batch_33333333-3333-3333-3333-333333333333.csv",
+			want: "legacyId,publicationStatus,accessConditions\n" +
+				"1,published,Open.\n" +
+				"2,draft,Restricted: personal information.\n",
+		},
+		{
+			name:      "writes CSV with configured columns",
+			bucketCfg: &bucket.Config{URL: "file:///" + t.TempDir()},
+			cfg: activities.CreateCSVConfig{
+				Columns: []activities.CSVColumn{
+					{Name: "legacyId", Source: "LegacyID"},
+					{Name: "identifier", Source: "Identifier"},
+					{Name: "consignment", Source: "Container.Consignment"},
+					{Name: "registered", Source: "Container.DateRegistered"},
+					{Name: "batch", Source: "Batch.Identifier"},
+					{Name: "sip", Source: "SIP.Name"},
+					{
+						Name:     "extentAndMedium",
+						Template: `{{.SIP.FileCount}} files in {{source . "Identifier"}}`,
+					},
+					{Name: "culture", Value: "fr"},
+					{Name: "empty"},
+				},
+			},
+			params: &activities.CreateCSVParams{
+				Batch: &childwf.PostbatchBatch{UUID: batchID, Identifier: "12345"},
+				SIPs: []*childwf.PostbatchSIP{
+					{
+						UUID:      sipID1,
+						Name:      "Test SIP 1",
+						AIPID:     &aipID1,
+						FileCount: 8,
+					},
+				},
+			},
+			setup: func(t *testing.T, b *blob.Bucket) {
+				t.Helper()
+				seedContainerMetadataXML(t, b, sipID1, sipContainerMetadataXML(containerMDXMLParams{
+					consignment:    "900036",
+					recordNumber:   "01-5000-12/2009-01",
+					dateRegistered: "2009-01-15T00:00:00Z",
+				}))
+			},
+			expectedKey: "reports/batch_12345_33333333-3333-3333-3333-333333333333.csv",
+			want: "legacyId,identifier,consignment,registered,batch,sip,extentAndMedium,culture,empty\n" +
+				"1,F2009-01,900036,2009-01-15,12345,Test SIP 1,8 files in F2009-01,fr,\n",
+		},
+		{
+			name:      "writes CSV with the configured events",
+			bucketCfg: &bucket.Config{URL: "file:///" + t.TempDir()},
+			cfg: activities.CreateCSVConfig{
+				Columns: []activities.CSVColumn{
+					{Name: "legacyId", Source: "LegacyID"},
+					{Name: "eventTypes", Source: "EventTypes"},
+					{Name: "eventDates", Source: "EventDates"},
+					{Name: "eventStartDates", Source: "EventStartDates"},
+					{Name: "eventEndDates", Source: "EventEndDates"},
+					{Name: "eventActors", Source: "EventActors"},
+				},
+				Events: []types.EventRule{
+					{Type: enums.EventTypeCreation, Start: "DateRegistered", End: "DateClosed", Actor: []string{"Creator", "OPR"}},
+					{Type: enums.EventTypeAccumulation, Start: "DateCreated", End: "DateLastUpdated", Actor: []string{"Department"}},
+					{Type: enums.EventTypePublication, Start: "DatePublished"},
+				},
+			},
+			params: &activities.CreateCSVParams{
+				Batch: &childwf.PostbatchBatch{UUID: batchID},
+				SIPs: []*childwf.PostbatchSIP{
+					{UUID: sipID1, Name: "Test SIP 1", AIPID: &aipID1},
+				},
+			},
+			setup: func(t *testing.T, b *blob.Bucket) {
+				t.Helper()
+				seedContainerMetadataXML(t, b, sipID1, sipContainerMetadataXML(containerMDXMLParams{
+					dateRegistered: "2009-01-15",
+					dateClosed:     "2012-06-30",
+					extra: "    <DateCreated>2008-11-03</DateCreated>\n" +
+						"    <DateLastUpdated>2013-02-01</DateLastUpdated>\n",
+				}))
+			},
+			expectedKey: "reports/batch_33333333-3333-3333-3333-333333333333.csv",
+			want: "legacyId,eventTypes,eventDates,eventStartDates,eventEndDates,eventActors\n" +
+				"1," +
+				"Creation|Accumulation," +
+				"2009-2012|2008-2013," +
+				"2009-01-15|2008-11-03," +
+				"2012-06-30|2013-02-01," +
+				"COV - Office of Custody (OPR)|NULL\n",
+		},
+		{
+			name:      "writes the extent from a configured inventory template",
+			bucketCfg: &bucket.Config{URL: "file:///" + t.TempDir()},
+			cfg: activities.CreateCSVConfig{
+				Columns: []activities.CSVColumn{
+					{Name: "legacyId", Source: "LegacyID"},
+					{
+						Name: "extentAndMedium",
+						Template: "{{with .Inventory}}{{.Files}} files, {{.Size}}" +
+							"{{range .Formats}}; {{.Name}}: {{.Files}}{{end}}{{end}}",
+					},
+					{Name: "piiReview", Source: "PIISummary"},
+				},
+			},
+			params: &activities.CreateCSVParams{
+				Batch: &childwf.PostbatchBatch{UUID: batchID},
+				SIPs: []*childwf.PostbatchSIP{
+					{UUID: sipID1, Name: "Test SIP 1", AIPID: &aipID1, FileCount: 3},
+					{UUID: sipID2, Name: "Test SIP 2", AIPID: &aipID2},
+				},
+			},
+			setup: func(t *testing.T, b *blob.Bucket) {
+				t.Helper()
+				seedContainerMetadataXML(t, b, sipID1, sipContainerMetadataXML(containerMDXMLParams{}))
+				seedContainerMetadataXML(t, b, sipID2, sipContainerMetadataXML(containerMDXMLParams{}))
+				seedInventory(t, b, sipID1, `{
+  "files": 3,
+  "bytes": 2500,
+  "formats": [
+    {"name": "PDF", "files": 2, "bytes": 2000},
+    {"name": "other", "files": 1, "bytes": 500}
+  ],
+  "pii": {
+    "files": 3,
+    "skipped": 0,
+    "hits": [
+      {"name": "SIN", "matches": 1, "files": 1},
+      {"name": "Email address", "matches": 4, "files": 2}
+    ],
+    "flagged": []
+  }
+}`)
+			},
+			expectedKey: "reports/batch_33333333-3333-3333-3333-333333333333.csv",
+			want: "legacyId,extentAndMedium,piiReview\n" +
+				"1,\"3 files, 2.5 KB; PDF: 2; other: 1\",\"1 SIN, 4 Email address\"\n" +
+				"2,,\n",
+		},
+		{
+			name:      "errors when a SIP inventory can't be parsed",
+			bucketCfg: &bucket.Config{URL: "file:///" + t.TempDir()},
+			params: &activities.CreateCSVParams{
+				Batch: &childwf.PostbatchBatch{UUID: batchID},
+				SIPs: []*childwf.PostbatchSIP{
+					{UUID: sipID1, Name: "Test SIP 1", AIPID: &aipID1},
+				},
+			},
+			setup: func(t *testing.T, b *blob.Bucket) {
+				t.Helper()
+				seedContainerMetadataXML(t, b, sipID1, sipContainerMetadataXML(containerMDXMLParams{}))
+				seedInventory(t, b, sipID1, "{")
+			},
+			wantErr: "create CSV: SIP 1: read inventory: aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa_inventory.json: unexpected end of JSON input",
+		},
+		{
+			name:      "keeps the metadata of SIPs whose inventory can't be read",
+			bucketCfg: &bucket.Config{URL: "file:///" + t.TempDir()},
+			cfg: activities.CreateCSVConfig{
+				Columns: []activities.CSVColumn{
+					{Name: "legacyId", Source: "LegacyID"},
+					{Name: "title", Source: "Title"},
+					{Name: "publicationStatus", Source: "PublicationStatus"},
+					{Name: "accessConditions", Source: "AccessConditions"},
+					{Name: "piiReview", Source: "PIISummary"},
+				},
+				OnMetadataError: activities.OnMetadataErrorPlaceholder,
+				AccessRules: []types.AccessRule{
+					{PublicationStatus: types.PublicationStatusPublished, AccessConditions: "Open."},
+				},
+			},
+			params: &activities.CreateCSVParams{
+				Batch: &childwf.PostbatchBatch{UUID: batchID},
+				SIPs: []*childwf.PostbatchSIP{
+					{UUID: sipID1, Name: "Test SIP 1", AIPID: &aipID1},
+					{UUID: sipID2, Name: "Test SIP 2", AIPID: &aipID2},
+				},
+			},
+			setup: func(t *testing.T, b *blob.Bucket) {
+				t.Helper()
+				seedContainerMetadataXML(t, b, sipID1, sipContainerMetadataXML(containerMDXMLParams{
+					titleFreeTextPart: "Test Title 1",
+				}))
+				seedInventory(t, b, sipID1, "{")
+				seedContainerMetadataXML(t, b, sipID2, sipContainerMetadataXML(containerMDXMLParams{
+					titleFreeTextPart: "Test Title 2",
+				}))
+			},
+			expectedKey: "reports/batch_33333333-3333-3333-3333-333333333333.csv",
+			want: "legacyId,title,publicationStatus,accessConditions,piiReview,metadataWarning\n" +
+				"1,Test Title 1,draft," + piiAccessConditionsValue + ",," +
+				`"Unable to read the file inventory, the extent and personal information are unknown: ` +
+				`read inventory: aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa_inventory.json: unexpected end of JSON input"` +
+				"\n" +
+				"2,Test Title 2,published,Open.,,\n",
+			wantMetadataErrors: []activities.SIPMetadataError{
+				{
+					SIPID:     sipID1,
+					Name:      "Test SIP 1",
+					Error:     "read inventory: aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa_inventory.json: unexpected end of JSON input",
+					Inventory: true,
+				},
+			},
+		},
+		{
+			name:      "errors when a column template fails",
+			bucketCfg: &bucket.Config{URL: "file:///" + t.TempDir()},
+			cfg: activities.CreateCSVConfig{
+				Columns: []activities.CSVColumn{
+					{Name: "title", Template: `{{source . "Unknown"}}`},
+				},
+			},
+			params: &activities.CreateCSVParams{
+				Batch: &childwf.PostbatchBatch{UUID: batchID},
+				SIPs: []*childwf.PostbatchSIP{
+					{UUID: sipID1, Name: "Test SIP 1", AIPID: &aipID1},
+				},
+			},
+			setup: func(t *testing.T, b *blob.Bucket) {
+				t.Helper()
+				seedContainerMetadataXML(t, b, sipID1, sipContainerMetadataXML(containerMDXMLParams{}))
+			},
+			wantErr: `create CSV: row 1: column "title": template: title:1:2: executing "title" at <source . "Unknown">: error calling source: unknown source "Unknown"`,
+		},
 		{
 			name:      "errors when no batch provided",
 			bucketCfg: &bucket.Config{URL: "file:///" + t.TempDir()},
@@ -374,7 +881,7 @@ func TestCreateCSV_Execute(t *testing.T) {
 				tc.setup(t, b)
 			}
 
-			act := activities.NewCreateCSV(b)
+			act := activities.NewCreateCSV(b, time.UTC, tc.cfg)
 			res, err := act.Execute(t.Context(), tc.params)
 
 			if tc.wantErr != "" {
@@ -384,6 +891,7 @@ func TestCreateCSV_Execute(t *testing.T) {
 
 			assert.NilError(t, err)
 			assert.Equal(t, tc.expectedKey, res.Key)
+			assert.DeepEqual(t, tc.wantMetadataErrors, res.MetadataErrors)
 
 			r, err := b.NewReader(t.Context(), res.Key, nil)
 			assert.NilError(t, err)
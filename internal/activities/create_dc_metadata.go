@@ -0,0 +1,206 @@
+package activities
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/types"
+)
+
+const (
+	CreateDCMetadataName string = "create-dc-metadata-activity"
+
+	// Dublin Core metadata file formats supported by the CreateDCMetadata
+	// activity.
+	DCMetadataFormatCSV  string = "csv"
+	DCMetadataFormatJSON string = "json"
+
+	// dcMetadataFilename is the filename value describing the whole SIP in
+	// an Archivematica metadata file.
+	dcMetadataFilename string = "objects/"
+)
+
+// CreateDCMetadata is an activity that derives Dublin Core metadata from the
+// ContainerMetadata.xml file of a SIP and writes it to a "metadata.csv" or
+// "metadata.json" file in the SIP "metadata" directory, in the Archivematica
+// metadata file format. The metadata file describes the whole SIP so the
+// descriptive metadata is preserved in the AIP.
+type (
+	CreateDCMetadata struct {
+		// loc is the time zone used to parse VanDocs dates without a time
+		// zone offset.
+		loc *time.Location
+
+		cfg DCMetadataConfig
+	}
+	DCMetadataConfig struct {
+		// Format is the Dublin Core metadata file format, "csv" or "json"
+		// (default: "csv").
+		Format string
+
+		// AccessRules map the ContainerMetadata.xml access fields of a SIP
+		// to its dc.rights access conditions, as the AtoM CSV access rules
+		// (see CreateCSVConfig.AccessRules). The first matching rule
+		// applies.
+		AccessRules []types.AccessRule
+
+		// PIIAccessConditions is the dc.rights access conditions text of the
+		// SIPs with potential personal information found by the personal
+		// information scan, whatever access rule matches (default: a
+		// restricted pending review text).
+		PIIAccessConditions string
+	}
+	CreateDCMetadataParams struct {
+		// Path is the absolute path of the SIP directory.
+		Path string
+
+		// PII is the personal information report of the SIP, or nil if it
+		// was not scanned (see ScanPIIResult).
+		PII *PIIReport
+	}
+	CreateDCMetadataResult struct {
+		// Path is the path of the metadata file relative to the SIP root.
+		Path string
+	}
+)
+
+func (c DCMetadataConfig) Validate() error {
+	var errs error
+	if !slices.Contains([]string{"", DCMetadataFormatCSV, DCMetadataFormatJSON}, c.Format) {
+		errs = errors.Join(errs, fmt.Errorf(
+			"Preprocessing.DCMetadata.Format: unknown format %q, must be %q or %q",
+			c.Format, DCMetadataFormatCSV, DCMetadataFormatJSON,
+		))
+	}
+
+	for i, r := range c.AccessRules {
+		if err := r.Validate(); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("Preprocessing.DCMetadata.AccessRules[%d]: %v", i, err))
+		}
+	}
+
+	return errs
+}
+
+// format returns the configured file format, or CSV if not set.
+func (c DCMetadataConfig) format() string {
+	if c.Format == "" {
+		return DCMetadataFormatCSV
+	}
+	return c.Format
+}
+
+// NewCreateDCMetadata creates a new CreateDCMetadata.
+func NewCreateDCMetadata(loc *time.Location, cfg DCMetadataConfig) *CreateDCMetadata {
+	return &CreateDCMetadata{
+		loc: loc,
+		cfg: cfg,
+	}
+}
+
+func (a *CreateDCMetadata) Execute(
+	ctx context.Context,
+	params *CreateDCMetadataParams,
+) (*CreateDCMetadataResult, error) {
+	md, err := parseSIPContainerMD(params.Path, a.loc)
+	if err != nil {
+		return nil, fmt.Errorf("create DC metadata: %w", err)
+	}
+
+	access := resolveAccess(accessRule(a.cfg.AccessRules, md), params.PII.hasHits(), a.cfg.PIIAccessConditions)
+	names, values := dcElements(md, access)
+	relPath := filepath.Join("metadata", "metadata."+a.cfg.format())
+
+	f, err := os.Create(filepath.Join(params.Path, relPath))
+	if err != nil {
+		return nil, fmt.Errorf("create DC metadata: %w", err)
+	}
+	defer f.Close()
+
+	switch a.cfg.format() {
+	case DCMetadataFormatJSON:
+		err = writeDCJSON(f, names, values)
+	default:
+		err = writeDCCSV(f, names, values)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create DC metadata: write %s: %w", relPath, err)
+	}
+
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("create DC metadata: close %s: %w", relPath, err)
+	}
+
+	return &CreateDCMetadataResult{Path: relPath}, nil
+}
+
+// parseSIPContainerMD parses the ContainerMetadata.xml file of the SIP at
+// sipPath. Dates without a time zone offset are interpreted in loc.
+func parseSIPContainerMD(sipPath string, loc *time.Location) (*types.ContainerMD, error) {
+	f, err := os.Open(filepath.Join(sipPath, containerMDPath))
+	if err != nil {
+		return nil, fmt.Errorf("parse container metadata: %w", err)
+	}
+	defer f.Close()
+
+	md, err := types.ParseContainerMD(f, loc)
+	if err != nil {
+		return nil, fmt.Errorf("parse container metadata: %w", err)
+	}
+
+	return md, nil
+}
+
+// dcElements maps the container metadata and access to the Archivematica
+// metadata file columns, with the same mappings used for the AtoM CSV where
+// possible.
+func dcElements(md *types.ContainerMD, access sipAccess) (names, values []string) {
+	names = []string{
+		"filename",
+		"dc.title",
+		"dc.identifier",
+		"dc.creator",
+		"dc.subject",
+		"dc.description",
+		"dc.date",
+		"dc.rights",
+	}
+	values = []string{
+		dcMetadataFilename,
+		md.Title(),
+		md.Identifier(),
+		md.Container.OPR,
+		md.Container.Classification,
+		md.Container.Notes,
+		strings.TrimSpace(md.CreationEvent().FormatDates()),
+		access.Conditions,
+	}
+
+	return names, values
+}
+
+func writeDCCSV(w io.Writer, names, values []string) error {
+	return csv.NewWriter(w).WriteAll([][]string{names, values})
+}
+
+func writeDCJSON(w io.Writer, names, values []string) error {
+	m := make(map[string]string, len(names))
+	for i, name := range names {
+		m[name] = values[i]
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	// Archivematica expects a list of objects, one per described path.
+	return enc.Encode([]map[string]string{m})
+}
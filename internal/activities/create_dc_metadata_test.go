@@ -0,0 +1,142 @@
+package activities_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/types"
+)
+
+func TestCreateDCMetadata_Execute(t *testing.T) {
+	t.Parallel()
+
+	containerMD := fs.WithDir("metadata",
+		fs.WithDir("submissionDocumentation",
+			fs.WithFile("ContainerMetadata.xml", sipContainerMetadataXML(containerMDXMLParams{
+				recordNumber:      "01-5000-12/2009-01",
+				titleFreeTextPart: "Council minutes",
+				dateRegistered:    "2009-01-15",
+				dateClosed:        "2012-06-30",
+			})),
+		),
+	)
+
+	for _, tc := range []struct {
+		name     string
+		cfg      activities.DCMetadataConfig
+		ops      []fs.PathOp
+		pii      *activities.PIIReport
+		wantPath string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "writes a metadata.csv file by default",
+			ops:      []fs.PathOp{containerMD},
+			wantPath: "metadata/metadata.csv",
+			want: "filename,dc.title,dc.identifier,dc.creator,dc.subject,dc.description,dc.date,dc.rights\n" +
+				"objects/,Council minutes,F2009-01,COV - Office of Custody (OPR),01-5000-12,,2009-2012," +
+				accessConditionsValue + "\n",
+		},
+		{
+			name:     "writes a metadata.json file",
+			cfg:      activities.DCMetadataConfig{Format: activities.DCMetadataFormatJSON},
+			ops:      []fs.PathOp{containerMD},
+			wantPath: "metadata/metadata.json",
+			want: `[
+  {
+    "dc.creator": "COV - Office of Custody (OPR)",
+    "dc.date": "2009-2012",
+    "dc.description": "",
+    "dc.identifier": "F2009-01",
+    "dc.rights": "` + accessConditionsValue + `",
+    "dc.subject": "01-5000-12",
+    "dc.title": "Council minutes",
+    "filename": "objects/"
+  }
+]
+`,
+		},
+		{
+			name: "writes the access conditions of the matching access rule",
+			cfg: activities.DCMetadataConfig{
+				AccessRules: []types.AccessRule{{Name: "Open", AccessConditions: "Open."}},
+			},
+			ops:      []fs.PathOp{containerMD},
+			wantPath: "metadata/metadata.csv",
+			want: "filename,dc.title,dc.identifier,dc.creator,dc.subject,dc.description,dc.date,dc.rights\n" +
+				"objects/,Council minutes,F2009-01,COV - Office of Custody (OPR),01-5000-12,,2009-2012,Open.\n",
+		},
+		{
+			name: "writes restricted access conditions for SIPs with PII",
+			cfg: activities.DCMetadataConfig{
+				AccessRules: []types.AccessRule{{Name: "Open", AccessConditions: "Open."}},
+			},
+			ops:      []fs.PathOp{containerMD},
+			pii:      &activities.PIIReport{Files: 1, Hits: []activities.PIIHits{{Name: "SIN", Matches: 1, Files: 1}}},
+			wantPath: "metadata/metadata.csv",
+			want: "filename,dc.title,dc.identifier,dc.creator,dc.subject,dc.description,dc.date,dc.rights\n" +
+				"objects/,Council minutes,F2009-01,COV - Office of Custody (OPR),01-5000-12,,2009-2012," +
+				piiAccessConditionsValue + "\n",
+		},
+		{
+			name: "writes the configured access conditions for SIPs with PII",
+			cfg: activities.DCMetadataConfig{
+				AccessRules:         []types.AccessRule{{Name: "Open", AccessConditions: "Open."}},
+				PIIAccessConditions: "Restricted: personal information.",
+			},
+			ops:      []fs.PathOp{containerMD},
+			pii:      &activities.PIIReport{Files: 1, Hits: []activities.PIIHits{{Name: "SIN", Matches: 1, Files: 1}}},
+			wantPath: "metadata/metadata.csv",
+			want: "filename,dc.title,dc.identifier,dc.creator,dc.subject,dc.description,dc.date,dc.rights\n" +
+				"objects/,Council minutes,F2009-01,COV - Office of Custody (OPR),01-5000-12,,2009-2012," +
+				"Restricted: personal information.\n",
+		},
+		{
+			name:    "errors when the ContainerMetadata.xml file is missing",
+			wantErr: "create DC metadata: parse container metadata: open ",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := fs.NewDir(t, "cva-enduro-workflows-test", tc.ops...)
+
+			res, err := activities.NewCreateDCMetadata(time.UTC, tc.cfg).Execute(
+				t.Context(),
+				&activities.CreateDCMetadataParams{Path: dir.Path(), PII: tc.pii},
+			)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Equal(t, res.Path, tc.wantPath)
+
+			got, err := os.ReadFile(dir.Join(tc.wantPath))
+			assert.NilError(t, err)
+			assert.Equal(t, string(got), tc.want)
+		})
+	}
+}
+
+func TestDCMetadataConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	assert.NilError(t, activities.DCMetadataConfig{}.Validate())
+	assert.NilError(t, activities.DCMetadataConfig{Format: activities.DCMetadataFormatJSON}.Validate())
+	assert.Error(t,
+		activities.DCMetadataConfig{
+			Format:      "xml",
+			AccessRules: []types.AccessRule{{Name: "Open"}},
+		}.Validate(),
+		`Preprocessing.DCMetadata.Format: unknown format "xml", must be "csv" or "json"
+Preprocessing.DCMetadata.AccessRules[0]: one of AccessConditions, PublicationStatus or RestrictionNote must be set`,
+	)
+}
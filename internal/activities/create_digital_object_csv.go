@@ -0,0 +1,168 @@
+package activities
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/artefactual-sdps/enduro/pkg/childwf"
+	"github.com/google/uuid"
+	"gocloud.dev/blob"
+)
+
+const CreateDigitalObjectCSVName string = "create-digital-object-csv-activity"
+
+// CreateDigitalObjectCSV is an activity that creates an AtoM digital object
+// CSV file for the given SIPs. The file links the description of each SIP in
+// the AtoM CSV file, by legacyId, to the digital object of its AIP.
+type (
+	CreateDigitalObjectCSV struct {
+		bucket *blob.Bucket
+		cfg    DigitalObjectCSVConfig
+	}
+	DigitalObjectCSVConfig struct {
+		// Enabled toggles the creation of the digital object CSV file
+		// (default: false).
+		Enabled bool
+
+		// URITemplate is a Go text/template rendered with a
+		// DigitalObjectCSVRow to build the digitalObjectURI column, e.g.
+		// "https://storage.example.org/aips/{{.AIPID}}/download".
+		URITemplate string
+
+		// PathTemplate is a Go text/template rendered with a
+		// DigitalObjectCSVRow to build the digitalObjectPath column, e.g.
+		// "/mnt/aips/{{.AIPID}}.7z". Only one of URITemplate or PathTemplate
+		// can be set.
+		PathTemplate string
+	}
+	DigitalObjectCSVRow struct {
+		Batch *childwf.PostbatchBatch
+		SIP   *childwf.PostbatchSIP
+		AIPID uuid.UUID
+	}
+	CreateDigitalObjectCSVParams struct {
+		Batch *childwf.PostbatchBatch
+		SIPs  []*childwf.PostbatchSIP
+
+		// Skipped lists the SIPs left out of the AtoM CSV file because their
+		// ContainerMetadata.xml file could not be read, which have no
+		// description to link (see SIPMetadataError.Skipped).
+		Skipped []uuid.UUID
+	}
+	CreateDigitalObjectCSVResult struct {
+		Key string
+	}
+)
+
+func (c DigitalObjectCSVConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if (c.URITemplate == "") == (c.PathTemplate == "") {
+		return errors.New(
+			"Postbatch.DigitalObjectCSV: exactly one of URITemplate or PathTemplate must be set",
+		)
+	}
+
+	if _, _, err := c.template(); err != nil {
+		return fmt.Errorf("Postbatch.DigitalObjectCSV: %v", err)
+	}
+
+	return nil
+}
+
+// template returns the digital object column name and its parsed template.
+func (c DigitalObjectCSVConfig) template() (string, *template.Template, error) {
+	column, text := "digitalObjectURI", c.URITemplate
+	if c.PathTemplate != "" {
+		column, text = "digitalObjectPath", c.PathTemplate
+	}
+
+	t, err := template.New(column).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", nil, fmt.Errorf("parse %s template: %v", column, err)
+	}
+
+	return column, t, nil
+}
+
+// NewCreateDigitalObjectCSV creates a new CreateDigitalObjectCSV.
+func NewCreateDigitalObjectCSV(b *blob.Bucket, cfg DigitalObjectCSVConfig) *CreateDigitalObjectCSV {
+	return &CreateDigitalObjectCSV{
+		bucket: b,
+		cfg:    cfg,
+	}
+}
+
+func (a *CreateDigitalObjectCSV) Execute(
+	ctx context.Context,
+	params *CreateDigitalObjectCSVParams,
+) (*CreateDigitalObjectCSVResult, error) {
+	if len(params.SIPs) == 0 {
+		return nil, fmt.Errorf("create digital object CSV: no SIPs provided")
+	}
+	if params.Batch == nil {
+		return nil, fmt.Errorf("create digital object CSV: missing batch")
+	}
+
+	column, tmpl, err := a.cfg.template()
+	if err != nil {
+		return nil, fmt.Errorf("create digital object CSV: %w", err)
+	}
+
+	key := batchReportKey(params.Batch, "_digital_objects.csv")
+
+	bw, err := a.bucket.NewWriter(ctx, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create digital object CSV: new writer: %w", err)
+	}
+	defer bw.Close()
+
+	cw := csv.NewWriter(bw)
+
+	err = cw.Write([]string{"legacyId", column})
+	if err != nil {
+		return nil, fmt.Errorf("create digital object CSV: write header: %w", err)
+	}
+
+	for i, sip := range params.SIPs {
+		// Skip SIPs without an AIP, and the SIPs skipped in the AtoM CSV.
+		if sip.AIPID == nil || *sip.AIPID == uuid.Nil || slices.Contains(params.Skipped, sip.UUID) {
+			continue
+		}
+
+		var sb strings.Builder
+		err := tmpl.Execute(&sb, DigitalObjectCSVRow{
+			Batch: params.Batch,
+			SIP:   sip,
+			AIPID: *sip.AIPID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create digital object CSV: row %d: %w", i+1, err)
+		}
+
+		// The legacyId must match the one written to the AtoM CSV.
+		err = cw.Write([]string{strconv.Itoa(i + 1), sb.String()})
+		if err != nil {
+			return nil, fmt.Errorf("create digital object CSV: write row %d: %w", i+1, err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return nil, fmt.Errorf("create digital object CSV: flush writer: %w", err)
+	}
+
+	if err := bw.Close(); err != nil {
+		return nil, fmt.Errorf("create digital object CSV: close writer: %w", err)
+	}
+
+	return &CreateDigitalObjectCSVResult{Key: key}, nil
+}
@@ -0,0 +1,179 @@
+package activities_test
+
+import (
+	"testing"
+
+	"github.com/artefactual-sdps/enduro/pkg/childwf"
+	"github.com/google/uuid"
+	"go.artefactual.dev/tools/bucket"
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+)
+
+func TestCreateDigitalObjectCSV_Execute(t *testing.T) {
+	t.Parallel()
+
+	batchID := uuid.MustParse("33333333-3333-3333-3333-333333333333")
+	sipID1 := uuid.MustParse("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa")
+	sipID2 := uuid.MustParse("bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb")
+	sipID3 := uuid.MustParse("cccccccc-cccc-cccc-cccc-cccccccccccc")
+	aipID1 := uuid.MustParse("11111111-2222-3333-4444-555555555555")
+	aipID3 := uuid.MustParse("33333333-4444-5555-6666-777777777777")
+
+	sips := []*childwf.PostbatchSIP{
+		{UUID: sipID1, Name: "Test SIP 1", AIPID: &aipID1},
+		{UUID: sipID2, Name: "Test SIP 2"},
+		{UUID: sipID3, Name: "Test SIP 3", AIPID: &aipID3},
+	}
+
+	for _, tc := range []struct {
+		name        string
+		cfg         activities.DigitalObjectCSVConfig
+		params      *activities.CreateDigitalObjectCSVParams
+		expectedKey string
+		want        string
+		wantErr     string
+	}{
+		{
+			name: "writes digital object URIs, skipping SIPs without an AIP",
+			cfg: activities.DigitalObjectCSVConfig{
+				Enabled:     true,
+				URITemplate: "https://storage.example.org/aips/{{.AIPID}}/download",
+			},
+			params: &activities.CreateDigitalObjectCSVParams{
+				Batch: &childwf.PostbatchBatch{UUID: batchID},
+				SIPs:  sips,
+			},
+			expectedKey: "reports/batch_33333333-3333-3333-3333-333333333333_digital_objects.csv",
+			want: "legacyId,digitalObjectURI\n" +
+				"1,https://storage.example.org/aips/11111111-2222-3333-4444-555555555555/download\n" +
+				"3,https://storage.example.org/aips/33333333-4444-5555-6666-777777777777/download\n",
+		},
+		{
+			name: "skips the SIPs left out of the AtoM CSV file",
+			cfg: activities.DigitalObjectCSVConfig{
+				Enabled:     true,
+				URITemplate: "https://storage.example.org/aips/{{.AIPID}}/download",
+			},
+			params: &activities.CreateDigitalObjectCSVParams{
+				Batch:   &childwf.PostbatchBatch{UUID: batchID},
+				SIPs:    sips,
+				Skipped: []uuid.UUID{sipID1},
+			},
+			expectedKey: "reports/batch_33333333-3333-3333-3333-333333333333_digital_objects.csv",
+			want: "legacyId,digitalObjectURI\n" +
+				"3,https://storage.example.org/aips/33333333-4444-5555-6666-777777777777/download\n",
+		},
+		{
+			name: "writes digital object paths with the batch identifier in the key",
+			cfg: activities.DigitalObjectCSVConfig{
+				Enabled:      true,
+				PathTemplate: "/mnt/aips/{{.Batch.Identifier}}/{{.SIP.Name}}-{{.AIPID}}.7z",
+			},
+			params: &activities.CreateDigitalObjectCSVParams{
+				Batch: &childwf.PostbatchBatch{UUID: batchID, Identifier: "12345"},
+				SIPs:  sips[:1],
+			},
+			expectedKey: "reports/batch_12345_33333333-3333-3333-3333-333333333333_digital_objects.csv",
+			want: "legacyId,digitalObjectPath\n" +
+				"1,/mnt/aips/12345/Test SIP 1-11111111-2222-3333-4444-555555555555.7z\n",
+		},
+		{
+			name: "errors when no batch provided",
+			params: &activities.CreateDigitalObjectCSVParams{
+				SIPs: sips,
+			},
+			wantErr: "create digital object CSV: missing batch",
+		},
+		{
+			name: "errors when no SIPs provided",
+			params: &activities.CreateDigitalObjectCSVParams{
+				Batch: &childwf.PostbatchBatch{UUID: batchID},
+			},
+			wantErr: "create digital object CSV: no SIPs provided",
+		},
+		{
+			name: "errors when the template fails",
+			cfg: activities.DigitalObjectCSVConfig{
+				Enabled:     true,
+				URITemplate: "{{.AIP}}",
+			},
+			params: &activities.CreateDigitalObjectCSVParams{
+				Batch: &childwf.PostbatchBatch{UUID: batchID},
+				SIPs:  sips[:1],
+			},
+			wantErr: "create digital object CSV: row 1: template: digitalObjectURI:1:2: executing \"digitalObjectURI\" at <.AIP>",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := bucket.NewWithConfig(t.Context(), &bucket.Config{URL: "file:///" + t.TempDir()})
+			assert.NilError(t, err)
+			defer b.Close()
+
+			res, err := activities.NewCreateDigitalObjectCSV(b, tc.cfg).Execute(t.Context(), tc.params)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Equal(t, res.Key, tc.expectedKey)
+
+			got, err := b.ReadAll(t.Context(), res.Key)
+			assert.NilError(t, err)
+			assert.Equal(t, string(got), tc.want)
+		})
+	}
+}
+
+func TestDigitalObjectCSVConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name    string
+		cfg     activities.DigitalObjectCSVConfig
+		wantErr string
+	}{
+		{
+			name: "accepts a disabled config",
+		},
+		{
+			name: "accepts a URI template",
+			cfg:  activities.DigitalObjectCSVConfig{Enabled: true, URITemplate: "https://example.org/{{.AIPID}}"},
+		},
+		{
+			name:    "rejects an enabled config without templates",
+			cfg:     activities.DigitalObjectCSVConfig{Enabled: true},
+			wantErr: "Postbatch.DigitalObjectCSV: exactly one of URITemplate or PathTemplate must be set",
+		},
+		{
+			name: "rejects both templates",
+			cfg: activities.DigitalObjectCSVConfig{
+				Enabled:      true,
+				URITemplate:  "https://example.org/{{.AIPID}}",
+				PathTemplate: "/mnt/{{.AIPID}}",
+			},
+			wantErr: "Postbatch.DigitalObjectCSV: exactly one of URITemplate or PathTemplate must be set",
+		},
+		{
+			name:    "rejects an invalid template",
+			cfg:     activities.DigitalObjectCSVConfig{Enabled: true, PathTemplate: "/mnt/{{.AIPID"},
+			wantErr: "Postbatch.DigitalObjectCSV: parse digitalObjectPath template: template: digitalObjectPath:1: unclosed action",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.cfg.Validate()
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+		})
+	}
+}
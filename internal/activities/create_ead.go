@@ -0,0 +1,445 @@
+package activities
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/artefactual-sdps/enduro/pkg/childwf"
+	"github.com/google/uuid"
+	"gocloud.dev/blob"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/types"
+)
+
+const (
+	CreateEADName string = "create-ead-activity"
+
+	// EAD versions supported by the CreateEAD activity.
+	EADVersion2002 string = "ead2002"
+	EADVersion3    string = "ead3"
+
+	ead2002Namespace string = "urn:isbn:1-931666-22-9"
+	ead3Namespace    string = "http://ead3.archivists.org/schema/"
+
+	eadAgencyName string = "City of Vancouver Archives"
+	eadAgent      string = "cva-enduro-workflows"
+
+	// eadPlaceholderNote is the note of the placeholder components of SIPs
+	// whose ContainerMetadata.xml file can't be read.
+	eadPlaceholderNote string = "The ContainerMetadata.xml file of this SIP could not be read," +
+		" its descriptive metadata is unavailable."
+)
+
+// CreateEAD is an activity that creates an EAD finding aid for the given
+// SIPs. Each SIP is described by a file level component, grouped in a series
+// level component by its QubitParentSlug.
+type (
+	CreateEAD struct {
+		bucket *blob.Bucket
+
+		// loc is the time zone used to parse VanDocs dates without a time
+		// zone offset.
+		loc *time.Location
+
+		// csv is the AtoM CSV configuration, whose event rules, access rules
+		// and metadata error mode the finding aid shares.
+		csv CreateCSVConfig
+
+		cfg EADConfig
+	}
+	EADConfig struct {
+		// Enabled toggles the creation of the EAD finding aid (default:
+		// false).
+		Enabled bool
+
+		// Version is the EAD version of the finding aid, "ead2002" or "ead3"
+		// (default: "ead2002").
+		Version string
+	}
+	CreateEADParams struct {
+		Batch *childwf.PostbatchBatch
+		SIPs  []*childwf.PostbatchSIP
+
+		// CreatedAt is the creation time recorded in the EAD3 maintenance
+		// history.
+		CreatedAt time.Time
+	}
+	CreateEADResult struct {
+		Key string
+	}
+)
+
+func (c EADConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if !slices.Contains([]string{"", EADVersion2002, EADVersion3}, c.Version) {
+		return fmt.Errorf(
+			"Postbatch.EAD.Version: unknown version %q, must be %q or %q",
+			c.Version, EADVersion2002, EADVersion3,
+		)
+	}
+
+	return nil
+}
+
+// version returns the configured EAD version, or EAD 2002 if not set.
+func (c EADConfig) version() string {
+	if c.Version == "" {
+		return EADVersion2002
+	}
+	return c.Version
+}
+
+// NewCreateEAD creates a new CreateEAD.
+func NewCreateEAD(b *blob.Bucket, loc *time.Location, csv CreateCSVConfig, cfg EADConfig) *CreateEAD {
+	return &CreateEAD{
+		bucket: b,
+		loc:    loc,
+		csv:    csv,
+		cfg:    cfg,
+	}
+}
+
+func (a *CreateEAD) Execute(ctx context.Context, params *CreateEADParams) (*CreateEADResult, error) {
+	if len(params.SIPs) == 0 {
+		return nil, fmt.Errorf("create EAD: no SIPs provided")
+	}
+	if params.Batch == nil {
+		return nil, fmt.Errorf("create EAD: missing batch")
+	}
+
+	doc := newEADDoc(a.cfg.version(), params.Batch, params.CreatedAt)
+
+	// series holds the index of the series component of each
+	// QubitParentSlug in doc.ArchDesc.Components.
+	series := map[string]int{}
+
+	for _, sip := range params.SIPs {
+		// Skip SIPs without an AIP, as they are skipped in the AtoM CSV.
+		if sip.AIPID == nil || *sip.AIPID == uuid.Nil {
+			continue
+		}
+
+		// SIPs whose metadata can't be read are described as in the AtoM
+		// CSV: skipped, or by a placeholder component without metadata.
+		var (
+			rule        *types.AccessRule
+			placeholder bool
+		)
+		md, err := parseContainerMetadata(ctx, a.bucket, a.loc, sip.UUID.String())
+		if err != nil {
+			switch a.csv.onMetadataError() {
+			case OnMetadataErrorSkip:
+				continue
+			case OnMetadataErrorPlaceholder:
+				md = &types.ContainerMD{}
+				placeholder = true
+			default:
+				return nil, fmt.Errorf("create EAD: %w", err)
+			}
+		} else {
+			rule = accessRule(a.csv.AccessRules, md)
+		}
+
+		// As in the AtoM CSV, a SIP whose file inventory can't be read is
+		// still described, and restricted as a SIP with personal
+		// information.
+		inv, err := readInventory(ctx, a.bucket, sip.UUID)
+		if err != nil && a.csv.onMetadataError() == OnMetadataErrorFail {
+			return nil, fmt.Errorf("create EAD: %w", err)
+		}
+		var pii *PIIReport
+		if inv != nil {
+			pii = inv.PII
+		}
+
+		access := resolveAccess(rule, err != nil || pii.hasHits(), a.csv.PIIAccessConditions)
+		c := doc.sipComponent(sip, md, md.DeriveEvents(a.csv.Events), access)
+		if placeholder {
+			// A placeholder component is titled after the SIP, as it has no
+			// ContainerMetadata.xml title.
+			c.DID.UnitTitle = sip.Name
+			if c.DID.UnitTitle == "" {
+				c.DID.UnitTitle = fmt.Sprintf("SIP %s", sip.UUID)
+			}
+			c.Odd = &eadNote{P: eadPlaceholderNote}
+		}
+
+		slug := md.QubitParentSlug()
+		if slug == "" {
+			doc.ArchDesc.Components = append(doc.ArchDesc.Components, c)
+			continue
+		}
+
+		i, ok := series[slug]
+		if !ok {
+			i = len(doc.ArchDesc.Components)
+			series[slug] = i
+			doc.ArchDesc.Components = append(doc.ArchDesc.Components, eadComponent{
+				Level: "series",
+				DID: eadDID{
+					UnitIDs: []eadUnitID{{Label: "Classification", Value: slug}},
+				},
+			})
+		}
+		doc.ArchDesc.Components[i].Components = append(doc.ArchDesc.Components[i].Components, c)
+	}
+
+	key := batchReportKey(params.Batch, "_ead.xml")
+
+	bw, err := a.bucket.NewWriter(ctx, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create EAD: new writer: %w", err)
+	}
+	defer bw.Close()
+
+	if _, err := bw.Write([]byte(xml.Header)); err != nil {
+		return nil, fmt.Errorf("create EAD: write header: %w", err)
+	}
+
+	enc := xml.NewEncoder(bw)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("create EAD: encode XML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("create EAD: close encoder: %w", err)
+	}
+
+	if err := bw.Close(); err != nil {
+		return nil, fmt.Errorf("create EAD: close writer: %w", err)
+	}
+
+	return &CreateEADResult{Key: key}, nil
+}
+
+// eadDoc is an EAD 2002 or EAD3 document. Only the elements used by CVA are
+// modelled, and the version specific elements and attributes are only set for
+// their version.
+type eadDoc struct {
+	XMLName  xml.Name    `xml:"ead"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	Header   *eadHeader  `xml:"eadheader"`
+	Control  *eadControl `xml:"control"`
+	ArchDesc eadArchDesc `xml:"archdesc"`
+
+	version string
+}
+
+type eadHeader struct {
+	EADID    string      `xml:"eadid"`
+	FileDesc eadFileDesc `xml:"filedesc"`
+}
+
+type eadControl struct {
+	RecordID          string              `xml:"recordid"`
+	FileDesc          eadFileDesc         `xml:"filedesc"`
+	MaintenanceStatus eadValue            `xml:"maintenancestatus"`
+	AgencyName        string              `xml:"maintenanceagency>agencyname"`
+	MaintenanceEvent  eadMaintenanceEvent `xml:"maintenancehistory>maintenanceevent"`
+}
+
+type eadFileDesc struct {
+	TitleProper string `xml:"titlestmt>titleproper"`
+}
+
+type eadValue struct {
+	Value string `xml:"value,attr"`
+}
+
+type eadMaintenanceEvent struct {
+	EventType     eadValue    `xml:"eventtype"`
+	EventDateTime eadDateTime `xml:"eventdatetime"`
+	AgentType     eadValue    `xml:"agenttype"`
+	Agent         string      `xml:"agent"`
+}
+
+type eadDateTime struct {
+	StandardDateTime string `xml:"standarddatetime,attr"`
+	Value            string `xml:",chardata"`
+}
+
+type eadArchDesc struct {
+	Level      string         `xml:"level,attr"`
+	OtherLevel string         `xml:"otherlevel,attr"`
+	DID        eadDID         `xml:"did"`
+	Components []eadComponent `xml:"dsc>c"`
+}
+
+type eadComponent struct {
+	Level          string         `xml:"level,attr"`
+	ID             string         `xml:"id,attr,omitempty"`
+	DID            eadDID         `xml:"did"`
+	AccessRestrict *eadNote       `xml:"accessrestrict"`
+	Odd            *eadNote       `xml:"odd"`
+	Components     []eadComponent `xml:"c"`
+}
+
+type eadNote struct {
+	P string `xml:"p"`
+}
+
+type eadDID struct {
+	UnitTitle    string           `xml:"unittitle,omitempty"`
+	UnitIDs      []eadUnitID      `xml:"unitid"`
+	UnitDates    []eadUnitDate    `xml:"unitdate"`
+	Originations []eadOrigination `xml:"origination"`
+}
+
+type eadUnitID struct {
+	Label string `xml:"label,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+type eadUnitDate struct {
+	Label  string `xml:"label,attr,omitempty"`
+	Normal string `xml:"normal,attr,omitempty"`
+
+	// Type is the EAD 2002 date type attribute.
+	Type string `xml:"type,attr,omitempty"`
+
+	// UnitDateType is the EAD3 date type attribute.
+	UnitDateType string `xml:"unitdatetype,attr,omitempty"`
+
+	Value string `xml:",chardata"`
+}
+
+type eadOrigination struct {
+	Label    string      `xml:"label,attr,omitempty"`
+	CorpName eadCorpName `xml:"corpname"`
+}
+
+type eadCorpName struct {
+	// Value is the EAD 2002 corporate name.
+	Value string `xml:",chardata"`
+
+	// Part is the EAD3 corporate name.
+	Part string `xml:"part,omitempty"`
+}
+
+// newEADDoc returns an EAD document describing batch, without components.
+func newEADDoc(version string, batch *childwf.PostbatchBatch, createdAt time.Time) *eadDoc {
+	id := batch.UUID.String()
+	title := fmt.Sprintf("Batch %s", batch.UUID)
+	unitIDs := []eadUnitID{{Label: "Batch UUID", Value: batch.UUID.String()}}
+	if batch.Identifier != "" {
+		title = fmt.Sprintf("Batch %s", batch.Identifier)
+		unitIDs = append([]eadUnitID{{Value: batch.Identifier}}, unitIDs...)
+	}
+
+	doc := &eadDoc{
+		ArchDesc: eadArchDesc{
+			Level:      "otherlevel",
+			OtherLevel: "batch",
+			DID: eadDID{
+				UnitTitle: title,
+				UnitIDs:   unitIDs,
+			},
+		},
+		version: version,
+	}
+
+	switch version {
+	case EADVersion3:
+		doc.Xmlns = ead3Namespace
+		doc.Control = &eadControl{
+			RecordID:          id,
+			FileDesc:          eadFileDesc{TitleProper: title},
+			MaintenanceStatus: eadValue{Value: "new"},
+			AgencyName:        eadAgencyName,
+			MaintenanceEvent: eadMaintenanceEvent{
+				EventType: eadValue{Value: "created"},
+				EventDateTime: eadDateTime{
+					StandardDateTime: createdAt.Format(time.RFC3339),
+					Value:            createdAt.Format(time.RFC3339),
+				},
+				AgentType: eadValue{Value: "machine"},
+				Agent:     eadAgent,
+			},
+		}
+	default:
+		doc.Xmlns = ead2002Namespace
+		doc.Header = &eadHeader{
+			EADID:    id,
+			FileDesc: eadFileDesc{TitleProper: title},
+		}
+	}
+
+	return doc
+}
+
+// sipComponent returns a file level component describing sip with the same
+// metadata mappings as the AtoM CSV, and the given events and access.
+func (d *eadDoc) sipComponent(
+	sip *childwf.PostbatchSIP,
+	md *types.ContainerMD,
+	events []types.Event,
+	access sipAccess,
+) eadComponent {
+	c := eadComponent{
+		Level: "file",
+		ID:    fmt.Sprintf("sip-%s", sip.UUID),
+		DID: eadDID{
+			UnitTitle: md.Title(),
+		},
+		AccessRestrict: &eadNote{P: access.Conditions},
+	}
+
+	if id := md.Identifier(); id != "" {
+		c.DID.UnitIDs = append(c.DID.UnitIDs, eadUnitID{Value: id})
+	}
+	ids, labels := md.AlternativeIdentifiers(*sip.AIPID)
+	for i := range ids {
+		c.DID.UnitIDs = append(c.DID.UnitIDs, eadUnitID{Label: labels[i], Value: ids[i]})
+	}
+
+	for _, e := range events {
+		if dates := strings.TrimSpace(e.FormatDates()); dates != "" {
+			c.DID.UnitDates = append(c.DID.UnitDates, d.unitDate(e, dates))
+		}
+		if e.Actor != "" {
+			c.DID.Originations = append(c.DID.Originations, d.origination(e))
+		}
+	}
+
+	return c
+}
+
+// unitDate returns an inclusive unit date for event e, with an ISO 8601
+// normalized date or date interval.
+func (d *eadDoc) unitDate(e types.Event, dates string) eadUnitDate {
+	normal := e.FormatStart()
+	switch {
+	case normal == "":
+		normal = e.FormatEnd()
+	case !e.End.IsZero():
+		normal = fmt.Sprintf("%s/%s", normal, e.FormatEnd())
+	}
+
+	ud := eadUnitDate{Label: e.GetType(), Normal: normal, Value: dates}
+	if d.version == EADVersion3 {
+		ud.UnitDateType = "inclusive"
+	} else {
+		ud.Type = "inclusive"
+	}
+
+	return ud
+}
+
+// origination returns an origination naming the actor of event e.
+func (d *eadDoc) origination(e types.Event) eadOrigination {
+	o := eadOrigination{Label: e.GetType()}
+	if d.version == EADVersion3 {
+		o.CorpName.Part = e.Actor
+	} else {
+		o.CorpName.Value = e.Actor
+	}
+
+	return o
+}
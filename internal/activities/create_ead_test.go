@@ -0,0 +1,434 @@
+package activities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/artefactual-sdps/enduro/pkg/childwf"
+	"github.com/google/uuid"
+	"go.artefactual.dev/tools/bucket"
+	"gocloud.dev/blob"
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/types"
+)
+
+func TestCreateEAD_Execute(t *testing.T) {
+	t.Parallel()
+
+	batchID := uuid.MustParse("33333333-3333-3333-3333-333333333333")
+	sipID1 := uuid.MustParse("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa")
+	sipID2 := uuid.MustParse("bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb")
+	sipID3 := uuid.MustParse("cccccccc-cccc-cccc-cccc-cccccccccccc")
+	aipID1 := uuid.MustParse("11111111-2222-3333-4444-555555555555")
+	aipID3 := uuid.MustParse("33333333-4444-5555-6666-777777777777")
+	createdAt := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	sips := []*childwf.PostbatchSIP{
+		{UUID: sipID1, Name: "Test SIP 1", AIPID: &aipID1},
+		{UUID: sipID2, Name: "Test SIP 2"},
+		{UUID: sipID3, Name: "Test SIP 3", AIPID: &aipID3},
+	}
+
+	setup := func(t *testing.T, b *blob.Bucket) {
+		seedContainerMetadataXML(t, b, sipID1, sipContainerMetadataXML(containerMDXMLParams{
+			recordNumber:      "01-5000-12/2009-01",
+			titleFreeTextPart: "Council minutes",
+			homeLocation:      "City Clerk's Office",
+			dateRegistered:    "2009-01-15T00:00:00Z",
+			dateClosed:        "2012-06-30T00:00:00Z",
+		}))
+		seedContainerMetadataXML(t, b, sipID3, `<ContainerMetadata>
+  <Container>
+    <TitleFreeTextPart>Unclassified records</TitleFreeTextPart>
+    <DateRegistered>2015-02-01</DateRegistered>
+  </Container>
+</ContainerMetadata>`)
+	}
+
+	for _, tc := range []struct {
+		name        string
+		cfg         activities.EADConfig
+		csv         activities.CreateCSVConfig
+		params      *activities.CreateEADParams
+		setup       func(t *testing.T, b *blob.Bucket)
+		expectedKey string
+		want        string
+		wantErr     string
+	}{
+		{
+			name: "writes an EAD 2002 finding aid",
+			cfg:  activities.EADConfig{Enabled: true},
+			params: &activities.CreateEADParams{
+				Batch:     &childwf.PostbatchBatch{UUID: batchID, Identifier: "12345"},
+				SIPs:      sips,
+				CreatedAt: createdAt,
+			},
+			setup:       setup,
+			expectedKey: "reports/batch_12345_33333333-3333-3333-3333-333333333333_ead.xml",
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<ead xmlns="urn:isbn:1-931666-22-9">
+  <eadheader>
+    <eadid>33333333-3333-3333-3333-333333333333</eadid>
+    <filedesc>
+      <titlestmt>
+        <titleproper>Batch 12345</titleproper>
+      </titlestmt>
+    </filedesc>
+  </eadheader>
+  <archdesc level="otherlevel" otherlevel="batch">
+    <did>
+      <unittitle>Batch 12345</unittitle>
+      <unitid>12345</unitid>
+      <unitid label="Batch UUID">33333333-3333-3333-3333-333333333333</unitid>
+    </did>
+    <dsc>
+      <c level="series">
+        <did>
+          <unitid label="Classification">01-5000-12</unitid>
+        </did>
+        <c level="file" id="sip-aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa">
+          <did>
+            <unittitle>Council minutes</unittitle>
+            <unitid>F2009-01</unitid>
+            <unitid label="AIP UUID">11111111-2222-3333-4444-555555555555</unitid>
+            <unitid label="VanDocs container record number">01-5000-12/2009-01</unitid>
+            <unitdate label="Creation" normal="2009-01-15/2012-06-30" type="inclusive">2009-2012</unitdate>
+            <origination label="Recordkeeping">
+              <corpname>City Clerk&#39;s Office</corpname>
+            </origination>
+          </did>
+          <accessrestrict>
+            <p>` + accessConditionsValue + `</p>
+          </accessrestrict>
+        </c>
+      </c>
+      <c level="file" id="sip-cccccccc-cccc-cccc-cccc-cccccccccccc">
+        <did>
+          <unittitle>Unclassified records</unittitle>
+          <unitid label="AIP UUID">33333333-4444-5555-6666-777777777777</unitid>
+          <unitdate label="Creation" normal="2015-02-01" type="inclusive">2015-</unitdate>
+        </did>
+        <accessrestrict>
+          <p>` + accessConditionsValue + `</p>
+        </accessrestrict>
+      </c>
+    </dsc>
+  </archdesc>
+</ead>`,
+		},
+		{
+			name: "writes an EAD3 finding aid",
+			cfg:  activities.EADConfig{Enabled: true, Version: activities.EADVersion3},
+			params: &activities.CreateEADParams{
+				Batch:     &childwf.PostbatchBatch{UUID: batchID},
+				SIPs:      sips[:1],
+				CreatedAt: createdAt,
+			},
+			setup:       setup,
+			expectedKey: "reports/batch_33333333-3333-3333-3333-333333333333_ead.xml",
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<ead xmlns="http://ead3.archivists.org/schema/">
+  <control>
+    <recordid>33333333-3333-3333-3333-333333333333</recordid>
+    <filedesc>
+      <titlestmt>
+        <titleproper>Batch 33333333-3333-3333-3333-333333333333</titleproper>
+      </titlestmt>
+    </filedesc>
+    <maintenancestatus value="new"></maintenancestatus>
+    <maintenanceagency>
+      <agencyname>City of Vancouver Archives</agencyname>
+    </maintenanceagency>
+    <maintenancehistory>
+      <maintenanceevent>
+        <eventtype value="created"></eventtype>
+        <eventdatetime standarddatetime="2026-10-17T12:00:00Z">2026-10-17T12:00:00Z</eventdatetime>
+        <agenttype value="machine"></agenttype>
+        <agent>cva-enduro-workflows</agent>
+      </maintenanceevent>
+    </maintenancehistory>
+  </control>
+  <archdesc level="otherlevel" otherlevel="batch">
+    <did>
+      <unittitle>Batch 33333333-3333-3333-3333-333333333333</unittitle>
+      <unitid label="Batch UUID">33333333-3333-3333-3333-333333333333</unitid>
+    </did>
+    <dsc>
+      <c level="series">
+        <did>
+          <unitid label="Classification">01-5000-12</unitid>
+        </did>
+        <c level="file" id="sip-aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa">
+          <did>
+            <unittitle>Council minutes</unittitle>
+            <unitid>F2009-01</unitid>
+            <unitid label="AIP UUID">11111111-2222-3333-4444-555555555555</unitid>
+            <unitid label="VanDocs container record number">01-5000-12/2009-01</unitid>
+            <unitdate label="Creation" normal="2009-01-15/2012-06-30" unitdatetype="inclusive">2009-2012</unitdate>
+            <origination label="Recordkeeping">
+              <corpname>
+                <part>City Clerk&#39;s Office</part>
+              </corpname>
+            </origination>
+          </did>
+          <accessrestrict>
+            <p>` + accessConditionsValue + `</p>
+          </accessrestrict>
+        </c>
+      </c>
+    </dsc>
+  </archdesc>
+</ead>`,
+		},
+		{
+			name: "writes the access conditions of the matching access rules, restricted for SIPs with PII",
+			cfg:  activities.EADConfig{Enabled: true},
+			csv: activities.CreateCSVConfig{
+				AccessRules: []types.AccessRule{
+					{Name: "Open", PublicationStatus: "published", AccessConditions: "Open."},
+				},
+			},
+			params: &activities.CreateEADParams{
+				Batch:     &childwf.PostbatchBatch{UUID: batchID},
+				SIPs:      []*childwf.PostbatchSIP{sips[0], sips[2]},
+				CreatedAt: createdAt,
+			},
+			setup: func(t *testing.T, b *blob.Bucket) {
+				setup(t, b)
+				seedInventory(t, b, sipID3, `{
+  "files": 1,
+  "bytes": 1,
+  "formats": [],
+  "pii": {"files": 1, "skipped": 0, "hits": [{"name": "SIN", "matches": 1, "files": 1}], "flagged": []}
+}`)
+			},
+			expectedKey: "reports/batch_33333333-3333-3333-3333-333333333333_ead.xml",
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<ead xmlns="urn:isbn:1-931666-22-9">
+  <eadheader>
+    <eadid>33333333-3333-3333-3333-333333333333</eadid>
+    <filedesc>
+      <titlestmt>
+        <titleproper>Batch 33333333-3333-3333-3333-333333333333</titleproper>
+      </titlestmt>
+    </filedesc>
+  </eadheader>
+  <archdesc level="otherlevel" otherlevel="batch">
+    <did>
+      <unittitle>Batch 33333333-3333-3333-3333-333333333333</unittitle>
+      <unitid label="Batch UUID">33333333-3333-3333-3333-333333333333</unitid>
+    </did>
+    <dsc>
+      <c level="series">
+        <did>
+          <unitid label="Classification">01-5000-12</unitid>
+        </did>
+        <c level="file" id="sip-aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa">
+          <did>
+            <unittitle>Council minutes</unittitle>
+            <unitid>F2009-01</unitid>
+            <unitid label="AIP UUID">11111111-2222-3333-4444-555555555555</unitid>
+            <unitid label="VanDocs container record number">01-5000-12/2009-01</unitid>
+            <unitdate label="Creation" normal="2009-01-15/2012-06-30" type="inclusive">2009-2012</unitdate>
+            <origination label="Recordkeeping">
+              <corpname>City Clerk&#39;s Office</corpname>
+            </origination>
+          </did>
+          <accessrestrict>
+            <p>Open.</p>
+          </accessrestrict>
+        </c>
+      </c>
+      <c level="file" id="sip-cccccccc-cccc-cccc-cccc-cccccccccccc">
+        <did>
+          <unittitle>Unclassified records</unittitle>
+          <unitid label="AIP UUID">33333333-4444-5555-6666-777777777777</unitid>
+          <unitdate label="Creation" normal="2015-02-01" type="inclusive">2015-</unitdate>
+        </did>
+        <accessrestrict>
+          <p>` + piiAccessConditionsValue + `</p>
+        </accessrestrict>
+      </c>
+    </dsc>
+  </archdesc>
+</ead>`,
+		},
+		{
+			name: "skips the SIPs whose ContainerMetadata.xml file can't be read, as in the AtoM CSV",
+			cfg:  activities.EADConfig{Enabled: true},
+			csv:  activities.CreateCSVConfig{OnMetadataError: activities.OnMetadataErrorSkip},
+			params: &activities.CreateEADParams{
+				Batch:     &childwf.PostbatchBatch{UUID: batchID},
+				SIPs:      sips,
+				CreatedAt: createdAt,
+			},
+			setup: func(t *testing.T, b *blob.Bucket) {
+				setup(t, b)
+				seedContainerMetadataXML(t, b, sipID1, "<ContainerMetadata>")
+			},
+			expectedKey: "reports/batch_33333333-3333-3333-3333-333333333333_ead.xml",
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<ead xmlns="urn:isbn:1-931666-22-9">
+  <eadheader>
+    <eadid>33333333-3333-3333-3333-333333333333</eadid>
+    <filedesc>
+      <titlestmt>
+        <titleproper>Batch 33333333-3333-3333-3333-333333333333</titleproper>
+      </titlestmt>
+    </filedesc>
+  </eadheader>
+  <archdesc level="otherlevel" otherlevel="batch">
+    <did>
+      <unittitle>Batch 33333333-3333-3333-3333-333333333333</unittitle>
+      <unitid label="Batch UUID">33333333-3333-3333-3333-333333333333</unitid>
+    </did>
+    <dsc>
+      <c level="file" id="sip-cccccccc-cccc-cccc-cccc-cccccccccccc">
+        <did>
+          <unittitle>Unclassified records</unittitle>
+          <unitid label="AIP UUID">33333333-4444-5555-6666-777777777777</unitid>
+          <unitdate label="Creation" normal="2015-02-01" type="inclusive">2015-</unitdate>
+        </did>
+        <accessrestrict>
+          <p>` + accessConditionsValue + `</p>
+        </accessrestrict>
+      </c>
+    </dsc>
+  </archdesc>
+</ead>`,
+		},
+		{
+			name: "writes placeholder components for SIPs whose ContainerMetadata.xml file can't be read",
+			cfg:  activities.EADConfig{Enabled: true},
+			csv:  activities.CreateCSVConfig{OnMetadataError: activities.OnMetadataErrorPlaceholder},
+			params: &activities.CreateEADParams{
+				Batch:     &childwf.PostbatchBatch{UUID: batchID},
+				SIPs:      sips[:1],
+				CreatedAt: createdAt,
+			},
+			expectedKey: "reports/batch_33333333-3333-3333-3333-333333333333_ead.xml",
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<ead xmlns="urn:isbn:1-931666-22-9">
+  <eadheader>
+    <eadid>33333333-3333-3333-3333-333333333333</eadid>
+    <filedesc>
+      <titlestmt>
+        <titleproper>Batch 33333333-3333-3333-3333-333333333333</titleproper>
+      </titlestmt>
+    </filedesc>
+  </eadheader>
+  <archdesc level="otherlevel" otherlevel="batch">
+    <did>
+      <unittitle>Batch 33333333-3333-3333-3333-333333333333</unittitle>
+      <unitid label="Batch UUID">33333333-3333-3333-3333-333333333333</unitid>
+    </did>
+    <dsc>
+      <c level="file" id="sip-aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa">
+        <did>
+          <unittitle>Test SIP 1</unittitle>
+          <unitid label="AIP UUID">11111111-2222-3333-4444-555555555555</unitid>
+        </did>
+        <accessrestrict>
+          <p>` + accessConditionsValue + `</p>
+        </accessrestrict>
+        <odd>
+          <p>The ContainerMetadata.xml file of this SIP could not be read, its descriptive metadata is unavailable.</p>
+        </odd>
+      </c>
+    </dsc>
+  </archdesc>
+</ead>`,
+		},
+		{
+			name: "errors when no batch provided",
+			params: &activities.CreateEADParams{
+				SIPs: sips,
+			},
+			wantErr: "create EAD: missing batch",
+		},
+		{
+			name: "errors when no SIPs provided",
+			params: &activities.CreateEADParams{
+				Batch: &childwf.PostbatchBatch{UUID: batchID},
+			},
+			wantErr: "create EAD: no SIPs provided",
+		},
+		{
+			name: "errors when the ContainerMetadata.xml file is missing",
+			params: &activities.CreateEADParams{
+				Batch: &childwf.PostbatchBatch{UUID: batchID},
+				SIPs:  sips[:1],
+			},
+			wantErr: "create EAD: parse container metadata: new reader:",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := bucket.NewWithConfig(t.Context(), &bucket.Config{URL: "file:///" + t.TempDir()})
+			assert.NilError(t, err)
+			defer b.Close()
+
+			if tc.setup != nil {
+				tc.setup(t, b)
+			}
+
+			res, err := activities.NewCreateEAD(
+				b, time.UTC, tc.csv, tc.cfg,
+			).Execute(t.Context(), tc.params)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Equal(t, res.Key, tc.expectedKey)
+
+			got, err := b.ReadAll(t.Context(), res.Key)
+			assert.NilError(t, err)
+			assert.Equal(t, string(got), tc.want)
+		})
+	}
+}
+
+func TestEADConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name    string
+		cfg     activities.EADConfig
+		wantErr string
+	}{
+		{
+			name: "accepts a disabled config",
+			cfg:  activities.EADConfig{Version: "ead1"},
+		},
+		{
+			name: "accepts the default version",
+			cfg:  activities.EADConfig{Enabled: true},
+		},
+		{
+			name: "accepts EAD3",
+			cfg:  activities.EADConfig{Enabled: true, Version: activities.EADVersion3},
+		},
+		{
+			name:    "rejects an unknown version",
+			cfg:     activities.EADConfig{Enabled: true, Version: "ead1"},
+			wantErr: `Postbatch.EAD.Version: unknown version "ead1", must be "ead2002" or "ead3"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.cfg.Validate()
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package activities
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+)
+
+const (
+	CreateInventoryName string = "create-inventory-activity"
+
+	// inventoryOtherFormat is the format name of the files without an
+	// extension in a file inventory.
+	inventoryOtherFormat string = "other"
+)
+
+// CreateInventory is an activity that creates an inventory of the SIP content
+// files and writes it to the ingest bucket as "<SIPID>_inventory.json", so the
+// postbatch workflow can describe the SIP extent in the AtoM CSV file. The
+// inventory includes the personal information report of the SIP, if it was
+// scanned (see ScanPII).
+type (
+	CreateInventory struct {
+		bucket *blob.Bucket
+	}
+	CreateInventoryParams struct {
+		// Path is the absolute path of the SIP directory.
+		Path string
+
+		// SIPID is the Enduro SIP UUID.
+		SIPID uuid.UUID
+
+		// PII is the personal information report of the SIP, or nil if it
+		// was not scanned (see ScanPIIResult).
+		PII *PIIReport
+	}
+	CreateInventoryResult struct {
+		// Key is the ingest bucket key of the inventory.
+		Key string
+
+		Inventory *Inventory
+	}
+)
+
+// Inventory describes the content files of a SIP.
+type Inventory struct {
+	// Files is the number of content files.
+	Files int `json:"files"`
+
+	// Bytes is the total size of the content files.
+	Bytes int64 `json:"bytes"`
+
+	// Formats breaks down the content files by format, from the most to the
+	// least common.
+	Formats []InventoryFormat `json:"formats"`
+
+	// PII is the personal information report of the SIP, nil if the SIP was
+	// not scanned for personal information.
+	PII *PIIReport `json:"pii,omitempty"`
+}
+
+// InventoryFormat is the number and size of the SIP content files of a
+// format.
+type InventoryFormat struct {
+	// Name is the upper case file extension, e.g. "PDF", or "other" for
+	// files without an extension.
+	Name  string `json:"name"`
+	Files int    `json:"files"`
+	Bytes int64  `json:"bytes"`
+}
+
+// NewCreateInventory creates a new CreateInventory.
+func NewCreateInventory(b *blob.Bucket) *CreateInventory {
+	return &CreateInventory{bucket: b}
+}
+
+func (a *CreateInventory) Execute(
+	ctx context.Context,
+	params *CreateInventoryParams,
+) (*CreateInventoryResult, error) {
+	inv, err := newInventory(filepath.Join(params.Path, "content"))
+	if err != nil {
+		return nil, fmt.Errorf("create inventory: %w", err)
+	}
+
+	inv.PII = params.PII
+
+	data, err := json.Marshal(inv)
+	if err != nil {
+		return nil, fmt.Errorf("create inventory: encode: %w", err)
+	}
+
+	key := inventoryKey(params.SIPID)
+	if err := a.bucket.WriteAll(ctx, key, data, nil); err != nil {
+		return nil, fmt.Errorf("create inventory: write %s: %w", key, err)
+	}
+
+	return &CreateInventoryResult{Key: key, Inventory: inv}, nil
+}
+
+// inventoryKey returns the ingest bucket key of the file inventory of a SIP.
+func inventoryKey(sipID uuid.UUID) string {
+	return fmt.Sprintf("%s_inventory.json", sipID)
+}
+
+// newInventory returns the inventory of the files in the dir tree.
+func newInventory(dir string) (*Inventory, error) {
+	inv := &Inventory{Formats: []InventoryFormat{}}
+	formats := map[string]*InventoryFormat{}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		name := strings.ToUpper(strings.TrimPrefix(filepath.Ext(path), "."))
+		if name == "" {
+			name = inventoryOtherFormat
+		}
+		f, ok := formats[name]
+		if !ok {
+			f = &InventoryFormat{Name: name}
+			formats[name] = f
+		}
+		f.Files++
+		f.Bytes += fi.Size()
+
+		inv.Files++
+		inv.Bytes += fi.Size()
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range formats {
+		inv.Formats = append(inv.Formats, *f)
+	}
+	slices.SortFunc(inv.Formats, func(a, b InventoryFormat) int {
+		return cmp.Or(cmp.Compare(b.Files, a.Files), cmp.Compare(a.Name, b.Name))
+	})
+
+	return inv, nil
+}
+
+// readInventory reads the file inventory of a SIP from bucket b. It returns
+// nil if the SIP has no inventory, e.g. if it was preprocessed before file
+// inventories were created.
+func readInventory(ctx context.Context, b *blob.Bucket, sipID uuid.UUID) (*Inventory, error) {
+	key := inventoryKey(sipID)
+
+	data, err := b.ReadAll(ctx, key)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read inventory: %w", err)
+	}
+
+	var inv Inventory
+	if err := json.Unmarshal(data, &inv); err != nil {
+		return nil, fmt.Errorf("read inventory: %s: %w", key, err)
+	}
+
+	return &inv, nil
+}
+
+// Size returns the total size of the content files in decimal units, e.g.
+// "118 MB".
+func (inv *Inventory) Size() string {
+	const unit = 1000
+	if inv.Bytes < unit {
+		return strconv.FormatInt(inv.Bytes, 10) + " B"
+	}
+
+	v := float64(inv.Bytes)
+	var i int
+	for v >= unit && i < 4 {
+		v /= unit
+		i++
+	}
+
+	prefix := "KMGT"[i-1 : i]
+	if v < 10 {
+		return fmt.Sprintf("%.1f %sB", v, prefix)
+	}
+
+	return fmt.Sprintf("%.0f %sB", v, prefix)
+}
+
+// FormatSummary returns the number of content files of each format, e.g.
+// "30 PDF, 12 DOCX".
+func (inv *Inventory) FormatSummary() string {
+	parts := make([]string, len(inv.Formats))
+	for i, f := range inv.Formats {
+		parts[i] = fmt.Sprintf("%d %s", f.Files, f.Name)
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,156 @@
+package activities_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.artefactual.dev/tools/bucket"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+)
+
+func TestCreateInventory_Execute(t *testing.T) {
+	t.Parallel()
+
+	sipID := uuid.MustParse("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa")
+
+	for _, tc := range []struct {
+		name    string
+		ops     []fs.PathOp
+		want    *activities.Inventory
+		wantErr string
+	}{
+		{
+			name: "creates an inventory of the content files",
+			ops: []fs.PathOp{
+				fs.WithDir("content",
+					fs.WithFile("a.pdf", strings.Repeat("a", 1000)),
+					fs.WithFile("b.PDF", "bb"),
+					fs.WithDir("sub",
+						fs.WithFile("c.docx", "ccc"),
+						fs.WithFile("d.tif", "dddd"),
+						fs.WithFile("README", "e"),
+					),
+				),
+				fs.WithDir("metadata", fs.WithFile("metadata.csv", "ignored")),
+			},
+			want: &activities.Inventory{
+				Files: 5,
+				Bytes: 1010,
+				Formats: []activities.InventoryFormat{
+					{Name: "PDF", Files: 2, Bytes: 1002},
+					{Name: "DOCX", Files: 1, Bytes: 3},
+					{Name: "TIF", Files: 1, Bytes: 4},
+					{Name: "other", Files: 1, Bytes: 1},
+				},
+			},
+		},
+		{
+			name:    "errors when the content directory is missing",
+			ops:     []fs.PathOp{fs.WithDir("metadata")},
+			wantErr: "create inventory: lstat ",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := fs.NewDir(t, "cva-enduro-workflows-test", tc.ops...)
+
+			b, err := bucket.NewWithConfig(t.Context(), &bucket.Config{URL: "file:///" + t.TempDir()})
+			assert.NilError(t, err)
+			defer b.Close()
+
+			res, err := activities.NewCreateInventory(b).Execute(
+				t.Context(),
+				&activities.CreateInventoryParams{Path: dir.Path(), SIPID: sipID},
+			)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.DeepEqual(t, res, &activities.CreateInventoryResult{
+				Key:       "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa_inventory.json",
+				Inventory: tc.want,
+			})
+
+			data, err := b.ReadAll(t.Context(), res.Key)
+			assert.NilError(t, err)
+			assert.Equal(t, string(data), `{"files":5,"bytes":1010,"formats":[`+
+				`{"name":"PDF","files":2,"bytes":1002},`+
+				`{"name":"DOCX","files":1,"bytes":3},`+
+				`{"name":"TIF","files":1,"bytes":4},`+
+				`{"name":"other","files":1,"bytes":1}]}`)
+		})
+	}
+}
+
+func TestCreateInventory_PIIReport(t *testing.T) {
+	t.Parallel()
+
+	dir := fs.NewDir(t, "cva-enduro-workflows-test",
+		fs.WithDir("content", fs.WithFile("a.txt", "SIN 046 454 286")),
+		fs.WithDir("metadata"),
+	)
+	scan, err := activities.NewScanPII(activities.ScanPIIConfig{}).Execute(
+		t.Context(),
+		&activities.ScanPIIParams{Path: dir.Path()},
+	)
+	assert.NilError(t, err)
+
+	b, err := bucket.NewWithConfig(t.Context(), &bucket.Config{URL: "file:///" + t.TempDir()})
+	assert.NilError(t, err)
+	defer b.Close()
+
+	res, err := activities.NewCreateInventory(b).Execute(
+		t.Context(),
+		&activities.CreateInventoryParams{Path: dir.Path(), SIPID: uuid.New(), PII: scan.Report},
+	)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, res.Inventory.PII, &activities.PIIReport{
+		Files:   1,
+		Hits:    []activities.PIIHits{{Name: "SIN", Matches: 1, Files: 1}},
+		Flagged: []activities.PIIFile{{Path: "content/a.txt", Hits: map[string]int{"SIN": 1}}},
+	})
+
+	data, err := b.ReadAll(t.Context(), res.Key)
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), `{"files":1,"bytes":15,"formats":[{"name":"TXT","files":1,"bytes":15}],`+
+		`"pii":{"files":1,"skipped":0,"hits":[{"name":"SIN","matches":1,"files":1}],`+
+		`"flagged":[{"path":"content/a.txt","hits":{"SIN":1}}]}}`)
+}
+
+func TestInventory_Size(t *testing.T) {
+	t.Parallel()
+
+	for bytes, want := range map[int64]string{
+		0:             "0 B",
+		999:           "999 B",
+		1000:          "1.0 KB",
+		2500:          "2.5 KB",
+		118_250_000:   "118 MB",
+		3_400_000_000: "3.4 GB",
+		5e15:          "5000 TB",
+	} {
+		assert.Equal(t, (&activities.Inventory{Bytes: bytes}).Size(), want, "bytes: %d", bytes)
+	}
+}
+
+func TestInventory_FormatSummary(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, (&activities.Inventory{}).FormatSummary(), "")
+	assert.Equal(t,
+		(&activities.Inventory{
+			Formats: []activities.InventoryFormat{
+				{Name: "PDF", Files: 30},
+				{Name: "DOCX", Files: 12},
+			},
+		}).FormatSummary(),
+		"30 PDF, 12 DOCX",
+	)
+}
@@ -0,0 +1,133 @@
+package activities
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/artefactual-sdps/enduro/pkg/childwf"
+	"github.com/google/uuid"
+	"gocloud.dev/blob"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/types"
+)
+
+const CreateSIPCSVName string = "create-sip-csv-activity"
+
+// CreateSIPCSV is an activity that creates an AtoM CSV file for a single SIP
+// that is not part of a batch, with the same columns as the batch CSV file
+// (see CreateCSVConfig).
+//
+// The CSV file is written before preservation, so the AIP UUID is not known
+// and is left out of the alternative identifiers.
+type (
+	CreateSIPCSV struct {
+		bucket *blob.Bucket
+
+		// loc is the time zone used to parse VanDocs dates without a time
+		// zone offset.
+		loc *time.Location
+
+		cfg CreateCSVConfig
+	}
+	CreateSIPCSVParams struct {
+		// Path is the absolute path of the SIP directory.
+		Path string
+
+		// SIPID is the Enduro SIP UUID.
+		SIPID uuid.UUID
+
+		// Name is the SIP name.
+		Name string
+
+		// PII is the personal information report of the SIP, or nil if it
+		// was not scanned (see ScanPIIResult).
+		PII *PIIReport
+	}
+	CreateSIPCSVResult struct {
+		Key string
+	}
+)
+
+// NewCreateSIPCSV creates a new CreateSIPCSV.
+func NewCreateSIPCSV(b *blob.Bucket, loc *time.Location, cfg CreateCSVConfig) *CreateSIPCSV {
+	return &CreateSIPCSV{
+		bucket: b,
+		loc:    loc,
+		cfg:    cfg,
+	}
+}
+
+func (a *CreateSIPCSV) Execute(ctx context.Context, params *CreateSIPCSVParams) (*CreateSIPCSVResult, error) {
+	if params.Name == "" {
+		return nil, fmt.Errorf("create SIP CSV: missing name")
+	}
+
+	cols, err := newCSVColumns(a.cfg.columns())
+	if err != nil {
+		return nil, fmt.Errorf("create SIP CSV: %w", err)
+	}
+
+	md, err := parseSIPContainerMD(params.Path, a.loc)
+	if err != nil {
+		return nil, fmt.Errorf("create SIP CSV: %w", err)
+	}
+
+	inv, err := newInventory(filepath.Join(params.Path, "content"))
+	if err != nil {
+		return nil, fmt.Errorf("create SIP CSV: create inventory: %w", err)
+	}
+
+	inv.PII = params.PII
+
+	row, err := cols.row(CSVRow{
+		Index: 1,
+		SIP: &childwf.PostbatchSIP{
+			UUID:      params.SIPID,
+			Name:      params.Name,
+			FileCount: inv.Files,
+		},
+		MD:        md,
+		Events:    md.DeriveEvents(a.cfg.Events),
+		Inventory: inv,
+		Access:    accessRule(a.cfg.AccessRules, md),
+
+		piiAccessConditions: a.cfg.PIIAccessConditions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create SIP CSV: row 1: %w", err)
+	}
+
+	key := sipReportKey(md, params.SIPID, ".csv")
+
+	bw, err := a.bucket.NewWriter(ctx, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create SIP CSV: new writer: %w", err)
+	}
+	defer bw.Close()
+
+	cw := csv.NewWriter(bw)
+	if err := cw.WriteAll([][]string{cols.header(), row}); err != nil {
+		return nil, fmt.Errorf("create SIP CSV: write CSV: %w", err)
+	}
+
+	if err := bw.Close(); err != nil {
+		return nil, fmt.Errorf("create SIP CSV: close writer: %w", err)
+	}
+
+	return &CreateSIPCSVResult{Key: key}, nil
+}
+
+// sipReportKey returns the ingest bucket key of a single SIP report file with
+// the given suffix. If the SIP has no AtoM identifier the key is
+// "reports/sip_<UUID><suffix>", otherwise it is
+// "reports/sip_<identifier>_<UUID><suffix>".
+func sipReportKey(md *types.ContainerMD, sipID uuid.UUID, suffix string) string {
+	if id := md.Identifier(); id != "" {
+		return fmt.Sprintf("reports/sip_%s_%s%s", id, sipID, suffix)
+	}
+
+	return fmt.Sprintf("reports/sip_%s%s", sipID, suffix)
+}
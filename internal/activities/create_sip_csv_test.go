@@ -0,0 +1,134 @@
+package activities_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.artefactual.dev/tools/bucket"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+)
+
+func TestCreateSIPCSV_Execute(t *testing.T) {
+	t.Parallel()
+
+	sipID := uuid.MustParse("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa")
+
+	withContainerMD := func(xml string) fs.PathOp {
+		return fs.WithDir("metadata",
+			fs.WithDir("submissionDocumentation",
+				fs.WithFile("ContainerMetadata.xml", xml),
+			),
+		)
+	}
+
+	for _, tc := range []struct {
+		name        string
+		cfg         activities.CreateCSVConfig
+		params      *activities.CreateSIPCSVParams
+		ops         []fs.PathOp
+		expectedKey string
+		want        string
+		wantErr     string
+	}{
+		{
+			name:   "writes CSV for a single SIP",
+			params: &activities.CreateSIPCSVParams{SIPID: sipID, Name: "Test SIP 1"},
+			ops: []fs.PathOp{
+				fs.WithDir("content",
+					fs.WithFile("a.pdf", "a"),
+					fs.WithDir("sub", fs.WithFile("b.pdf", "b")),
+				),
+				withContainerMD(sipContainerMetadataXML(containerMDXMLParams{
+					consignment:       "900036",
+					recordNumber:      "01-5000-12/2009-01",
+					titleFreeTextPart: "Test Title 1",
+					homeLocation:      "Finance and Supply Chain Management (FSC)",
+					dateRegistered:    "2009-01-15",
+					dateClosed:        "2012-06-30",
+				})),
+			},
+			expectedKey: "reports/sip_F2009-01_aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa.csv",
+			want: strings.Join(columns, ",") +
+				"\n" +
+				"1," +
+				"01-5000-12," +
+				"VanDocs transfer: 900036," +
+				"Creation|Recordkeeping," +
+				"2009-2012|NULL," +
+				"2009-01-15|NULL," +
+				"2012-06-30|NULL," +
+				"NULL|Finance and Supply Chain Management (FSC)," +
+				"F2009-01," +
+				"01-5000-12/2009-01," +
+				"VanDocs container record number," +
+				"Test Title 1," +
+				"2 digital documents (2 B): 2 PDF," +
+				"Multiple media," +
+				"File," +
+				"en," +
+				"draft," +
+				accessConditionsValue +
+				"\n",
+		},
+		{
+			name: "writes CSV without an identifier in the key",
+			cfg: activities.CreateCSVConfig{
+				Columns: []activities.CSVColumn{
+					{Name: "title", Source: "Title"},
+					{Name: "aip", Source: "SIP.AIPID"},
+					{Name: "batch", Source: "Batch.Identifier"},
+					{Name: "name", Template: "{{.SIP.Name}}{{if .Batch}} ({{.Batch.Identifier}}){{end}}"},
+				},
+			},
+			params: &activities.CreateSIPCSVParams{SIPID: sipID, Name: "Test SIP 1"},
+			ops: []fs.PathOp{
+				fs.WithDir("content", fs.WithFile("a.pdf", "a")),
+				withContainerMD(sipContainerMetadataXML(containerMDXMLParams{
+					titleFreeTextPart: "Test Title 1",
+				})),
+			},
+			expectedKey: "reports/sip_aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa.csv",
+			want:        "title,aip,batch,name\nTest Title 1,,,Test SIP 1\n",
+		},
+		{
+			name:    "errors when the SIP name is missing",
+			params:  &activities.CreateSIPCSVParams{SIPID: sipID},
+			wantErr: "create SIP CSV: missing name",
+		},
+		{
+			name:    "errors when the ContainerMetadata.xml file is missing",
+			params:  &activities.CreateSIPCSVParams{SIPID: sipID, Name: "Test SIP 1"},
+			ops:     []fs.PathOp{fs.WithDir("content", fs.WithFile("a.pdf", "a"))},
+			wantErr: "create SIP CSV: parse container metadata: open ",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := bucket.NewWithConfig(t.Context(), &bucket.Config{URL: "file:///" + t.TempDir()})
+			assert.NilError(t, err)
+			defer b.Close()
+
+			dir := fs.NewDir(t, "cva-enduro-workflows-test", tc.ops...)
+			tc.params.Path = dir.Path()
+
+			res, err := activities.NewCreateSIPCSV(b, time.UTC, tc.cfg).Execute(t.Context(), tc.params)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Equal(t, res.Key, tc.expectedKey)
+
+			got, err := b.ReadAll(t.Context(), res.Key)
+			assert.NilError(t, err)
+			assert.Equal(t, string(got), tc.want)
+		})
+	}
+}
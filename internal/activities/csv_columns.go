@@ -0,0 +1,458 @@
+package activities
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/artefactual-sdps/enduro/pkg/childwf"
+	"github.com/google/uuid"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/types"
+)
+
+// CSVColumn configures a column of the AtoM CSV file. The column value is
+// taken from Source if set, rendered from Template if set, or else set to the
+// literal Value.
+type CSVColumn struct {
+	// Name is the CSV column header (required).
+	Name string
+
+	// Source is the name of the value source for the column, which can be:
+	//
+	//   - a derived value: "LegacyID", "QubitParentSlug", "Acquisition",
+	//     "EventTypes", "EventDates", "EventStartDates", "EventEndDates",
+	//     "EventActors", "Identifier", "AlternativeIdentifiers",
+	//     "AlternativeIdentifierLabels", "Title", "PIISummary",
+	//     "AccessConditions", "PublicationStatus" or "RestrictionNote";
+	//   - a SIP attribute: "SIP.UUID", "SIP.Name", "SIP.AIPID" or
+	//     "SIP.FileCount";
+	//   - a batch attribute: "Batch.UUID" or "Batch.Identifier";
+	//   - a ContainerMetadata.xml field, e.g. "Container.Consignment".
+	Source string
+
+	// Template is a Go text/template rendered with the CSVRow for the SIP,
+	// e.g. "{{.SIP.FileCount}} digital documents" or
+	// "{{with .Inventory}}{{.Files}} files ({{.Size}}){{end}}". The "source"
+	// function returns the value of a source, e.g. `{{source . "Identifier"}}`.
+	Template string
+
+	// Value is a literal value used when neither Source nor Template are set.
+	Value string
+}
+
+// CSVRow holds the data available to CSV column sources and templates.
+type CSVRow struct {
+	// Index is the 1-based position of the SIP in the batch.
+	Index int
+
+	// Batch is nil for a SIP that is not part of a batch.
+	Batch *childwf.PostbatchBatch
+
+	// SIP.AIPID is nil for a SIP that is not part of a batch, as its CSV file
+	// is written before preservation.
+	SIP *childwf.PostbatchSIP
+
+	MD *types.ContainerMD
+
+	// Events are the non-zero AtoM events derived from MD (see
+	// CreateCSVConfig.Events).
+	Events []types.Event
+
+	// Inventory is nil if the SIP has no file inventory, e.g. if it was
+	// preprocessed before file inventories were created.
+	Inventory *Inventory
+
+	// Access is the first access rule matched by MD, or nil if none match
+	// (see CreateCSVConfig.AccessRules). The AccessConditions and
+	// PublicationStatus sources override it for SIPs with potential personal
+	// information.
+	Access *types.AccessRule
+
+	// piiAccessConditions is the configured access conditions text of the
+	// SIPs with potential personal information.
+	piiAccessConditions string
+
+	// inventoryErr is true if the SIP file inventory could not be read, so
+	// it is not known whether the SIP has personal information.
+	inventoryErr bool
+}
+
+// access returns the resolved access of the row SIP. A SIP whose file
+// inventory can't be read is restricted as a SIP with personal information.
+func (r CSVRow) access() sipAccess {
+	var pii *PIIReport
+	if r.Inventory != nil {
+		pii = r.Inventory.PII
+	}
+	return resolveAccess(r.Access, r.inventoryErr || pii.hasHits(), r.piiAccessConditions)
+}
+
+// Batch CSV behaviours when the ContainerMetadata.xml file of a SIP can't be
+// read.
+const (
+	// OnMetadataErrorFail fails the batch CSV creation.
+	OnMetadataErrorFail string = "fail"
+
+	// OnMetadataErrorSkip omits the SIP row from the batch CSV file.
+	OnMetadataErrorSkip string = "skip"
+
+	// OnMetadataErrorPlaceholder writes the SIP row without metadata values,
+	// and the error to a "metadataWarning" column added to the batch CSV
+	// file.
+	OnMetadataErrorPlaceholder string = "placeholder"
+)
+
+// metadataWarningColumn is the name of the column added to the batch CSV file
+// in OnMetadataErrorPlaceholder mode.
+const metadataWarningColumn = "metadataWarning"
+
+type CreateCSVConfig struct {
+	// Columns lists the columns of the AtoM CSV file in order (default:
+	// DefaultCSVColumns).
+	Columns []CSVColumn
+
+	// OnMetadataError sets what the batch CSV creation does when the
+	// ContainerMetadata.xml file of a SIP is missing or can't be parsed:
+	// "fail", "skip" or "placeholder" (default: "fail").
+	OnMetadataError string
+
+	// AccessRules map the ContainerMetadata.xml PersonalInformationBank,
+	// Security, AccessControl and HasHolds fields of a SIP to its access
+	// conditions, publication status and restriction note. The first
+	// matching rule applies.
+	AccessRules []types.AccessRule
+
+	// PIIAccessConditions is the access conditions text of the SIPs with
+	// potential personal information found by the personal information
+	// scan, which are kept in draft whatever access rule matches (default: a
+	// restricted pending review text).
+	PIIAccessConditions string
+
+	// Events are the rules deriving the AtoM events of a SIP from its
+	// ContainerMetadata.xml fields, in column order (default: a Creation
+	// event from DateRegistered and DateClosed, and a Recordkeeping event
+	// from HomeLocation). The EAD finding aid uses the same events.
+	Events []types.EventRule
+}
+
+func (c CreateCSVConfig) Validate() error {
+	var errs error
+	for i, col := range c.Columns {
+		if err := col.validate(); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("Postbatch.CreateCSV.Columns[%d]: %v", i, err))
+		}
+	}
+
+	modes := []string{"", OnMetadataErrorFail, OnMetadataErrorSkip, OnMetadataErrorPlaceholder}
+	if !slices.Contains(modes, c.OnMetadataError) {
+		errs = errors.Join(errs, fmt.Errorf(
+			"Postbatch.CreateCSV.OnMetadataError: unknown value %q, must be %q, %q or %q",
+			c.OnMetadataError, OnMetadataErrorFail, OnMetadataErrorSkip, OnMetadataErrorPlaceholder,
+		))
+	}
+
+	for i, r := range c.Events {
+		if err := r.Validate(); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("Postbatch.CreateCSV.Events[%d]: %v", i, err))
+		}
+	}
+
+	for i, r := range c.AccessRules {
+		if err := r.Validate(); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("Postbatch.CreateCSV.AccessRules[%d]: %v", i, err))
+		}
+	}
+
+	return errs
+}
+
+// onMetadataError returns the configured metadata error mode, or
+// OnMetadataErrorFail if not set.
+func (c CreateCSVConfig) onMetadataError() string {
+	if c.OnMetadataError == "" {
+		return OnMetadataErrorFail
+	}
+	return c.OnMetadataError
+}
+
+// columns returns the configured columns, or the default columns if none are
+// configured.
+func (c CreateCSVConfig) columns() []CSVColumn {
+	if len(c.Columns) == 0 {
+		return DefaultCSVColumns
+	}
+	return c.Columns
+}
+
+// accessRule returns the first of rules matched by md, or nil if none match.
+func accessRule(rules []types.AccessRule, md *types.ContainerMD) *types.AccessRule {
+	r, ok := md.MatchAccessRule(rules)
+	if !ok {
+		return nil
+	}
+	return &r
+}
+
+// sipAccess is the resolved access of a SIP, shared by the AtoM CSV, the EAD
+// finding aid and the Dublin Core metadata.
+type sipAccess struct {
+	Conditions        string
+	PublicationStatus string
+	RestrictionNote   string
+}
+
+// resolveAccess returns the access of a SIP from its matched access rule,
+// which can be nil, and whether it may contain personal information. Potential
+// personal information overrides the rule: the SIP is kept in draft with the
+// piiConditions access conditions, or the default restricted access text if
+// empty, until it is reviewed.
+func resolveAccess(rule *types.AccessRule, pii bool, piiConditions string) sipAccess {
+	a := sipAccess{
+		Conditions:        accessConditions,
+		PublicationStatus: types.PublicationStatusDraft,
+	}
+	if rule != nil {
+		if rule.AccessConditions != "" {
+			a.Conditions = rule.AccessConditions
+		}
+		if rule.PublicationStatus != "" {
+			a.PublicationStatus = rule.PublicationStatus
+		}
+		a.RestrictionNote = rule.RestrictionNote
+	}
+
+	if pii {
+		a.Conditions = piiConditions
+		if a.Conditions == "" {
+			a.Conditions = defaultPIIAccessConditions
+		}
+		a.PublicationStatus = types.PublicationStatusDraft
+	}
+
+	return a
+}
+
+// defaultExtentTemplate describes the SIP extent from its file inventory, e.g.
+// "42 digital documents (118 MB): 30 PDF, 12 DOCX", or from the Enduro file
+// count if the SIP has no inventory.
+const defaultExtentTemplate = "{{with .Inventory}}" +
+	"{{if .Files}}{{.Files}} digital documents ({{.Size}}): {{.FormatSummary}}{{end}}" +
+	"{{else}}" +
+	"{{if .SIP.FileCount}}{{.SIP.FileCount}} digital documents{{end}}" +
+	"{{end}}"
+
+// DefaultCSVColumns are the AtoM information object CSV columns written when
+// no columns are configured.
+var DefaultCSVColumns = []CSVColumn{
+	{Name: "legacyId", Source: "LegacyID"},
+	{Name: "qubitParentSlug", Source: "QubitParentSlug"},
+	{Name: "acquisition", Source: "Acquisition"},
+	{Name: "eventTypes", Source: "EventTypes"},
+	{Name: "eventDates", Source: "EventDates"},
+	{Name: "eventStartDates", Source: "EventStartDates"},
+	{Name: "eventEndDates", Source: "EventEndDates"},
+	{Name: "eventActors", Source: "EventActors"},
+	{Name: "identifier", Source: "Identifier"},
+	{Name: "alternativeIdentifiers", Source: "AlternativeIdentifiers"},
+	{Name: "alternativeIdentifierLabels", Source: "AlternativeIdentifierLabels"},
+	{Name: "title", Source: "Title"},
+	{
+		Name:     "extentAndMedium",
+		Template: defaultExtentTemplate,
+	},
+	{Name: "radGeneralMaterialDesignation", Value: "Multiple media"},
+	{Name: "levelOfDescription", Value: "File"},
+	{Name: "culture", Value: "en"},
+	{Name: "publicationStatus", Source: "PublicationStatus"},
+	{Name: "accessConditions", Source: "AccessConditions"},
+}
+
+// csvSources maps the derived, SIP and batch source names to a function
+// returning the source value for a row.
+var csvSources = map[string]func(CSVRow) string{
+	"LegacyID":        func(r CSVRow) string { return strconv.Itoa(r.Index) },
+	"QubitParentSlug": func(r CSVRow) string { return r.MD.QubitParentSlug() },
+	"Acquisition":     func(r CSVRow) string { return r.MD.Acquisition() },
+	"EventTypes":      func(r CSVRow) string { return joinWithPipe(r.Events, types.Event.GetType) },
+	"EventDates":      func(r CSVRow) string { return joinWithPipe(r.Events, types.Event.FormatDates) },
+	"EventStartDates": func(r CSVRow) string { return joinWithPipe(r.Events, types.Event.FormatStart) },
+	"EventEndDates":   func(r CSVRow) string { return joinWithPipe(r.Events, types.Event.FormatEnd) },
+	"EventActors":     func(r CSVRow) string { return joinWithPipe(r.Events, types.Event.GetActor) },
+	"Identifier":      func(r CSVRow) string { return r.MD.Identifier() },
+	"AlternativeIdentifiers": func(r CSVRow) string {
+		ids, _ := r.alternativeIdentifiers()
+		return strings.Join(ids, "|")
+	},
+	"AlternativeIdentifierLabels": func(r CSVRow) string {
+		_, labels := r.alternativeIdentifiers()
+		return strings.Join(labels, "|")
+	},
+	"Title": func(r CSVRow) string { return r.MD.Title() },
+	"PIISummary": func(r CSVRow) string {
+		if r.Inventory == nil || r.Inventory.PII == nil {
+			return ""
+		}
+		return r.Inventory.PII.HitSummary()
+	},
+	"AccessConditions":  func(r CSVRow) string { return r.access().Conditions },
+	"PublicationStatus": func(r CSVRow) string { return r.access().PublicationStatus },
+	"RestrictionNote":   func(r CSVRow) string { return r.access().RestrictionNote },
+	"SIP.UUID":          func(r CSVRow) string { return r.SIP.UUID.String() },
+	"SIP.Name":          func(r CSVRow) string { return r.SIP.Name },
+	"SIP.AIPID": func(r CSVRow) string {
+		if r.SIP.AIPID == nil {
+			return ""
+		}
+		return r.SIP.AIPID.String()
+	},
+	"SIP.FileCount": func(r CSVRow) string { return strconv.Itoa(r.SIP.FileCount) },
+	"Batch.UUID": func(r CSVRow) string {
+		if r.Batch == nil {
+			return ""
+		}
+		return r.Batch.UUID.String()
+	},
+	"Batch.Identifier": func(r CSVRow) string {
+		if r.Batch == nil {
+			return ""
+		}
+		return r.Batch.Identifier
+	},
+}
+
+// alternativeIdentifiers returns the alternative identifiers and labels of
+// row r. The AIP UUID, which is always the first alternative identifier, is
+// left out if the SIP has no AIP yet.
+func (r CSVRow) alternativeIdentifiers() (ids, labels []string) {
+	if r.SIP.AIPID == nil {
+		ids, labels := r.MD.AlternativeIdentifiers(uuid.Nil)
+		return ids[1:], labels[1:]
+	}
+
+	return r.MD.AlternativeIdentifiers(*r.SIP.AIPID)
+}
+
+// CSVSources returns the names of all the available column sources in
+// alphabetical order.
+func CSVSources() []string {
+	names := make([]string, 0, len(csvSources))
+	for name := range csvSources {
+		names = append(names, name)
+	}
+	for _, name := range types.ContainerMDFields() {
+		names = append(names, "Container."+name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// sourceValue returns the value of the named source for row r.
+func sourceValue(r CSVRow, name string) (string, error) {
+	if f, ok := csvSources[name]; ok {
+		return f(r), nil
+	}
+	if field, ok := strings.CutPrefix(name, "Container."); ok {
+		if v, ok := r.MD.FieldValue(field); ok {
+			return v, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown source %q", name)
+}
+
+// isSource reports whether name is a known column source.
+func isSource(name string) bool {
+	if _, ok := csvSources[name]; ok {
+		return true
+	}
+	field, ok := strings.CutPrefix(name, "Container.")
+	return ok && types.IsContainerMDField(field)
+}
+
+func (c CSVColumn) validate() error {
+	var errs error
+	if c.Name == "" {
+		errs = errors.Join(errs, errors.New("Name: missing required value"))
+	}
+	if c.Source != "" && c.Template != "" {
+		errs = errors.Join(errs, errors.New("only one of Source or Template can be set"))
+	}
+	if c.Source != "" && !isSource(c.Source) {
+		errs = errors.Join(errs, fmt.Errorf("Source: unknown source %q", c.Source))
+	}
+	if c.Template != "" {
+		if _, err := c.parseTemplate(); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("Template: %v", err))
+		}
+	}
+
+	return errs
+}
+
+func (c CSVColumn) parseTemplate() (*template.Template, error) {
+	return template.New(c.Name).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"source": sourceValue}).
+		Parse(c.Template)
+}
+
+// csvColumns renders the values of a list of CSV columns.
+type csvColumns struct {
+	cols  []CSVColumn
+	tmpls []*template.Template
+}
+
+func newCSVColumns(cols []CSVColumn) (*csvColumns, error) {
+	c := &csvColumns{cols: cols, tmpls: make([]*template.Template, len(cols))}
+	for i, col := range cols {
+		if col.Template == "" {
+			continue
+		}
+		t, err := col.parseTemplate()
+		if err != nil {
+			return nil, fmt.Errorf("column %q: parse template: %v", col.Name, err)
+		}
+		c.tmpls[i] = t
+	}
+
+	return c, nil
+}
+
+// header returns the column names.
+func (c *csvColumns) header() []string {
+	names := make([]string, len(c.cols))
+	for i, col := range c.cols {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// row returns the column values for r.
+func (c *csvColumns) row(r CSVRow) ([]string, error) {
+	vals := make([]string, len(c.cols))
+	for i, col := range c.cols {
+		switch {
+		case col.Source != "":
+			v, err := sourceValue(r, col.Source)
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %v", col.Name, err)
+			}
+			vals[i] = v
+		case c.tmpls[i] != nil:
+			var sb strings.Builder
+			if err := c.tmpls[i].Execute(&sb, r); err != nil {
+				return nil, fmt.Errorf("column %q: %v", col.Name, err)
+			}
+			vals[i] = sb.String()
+		default:
+			vals[i] = col.Value
+		}
+	}
+
+	return vals, nil
+}
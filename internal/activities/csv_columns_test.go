@@ -0,0 +1,108 @@
+package activities_test
+
+import (
+	"slices"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/types"
+)
+
+func TestCreateCSVConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name    string
+		cfg     activities.CreateCSVConfig
+		wantErr string
+	}{
+		{
+			name: "accepts an empty config",
+		},
+		{
+			name: "accepts the default columns",
+			cfg:  activities.CreateCSVConfig{Columns: activities.DefaultCSVColumns},
+		},
+		{
+			name: "accepts sources, templates and literal values",
+			cfg: activities.CreateCSVConfig{
+				Columns: []activities.CSVColumn{
+					{Name: "identifier", Source: "Identifier"},
+					{Name: "notes", Source: "Container.Notes"},
+					{Name: "batch", Source: "Batch.UUID"},
+					{Name: "title", Template: "{{.MD.Title}} ({{.SIP.Name}})"},
+					{Name: "culture", Value: "en"},
+					{Name: "empty"},
+				},
+			},
+		},
+		{
+			name: "rejects invalid columns",
+			cfg: activities.CreateCSVConfig{
+				Columns: []activities.CSVColumn{
+					{Source: "Identifier"},
+					{Name: "title", Source: "Title", Template: "{{.MD.Title}}"},
+					{Name: "colour", Source: "Container.Colour"},
+					{Name: "size", Source: "Size"},
+					{Name: "broken", Template: "{{.MD.Title"},
+				},
+			},
+			wantErr: `Postbatch.CreateCSV.Columns[0]: Name: missing required value
+Postbatch.CreateCSV.Columns[1]: only one of Source or Template can be set
+Postbatch.CreateCSV.Columns[2]: Source: unknown source "Container.Colour"
+Postbatch.CreateCSV.Columns[3]: Source: unknown source "Size"
+Postbatch.CreateCSV.Columns[4]: Template: template: broken:1: unclosed action`,
+		},
+		{
+			name: "rejects invalid access rules",
+			cfg: activities.CreateCSVConfig{
+				AccessRules: []types.AccessRule{
+					{Security: []string{"Protected *"}, PublicationStatus: "published"},
+					{Name: "No access"},
+					{AccessControl: []string{"["}, PublicationStatus: "public"},
+				},
+			},
+			wantErr: `Postbatch.CreateCSV.AccessRules[1]: one of AccessConditions, PublicationStatus or RestrictionNote must be set
+Postbatch.CreateCSV.AccessRules[2]: AccessControl[0]: invalid pattern "[": syntax error in pattern
+PublicationStatus: unknown value "public", must be "draft" or "published"`,
+		},
+		{
+			name: "rejects invalid event rules",
+			cfg: activities.CreateCSVConfig{
+				Events: []types.EventRule{
+					{Type: "Accumulation", Start: "DateCreated", Actor: []string{"Department"}},
+					{Type: "Birth", Start: "Title", Actor: []string{"DateCreated"}},
+					{Type: "Publication"},
+				},
+			},
+			wantErr: `Postbatch.CreateCSV.Events[1]: Type: unknown event type "Birth", must be one of Creation, Recordkeeping, Accumulation, Publication
+Start: "Title" is not a date field
+Actor[0]: "DateCreated" is not a text field
+Postbatch.CreateCSV.Events[2]: one of Start, End or Actor must be set`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.cfg.Validate()
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestCSVSources(t *testing.T) {
+	t.Parallel()
+
+	sources := activities.CSVSources()
+	assert.Assert(t, len(sources) > 0)
+	for _, name := range []string{"Identifier", "SIP.AIPID", "Batch.Identifier", "Container.RecordNumber", "AccessConditions", "PublicationStatus", "RestrictionNote"} {
+		assert.Assert(t, slices.Contains(sources, name), "missing source %q", name)
+	}
+}
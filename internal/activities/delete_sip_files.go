@@ -0,0 +1,56 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+)
+
+const DeleteSIPFilesName string = "delete-sip-files-activity"
+
+// DeleteSIPFiles is an activity that deletes the ContainerMetadata.xml file
+// and file inventory uploaded to the ingest bucket by the preprocessing
+// workflow for a SIP. Missing files are not an error, as SIPs preprocessed
+// before file inventories were created have no inventory, and a retried
+// deletion may find the files already deleted.
+type (
+	DeleteSIPFiles struct {
+		bucket *blob.Bucket
+	}
+	DeleteSIPFilesParams struct {
+		// SIPID is the Enduro SIP UUID.
+		SIPID uuid.UUID
+	}
+	DeleteSIPFilesResult struct {
+		// Deleted lists the keys of the deleted files.
+		Deleted []string
+	}
+)
+
+// NewDeleteSIPFiles creates a new DeleteSIPFiles.
+func NewDeleteSIPFiles(b *blob.Bucket) *DeleteSIPFiles {
+	return &DeleteSIPFiles{bucket: b}
+}
+
+func (a *DeleteSIPFiles) Execute(ctx context.Context, params *DeleteSIPFilesParams) (*DeleteSIPFilesResult, error) {
+	res := &DeleteSIPFilesResult{Deleted: []string{}}
+
+	for _, key := range []string{
+		fmt.Sprintf("%s_ContainerMetadata.xml", params.SIPID),
+		inventoryKey(params.SIPID),
+	} {
+		err := a.bucket.Delete(ctx, key)
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("delete SIP files: %s: %w", key, err)
+		}
+		res.Deleted = append(res.Deleted, key)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,72 @@
+package activities_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"go.artefactual.dev/tools/bucket"
+	"gocloud.dev/blob"
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+)
+
+func TestDeleteSIPFiles_Execute(t *testing.T) {
+	t.Parallel()
+
+	sipID := uuid.MustParse("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa")
+
+	for _, tc := range []struct {
+		name  string
+		setup func(t *testing.T, b *blob.Bucket)
+		want  []string
+	}{
+		{
+			name: "deletes the ContainerMetadata.xml file and file inventory",
+			setup: func(t *testing.T, b *blob.Bucket) {
+				seedContainerMetadataXML(t, b, sipID, "<ContainerMetadata/>")
+				seedInventory(t, b, sipID, "{}")
+			},
+			want: []string{
+				"aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa_ContainerMetadata.xml",
+				"aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa_inventory.json",
+			},
+		},
+		{
+			name: "ignores missing files",
+			setup: func(t *testing.T, b *blob.Bucket) {
+				seedContainerMetadataXML(t, b, sipID, "<ContainerMetadata/>")
+			},
+			want: []string{"aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa_ContainerMetadata.xml"},
+		},
+		{
+			name: "succeeds when the files are already deleted",
+			want: []string{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := bucket.NewWithConfig(t.Context(), &bucket.Config{URL: "file:///" + t.TempDir()})
+			assert.NilError(t, err)
+			defer b.Close()
+
+			if tc.setup != nil {
+				tc.setup(t, b)
+			}
+
+			res, err := activities.NewDeleteSIPFiles(b).Execute(
+				t.Context(),
+				&activities.DeleteSIPFilesParams{SIPID: sipID},
+			)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, res.Deleted, tc.want)
+
+			for _, key := range tc.want {
+				ok, err := b.Exists(t.Context(), key)
+				assert.NilError(t, err)
+				assert.Assert(t, !ok, key)
+			}
+		})
+	}
+}
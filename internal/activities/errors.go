@@ -0,0 +1,18 @@
+package activities
+
+import (
+	temporalsdk_temporal "go.temporal.io/sdk/temporal"
+)
+
+// ContentErrorType is the Temporal application error type used by activities
+// to report problems with the contents of a SIP, e.g. a missing file or an
+// invalid metadata field. Retrying won't fix a content error, the SIP has to
+// be corrected and submitted again.
+const ContentErrorType = "ContentError"
+
+// NewContentError returns a non-retryable Temporal application error of type
+// ContentErrorType. The message should summarize the problem and name the
+// offending file, and failures should list each individual problem found.
+func NewContentError(msg string, failures ...string) error {
+	return temporalsdk_temporal.NewNonRetryableApplicationError(msg, ContentErrorType, nil, failures)
+}
@@ -0,0 +1,34 @@
+package activities_test
+
+import (
+	"errors"
+	"testing"
+
+	temporalsdk_temporal "go.temporal.io/sdk/temporal"
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+)
+
+// assertContentError asserts that err is a content error with the given
+// message and failures.
+func assertContentError(t *testing.T, err error, msg string, failures []string) {
+	t.Helper()
+
+	var appErr *temporalsdk_temporal.ApplicationError
+	assert.Assert(t, errors.As(err, &appErr), "not an application error: %v", err)
+	assert.Equal(t, appErr.Type(), activities.ContentErrorType)
+	assert.Equal(t, appErr.Message(), msg)
+	assert.Assert(t, appErr.NonRetryable())
+
+	var got []string
+	assert.NilError(t, appErr.Details(&got))
+	assert.DeepEqual(t, got, failures)
+}
+
+func TestNewContentError(t *testing.T) {
+	t.Parallel()
+
+	err := activities.NewContentError("The SIP is not valid", "failure 1", "failure 2")
+	assertContentError(t, err, "The SIP is not valid", []string{"failure 1", "failure 2"})
+}
@@ -0,0 +1,300 @@
+package activities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	temporalsdk_activity "go.temporal.io/sdk/activity"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/formatid"
+)
+
+const (
+	IdentifyFormatsName string = "identify-formats-activity"
+
+	// Format policy behaviours when a file format is not allowed.
+	OnFormatViolationFail string = "fail"
+	OnFormatViolationWarn string = "warn"
+
+	// formatReportPath is the path of the format identification report
+	// relative to the SIP root.
+	formatReportPath string = "metadata/format-identification.json"
+
+	// identifyFormatsTimeoutPerGiB is the default format identification time
+	// allowed for each GiB of SIP files.
+	identifyFormatsTimeoutPerGiB = time.Minute
+)
+
+type IdentifyFormatsConfig struct {
+	// Enabled adds the format identification and policy check of the SIP
+	// content files to the preprocessing workflow.
+	Enabled bool
+
+	// SignatureFile is the path of a JSON signature file (default: the
+	// bundled signatures).
+	SignatureFile string
+
+	// Allow lists the accepted PUIDs. If not empty, the formats not listed
+	// are not allowed. Use "UNKNOWN" to allow unidentified files.
+	Allow []string
+
+	// Deny lists the rejected PUIDs.
+	Deny []string
+
+	// AllowTentative lists the PUIDs accepted when a file is identified
+	// tentatively, e.g. by extension only. Other tentative identifications
+	// are checked against Allow as "UNKNOWN", and against Deny by their PUID.
+	AllowTentative []string
+
+	// OnViolation sets what happens when a file format is not allowed:
+	// "fail" rejects the SIP with a content error, "warn" reports the file in
+	// the task message (default: "fail").
+	OnViolation string
+
+	// TimeoutPerGiB is the identification time allowed for each GiB of SIP
+	// files, added to a 10 minute minimum timeout (default: 1m).
+	TimeoutPerGiB time.Duration
+}
+
+func (c IdentifyFormatsConfig) Validate() error {
+	var errs error
+	if !slices.Contains([]string{"", OnFormatViolationFail, OnFormatViolationWarn}, c.OnViolation) {
+		errs = errors.Join(errs, fmt.Errorf(
+			"Preprocessing.IdentifyFormats.OnViolation: unknown value %q, must be %q or %q",
+			c.OnViolation, OnFormatViolationFail, OnFormatViolationWarn,
+		))
+	}
+	for _, puid := range c.Deny {
+		if slices.Contains(c.Allow, puid) {
+			errs = errors.Join(errs, fmt.Errorf(
+				"Preprocessing.IdentifyFormats.Deny: %q is also in Preprocessing.IdentifyFormats.Allow", puid,
+			))
+		}
+	}
+	if c.TimeoutPerGiB < 0 {
+		errs = errors.Join(errs, fmt.Errorf(
+			"Preprocessing.IdentifyFormats.TimeoutPerGiB: %s is negative", c.TimeoutPerGiB,
+		))
+	}
+
+	return errs
+}
+
+// Timeout returns the format identification timeout for a SIP of size bytes:
+// 10 minutes plus TimeoutPerGiB for each GiB of SIP files.
+func (c IdentifyFormatsConfig) Timeout(size int64) time.Duration {
+	return sizeTimeout(size, c.TimeoutPerGiB, identifyFormatsTimeoutPerGiB)
+}
+
+// allowed reports whether the format policy accepts the identification r.
+// Deny is checked against the identified PUID, even if the identification is
+// tentative, so a denied format is rejected however it is identified.
+func (c IdentifyFormatsConfig) allowed(r formatid.Result) bool {
+	if slices.Contains(c.Deny, r.PUID) {
+		return false
+	}
+
+	return len(c.Allow) == 0 || slices.Contains(c.Allow, c.policyPUID(r))
+}
+
+// policyPUID returns the PUID of r checked against the Allow list: tentative
+// identifications not in AllowTentative are checked as unknown.
+func (c IdentifyFormatsConfig) policyPUID(r formatid.Result) string {
+	if r.Tentative && !slices.Contains(c.AllowTentative, r.PUID) {
+		return formatid.UnknownPUID
+	}
+
+	return r.PUID
+}
+
+// IdentifyFormats is an activity that identifies the format of every file in
+// the SIP "content" directory, and checks it against the configured allow and
+// deny lists.
+//
+// The identification results are written to a
+// "metadata/format-identification.json" report in the SIP. Tentative
+// identifications, e.g. by extension only, are checked against Deny by their
+// PUID, and against Allow as "UNKNOWN" unless their PUID is in
+// AllowTentative. Files with a format that is not allowed are reported as a
+// content error, or as warnings in the result if OnViolation is "warn". The
+// activity records a heartbeat with the number of files identified after each
+// file.
+type (
+	IdentifyFormats struct {
+		identifier *formatid.Identifier
+		cfg        IdentifyFormatsConfig
+	}
+	IdentifyFormatsParams struct {
+		// Path is the absolute path of the SIP directory.
+		Path string
+	}
+	IdentifyFormatsResult struct {
+		// Path is the path of the report relative to the SIP root.
+		Path string
+
+		// Files is the number of files identified.
+		Files int
+
+		// Warnings lists the files with a format that is not allowed, if
+		// OnViolation is "warn".
+		Warnings []string
+	}
+)
+
+// formatReport is the format identification report written to the SIP.
+type formatReport struct {
+	Signatures string             `json:"signatures"`
+	Files      []formatReportFile `json:"files"`
+}
+
+type formatReportFile struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	PUID      string `json:"puid"`
+	Format    string `json:"format,omitempty"`
+	MIMEType  string `json:"mimeType,omitempty"`
+	Basis     string `json:"basis"`
+	Tentative bool   `json:"tentative,omitempty"`
+	Allowed   bool   `json:"allowed"`
+}
+
+// NewIdentifyFormats creates a new IdentifyFormats.
+func NewIdentifyFormats(identifier *formatid.Identifier, cfg IdentifyFormatsConfig) *IdentifyFormats {
+	return &IdentifyFormats{
+		identifier: identifier,
+		cfg:        cfg,
+	}
+}
+
+func (a *IdentifyFormats) Execute(
+	ctx context.Context,
+	params *IdentifyFormatsParams,
+) (*IdentifyFormatsResult, error) {
+	report := formatReport{
+		Signatures: a.identifier.Version(),
+		Files:      []formatReportFile{},
+	}
+
+	var violations []string
+	err := filepath.WalkDir(filepath.Join(params.Path, "content"), func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(params.Path, name)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		file, err := a.identify(name, rel)
+		if err != nil {
+			return err
+		}
+		report.Files = append(report.Files, file)
+
+		if !file.Allowed {
+			violations = append(violations, fmt.Sprintf("%s: %s is not allowed", rel, formatName(file)))
+		}
+
+		if temporalsdk_activity.IsActivity(ctx) {
+			temporalsdk_activity.RecordHeartbeat(ctx, len(report.Files))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("identify formats: %w", err)
+	}
+
+	if err := writeFormatReport(filepath.Join(params.Path, formatReportPath), report); err != nil {
+		return nil, fmt.Errorf("identify formats: %w", err)
+	}
+
+	res := &IdentifyFormatsResult{
+		Path:  formatReportPath,
+		Files: len(report.Files),
+	}
+	if len(violations) > 0 {
+		if a.cfg.OnViolation == OnFormatViolationWarn {
+			res.Warnings = violations
+		} else {
+			return nil, NewContentError("Files with a format that is not allowed were found in the SIP", violations...)
+		}
+	}
+
+	return res, nil
+}
+
+func (a *IdentifyFormats) identify(name, rel string) (formatReportFile, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return formatReportFile{}, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return formatReportFile{}, err
+	}
+
+	r, err := a.identifier.Identify(rel, f)
+	if err != nil {
+		return formatReportFile{}, err
+	}
+
+	return formatReportFile{
+		Path:      rel,
+		Size:      fi.Size(),
+		PUID:      r.PUID,
+		Format:    r.Format,
+		MIMEType:  r.MIMEType,
+		Basis:     r.Basis,
+		Tentative: r.Tentative,
+		Allowed:   a.cfg.allowed(r),
+	}, nil
+}
+
+// formatName returns a description of the file format for messages, e.g.
+// "x-fmt/411 (Windows Portable Executable)".
+func formatName(f formatReportFile) string {
+	name := f.PUID
+	if f.Format != "" {
+		name = fmt.Sprintf("%s (%s)", f.PUID, f.Format)
+	}
+	if f.Tentative {
+		name += " identified tentatively by " + f.Basis
+	}
+
+	return name
+}
+
+func writeFormatReport(path string, report formatReport) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("write %s: %w", formatReportPath, err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", formatReportPath, err)
+	}
+
+	return nil
+}
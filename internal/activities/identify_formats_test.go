@@ -0,0 +1,221 @@
+package activities_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/formatid"
+)
+
+const formatReport = `{
+  "signatures": "cva-2026-10",
+  "files": [
+    {
+      "path": "content/a.pdf",
+      "size": 9,
+      "puid": "fmt/276",
+      "format": "Acrobat PDF 1.7 - Portable Document Format",
+      "mimeType": "application/pdf",
+      "basis": "signature and extension match",
+      "allowed": true
+    },
+    {
+      "path": "content/sub/b.exe",
+      "size": 4,
+      "puid": "x-fmt/411",
+      "format": "Windows Portable Executable",
+      "mimeType": "application/vnd.microsoft.portable-executable",
+      "basis": "signature and extension match",
+      "allowed": false
+    },
+    {
+      "path": "content/sub/c.dat",
+      "size": 3,
+      "puid": "UNKNOWN",
+      "basis": "no match",
+      "allowed": %s
+    }
+  ]
+}
+`
+
+func TestIdentifyFormats_Execute(t *testing.T) {
+	t.Parallel()
+
+	sipOps := []fs.PathOp{
+		fs.WithDir("content",
+			fs.WithFile("a.pdf", "%PDF-1.7\n"),
+			fs.WithDir("sub",
+				fs.WithFile("b.exe", "MZ\x90\x00"),
+				fs.WithFile("c.dat", "abc"),
+			),
+		),
+		fs.WithDir("metadata"),
+	}
+
+	for _, tc := range []struct {
+		name       string
+		cfg        activities.IdentifyFormatsConfig
+		want       *activities.IdentifyFormatsResult
+		wantReport string
+		failures   []string
+	}{
+		{
+			name: "rejects files with a format that is not allowed",
+			cfg: activities.IdentifyFormatsConfig{
+				Allow: []string{"fmt/276", "UNKNOWN"},
+			},
+			wantReport: fmt.Sprintf(formatReport, "true"),
+			failures: []string{
+				"content/sub/b.exe: x-fmt/411 (Windows Portable Executable) is not allowed",
+			},
+		},
+		{
+			name: "rejects files with a denied format",
+			cfg: activities.IdentifyFormatsConfig{
+				Deny: []string{"x-fmt/411", "UNKNOWN"},
+			},
+			wantReport: fmt.Sprintf(formatReport, "false"),
+			failures: []string{
+				"content/sub/b.exe: x-fmt/411 (Windows Portable Executable) is not allowed",
+				"content/sub/c.dat: UNKNOWN is not allowed",
+			},
+		},
+		{
+			name: "warns about files with a format that is not allowed",
+			cfg: activities.IdentifyFormatsConfig{
+				Deny:        []string{"x-fmt/411"},
+				OnViolation: activities.OnFormatViolationWarn,
+			},
+			want: &activities.IdentifyFormatsResult{
+				Path:  "metadata/format-identification.json",
+				Files: 3,
+				Warnings: []string{
+					"content/sub/b.exe: x-fmt/411 (Windows Portable Executable) is not allowed",
+				},
+			},
+			wantReport: fmt.Sprintf(formatReport, "true"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			id, err := formatid.Load("")
+			assert.NilError(t, err)
+
+			dir := fs.NewDir(t, "cva-enduro-workflows-test", sipOps...)
+
+			res, err := activities.NewIdentifyFormats(id, tc.cfg).Execute(
+				t.Context(),
+				&activities.IdentifyFormatsParams{Path: dir.Path()},
+			)
+			if tc.failures != nil {
+				assertContentError(t, err, "Files with a format that is not allowed were found in the SIP", tc.failures)
+			} else {
+				assert.NilError(t, err)
+				assert.DeepEqual(t, res, tc.want)
+			}
+
+			assert.Assert(t, fs.Equal(dir.Path(), fs.Expected(t,
+				fs.WithDir("content",
+					fs.WithFile("a.pdf", "%PDF-1.7\n"),
+					fs.WithDir("sub",
+						fs.WithFile("b.exe", "MZ\x90\x00"),
+						fs.WithFile("c.dat", "abc"),
+					),
+				),
+				fs.WithDir("metadata",
+					fs.WithFile("format-identification.json", tc.wantReport, fs.MatchAnyFileMode),
+				),
+				fs.MatchAnyFileMode,
+			)))
+		})
+	}
+}
+
+func TestIdentifyFormats_ExecuteTentative(t *testing.T) {
+	t.Parallel()
+
+	id, err := formatid.Load("")
+	assert.NilError(t, err)
+
+	dir := fs.NewDir(t, "cva-enduro-workflows-test",
+		fs.WithDir("content",
+			fs.WithFile("letter.docx", "PK\x03\x04\x14\x00"),
+			fs.WithFile("notes.txt", "Meeting notes"),
+			fs.WithFile("photo.jpg", "\xff\xd8\xff\xe1\x00\x16Exif\x00\x00"),
+		),
+		fs.WithDir("metadata"),
+	)
+
+	_, err = activities.NewIdentifyFormats(id, activities.IdentifyFormatsConfig{
+		Allow:          []string{"fmt/41", "fmt/412", "x-fmt/111"},
+		AllowTentative: []string{"x-fmt/111"},
+	}).Execute(
+		t.Context(),
+		&activities.IdentifyFormatsParams{Path: dir.Path()},
+	)
+	assertContentError(t, err, "Files with a format that is not allowed were found in the SIP", []string{
+		"content/letter.docx: fmt/412 (Microsoft Word for Windows 2007 onwards) identified tentatively by signature and extension match is not allowed",
+		"content/photo.jpg: fmt/41 (Raw JPEG Stream) identified tentatively by signature and extension match is not allowed",
+	})
+}
+
+func TestIdentifyFormats_ExecuteTentativeDenied(t *testing.T) {
+	t.Parallel()
+
+	id, err := formatid.Load("")
+	assert.NilError(t, err)
+
+	dir := fs.NewDir(t, "cva-enduro-workflows-test",
+		fs.WithDir("content",
+			fs.WithFile("archive.bin", "PK\x03\x04\x14\x00"),
+			fs.WithFile("notes.txt", "Meeting notes"),
+		),
+		fs.WithDir("metadata"),
+	)
+
+	_, err = activities.NewIdentifyFormats(id, activities.IdentifyFormatsConfig{
+		Allow: []string{"UNKNOWN"},
+		Deny:  []string{"x-fmt/263"},
+	}).Execute(
+		t.Context(),
+		&activities.IdentifyFormatsParams{Path: dir.Path()},
+	)
+	assertContentError(t, err, "Files with a format that is not allowed were found in the SIP", []string{
+		"content/archive.bin: x-fmt/263 (ZIP Format) identified tentatively by signature match is not allowed",
+	})
+}
+
+func TestIdentifyFormatsConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	assert.NilError(t, activities.IdentifyFormatsConfig{
+		Allow:       []string{"fmt/276"},
+		Deny:        []string{"x-fmt/411"},
+		OnViolation: activities.OnFormatViolationWarn,
+	}.Validate())
+	assert.Error(t,
+		activities.IdentifyFormatsConfig{
+			Allow:         []string{"fmt/276"},
+			Deny:          []string{"fmt/276"},
+			OnViolation:   "ignore",
+			TimeoutPerGiB: -time.Minute,
+		}.Validate(),
+		`Preprocessing.IdentifyFormats.OnViolation: unknown value "ignore", must be "fail" or "warn"
+Preprocessing.IdentifyFormats.Deny: "fmt/276" is also in Preprocessing.IdentifyFormats.Allow
+Preprocessing.IdentifyFormats.TimeoutPerGiB: -1m0s is negative`,
+	)
+}
+
+func TestIdentifyFormatsConfig_Timeout(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, activities.IdentifyFormatsConfig{}.Timeout(2<<30), 12*time.Minute)
+	assert.Equal(t, activities.IdentifyFormatsConfig{TimeoutPerGiB: 30 * time.Second}.Timeout(4<<30), 12*time.Minute)
+}
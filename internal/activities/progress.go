@@ -0,0 +1,57 @@
+package activities
+
+import (
+	"context"
+
+	temporalsdk_activity "go.temporal.io/sdk/activity"
+)
+
+// heartbeatBytes is the number of bytes read between heartbeats while reading
+// a large file.
+const heartbeatBytes = 64 << 20
+
+// FileProgress is the progress recorded in the heartbeats of the activities
+// that read every SIP file, e.g. to hash or scan them.
+type FileProgress struct {
+	// Files is the number of files processed.
+	Files int
+
+	// Bytes is the number of bytes processed.
+	Bytes int64
+}
+
+// fileProgress counts the files and bytes processed, and records activity
+// heartbeats with the progress.
+type fileProgress struct {
+	FileProgress
+
+	ctx context.Context
+
+	// unreported is the number of bytes processed since the last heartbeat.
+	unreported int64
+}
+
+// Write counts the bytes processed, and records a heartbeat every
+// heartbeatBytes.
+func (p *fileProgress) Write(b []byte) (int, error) {
+	p.Bytes += int64(len(b))
+	p.unreported += int64(len(b))
+	if p.unreported >= heartbeatBytes {
+		p.heartbeat()
+	}
+
+	return len(b), nil
+}
+
+// fileDone counts a processed file and records a heartbeat.
+func (p *fileProgress) fileDone() {
+	p.Files++
+	p.heartbeat()
+}
+
+func (p *fileProgress) heartbeat() {
+	p.unreported = 0
+	if temporalsdk_activity.IsActivity(p.ctx) {
+		temporalsdk_activity.RecordHeartbeat(p.ctx, p.FileProgress)
+	}
+}
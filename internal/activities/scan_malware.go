@@ -0,0 +1,197 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/clamav"
+)
+
+const (
+	ScanMalwareName string = "scan-malware-activity"
+
+	// malwareScanTimeoutPerGiB is the default malware scan time allowed for
+	// each GiB of SIP files.
+	malwareScanTimeoutPerGiB = 2 * time.Minute
+)
+
+// Scanner scans file contents for malware.
+type Scanner interface {
+	// Scan returns the name of the malware signature found in r, or an empty
+	// string if no malware is found.
+	Scan(ctx context.Context, r io.Reader) (string, error)
+}
+
+type MalwareScanConfig struct {
+	// Enabled adds a malware scan of the SIP files to the preprocessing
+	// workflow.
+	Enabled bool
+
+	// Address is the ClamAV daemon address, e.g. "tcp://clamav:3310" or
+	// "unix:///var/run/clamav/clamd.ctl" (required if enabled).
+	Address string
+
+	// FileTimeout is the maximum duration of the scan of a single file
+	// (default: no timeout).
+	FileTimeout time.Duration
+
+	// TimeoutPerGiB is the scan time allowed for each GiB of SIP files, added
+	// to a 10 minute minimum timeout (default: 2m).
+	TimeoutPerGiB time.Duration
+
+	// AllowUnscanned accepts the SIPs with files that can't be scanned
+	// because they exceed the ClamAV daemon stream size limit, and lists the
+	// files in the task message. By default these SIPs are rejected with a
+	// content error.
+	AllowUnscanned bool
+}
+
+func (c MalwareScanConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	var errs error
+	if c.Address == "" {
+		errs = errors.Join(errs, errors.New("Preprocessing.MalwareScan.Address: missing required value"))
+	} else if _, _, err := clamav.ParseAddress(c.Address); err != nil {
+		errs = errors.Join(errs, fmt.Errorf("Preprocessing.MalwareScan.Address: %v", err))
+	}
+	if c.FileTimeout < 0 {
+		errs = errors.Join(errs, fmt.Errorf("Preprocessing.MalwareScan.FileTimeout: %s is negative", c.FileTimeout))
+	}
+	if c.TimeoutPerGiB < 0 {
+		errs = errors.Join(errs, fmt.Errorf(
+			"Preprocessing.MalwareScan.TimeoutPerGiB: %s is negative", c.TimeoutPerGiB,
+		))
+	}
+
+	return errs
+}
+
+// Timeout returns the malware scan timeout for a SIP of size bytes: 10 minutes
+// plus TimeoutPerGiB for each GiB of SIP files.
+func (c MalwareScanConfig) Timeout(size int64) time.Duration {
+	return sizeTimeout(size, c.TimeoutPerGiB, malwareScanTimeoutPerGiB)
+}
+
+// ScanMalware is an activity that scans every file in a SIP for malware.
+//
+// If malware is found a content error listing the infected files, relative to
+// the SIP root, and their signature names is returned, so the SIP is rejected.
+// Files larger than the ClamAV daemon StreamMaxLength setting can't be
+// scanned: they are reported as a content error too, unless AllowUnscanned is
+// set and they are listed in the result. The activity records a heartbeat
+// with the FileProgress after each file, and while streaming large files.
+type (
+	ScanMalware struct {
+		scanner Scanner
+		cfg     MalwareScanConfig
+	}
+	ScanMalwareParams struct {
+		// Path is the absolute path of the SIP directory.
+		Path string
+	}
+	ScanMalwareResult struct {
+		// Files is the number of files scanned.
+		Files int
+
+		// Unscanned lists the files, relative to the SIP root, that were not
+		// scanned because they exceed the ClamAV daemon stream size limit, if
+		// AllowUnscanned is set.
+		Unscanned []string
+	}
+)
+
+// NewScanMalware creates a new ScanMalware.
+func NewScanMalware(scanner Scanner, cfg MalwareScanConfig) *ScanMalware {
+	return &ScanMalware{
+		scanner: scanner,
+		cfg:     cfg,
+	}
+}
+
+func (a *ScanMalware) Execute(ctx context.Context, params *ScanMalwareParams) (*ScanMalwareResult, error) {
+	var (
+		unscanned = []string{}
+		failures  []string
+	)
+
+	p := &fileProgress{ctx: ctx}
+
+	err := filepath.WalkDir(params.Path, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(params.Path, name)
+		if err != nil {
+			return err
+		}
+
+		sig, err := a.scanFile(ctx, name, p)
+		if errors.Is(err, clamav.ErrSizeLimit) {
+			unscanned = append(unscanned, filepath.ToSlash(rel))
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("scan %s: %w", rel, err)
+		}
+		if sig != "" {
+			failures = append(failures, fmt.Sprintf("%s: %s", filepath.ToSlash(rel), sig))
+		}
+
+		p.fileDone()
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan malware: %w", err)
+	}
+
+	if len(failures) > 0 {
+		if !a.cfg.AllowUnscanned {
+			failures = append(failures, unscannedFailures(unscanned)...)
+		}
+		return nil, NewContentError("Malware was found in the SIP", failures...)
+	}
+	if len(unscanned) > 0 && !a.cfg.AllowUnscanned {
+		return nil, NewContentError(
+			"Some SIP files could not be scanned for malware",
+			unscannedFailures(unscanned)...,
+		)
+	}
+
+	return &ScanMalwareResult{Files: p.Files, Unscanned: unscanned}, nil
+}
+
+// scanFile scans the named file, counting the bytes sent to the scanner in p.
+func (a *ScanMalware) scanFile(ctx context.Context, name string, p *fileProgress) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return a.scanner.Scan(ctx, io.TeeReader(f, p))
+}
+
+// unscannedFailures describes the files that were not scanned for the content
+// error.
+func unscannedFailures(unscanned []string) []string {
+	failures := make([]string, len(unscanned))
+	for i, rel := range unscanned {
+		failures[i] = fmt.Sprintf("%s: not scanned, larger than the ClamAV StreamMaxLength limit", rel)
+	}
+
+	return failures
+}
@@ -0,0 +1,168 @@
+package activities_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/clamav"
+)
+
+// fakeScanner reports the "Eicar-Test-Signature" signature for files that
+// contain "EICAR", exceeds the size limit for files that contain "BIG", and
+// fails for files that contain "FAIL".
+type fakeScanner struct{}
+
+func (fakeScanner) Scan(_ context.Context, r io.Reader) (string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case strings.Contains(string(b), "FAIL"):
+		return "", errors.New("clamav: connect: connection refused")
+	case strings.Contains(string(b), "BIG"):
+		return "", clamav.ErrSizeLimit
+	case strings.Contains(string(b), "EICAR"):
+		return "Eicar-Test-Signature", nil
+	default:
+		return "", nil
+	}
+}
+
+func TestScanMalware_Execute(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		cfg      activities.MalwareScanConfig
+		ops      []fs.PathOp
+		want     *activities.ScanMalwareResult
+		message  string
+		failures []string
+		wantErr  string
+	}{
+		{
+			name: "scans every file in the SIP",
+			ops: []fs.PathOp{
+				fs.WithDir("content",
+					fs.WithFile("a.pdf", "a"),
+					fs.WithDir("sub", fs.WithFile("b.pdf", "b")),
+				),
+				fs.WithDir("metadata", fs.WithFile("metadata.csv", "c")),
+			},
+			want: &activities.ScanMalwareResult{Files: 3, Unscanned: []string{}},
+		},
+		{
+			name: "rejects the files that exceed the scanner size limit",
+			ops: []fs.PathOp{
+				fs.WithDir("content",
+					fs.WithFile("a.pdf", "a"),
+					fs.WithFile("b.iso", "BIG"),
+				),
+			},
+			message: "Some SIP files could not be scanned for malware",
+			failures: []string{
+				"content/b.iso: not scanned, larger than the ClamAV StreamMaxLength limit",
+			},
+		},
+		{
+			name: "lists the files that exceed the scanner size limit if allowed",
+			cfg:  activities.MalwareScanConfig{AllowUnscanned: true},
+			ops: []fs.PathOp{
+				fs.WithDir("content",
+					fs.WithFile("a.pdf", "a"),
+					fs.WithFile("b.iso", "BIG"),
+				),
+			},
+			want: &activities.ScanMalwareResult{Files: 1, Unscanned: []string{"content/b.iso"}},
+		},
+		{
+			name: "reports the infected files",
+			ops: []fs.PathOp{
+				fs.WithDir("content",
+					fs.WithFile("a.pdf", "a"),
+					fs.WithFile("b.exe", "EICAR"),
+					fs.WithFile("c.iso", "BIG"),
+					fs.WithDir("sub", fs.WithFile("d.doc", "EICAR")),
+				),
+			},
+			message: "Malware was found in the SIP",
+			failures: []string{
+				"content/b.exe: Eicar-Test-Signature",
+				"content/sub/d.doc: Eicar-Test-Signature",
+				"content/c.iso: not scanned, larger than the ClamAV StreamMaxLength limit",
+			},
+		},
+		{
+			name: "errors when the scanner fails",
+			ops: []fs.PathOp{
+				fs.WithDir("content", fs.WithFile("a.pdf", "FAIL")),
+			},
+			wantErr: "scan malware: scan content/a.pdf: clamav: connect: connection refused",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := fs.NewDir(t, "cva-enduro-workflows-test", tc.ops...)
+
+			res, err := activities.NewScanMalware(fakeScanner{}, tc.cfg).Execute(
+				t.Context(),
+				&activities.ScanMalwareParams{Path: dir.Path()},
+			)
+			if tc.failures != nil {
+				assertContentError(t, err, tc.message, tc.failures)
+				return
+			}
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.DeepEqual(t, res, tc.want)
+		})
+	}
+}
+
+func TestMalwareScanConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	assert.NilError(t, activities.MalwareScanConfig{}.Validate())
+	assert.NilError(t, activities.MalwareScanConfig{
+		Enabled: true,
+		Address: "tcp://clamav:3310",
+	}.Validate())
+	assert.Error(t,
+		activities.MalwareScanConfig{Enabled: true}.Validate(),
+		"Preprocessing.MalwareScan.Address: missing required value",
+	)
+	assert.Error(t,
+		activities.MalwareScanConfig{
+			Enabled:       true,
+			Address:       "clamav:3310",
+			FileTimeout:   -time.Second,
+			TimeoutPerGiB: -time.Minute,
+		}.Validate(),
+		`Preprocessing.MalwareScan.Address: invalid address "clamav:3310": scheme must be "tcp" or "unix"
+Preprocessing.MalwareScan.FileTimeout: -1s is negative
+Preprocessing.MalwareScan.TimeoutPerGiB: -1m0s is negative`,
+	)
+}
+
+func TestMalwareScanConfig_Timeout(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, activities.MalwareScanConfig{}.Timeout(0), 10*time.Minute)
+	assert.Equal(t, activities.MalwareScanConfig{}.Timeout(3<<30), 16*time.Minute)
+	assert.Equal(t, activities.MalwareScanConfig{TimeoutPerGiB: time.Minute}.Timeout(1<<29), 10*time.Minute+30*time.Second)
+}
@@ -0,0 +1,433 @@
+package activities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	temporalsdk_activity "go.temporal.io/sdk/activity"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/textextract"
+)
+
+const (
+	ScanPIIName string = "scan-pii-activity"
+
+	// piiReportPath is the path of the personal information report relative
+	// to the SIP root.
+	piiReportPath string = "metadata/pii-report.json"
+
+	// piiMaxFileSize is the default maximum size of the files scanned.
+	piiMaxFileSize int64 = 100 << 20
+
+	// piiMaxTextSize is the default maximum size of the text extracted from
+	// a file.
+	piiMaxTextSize int64 = 64 << 20
+
+	// scanPIITimeoutPerGiB is the default personal information scan time
+	// allowed for each GiB of SIP files.
+	scanPIITimeoutPerGiB = time.Minute
+
+	// PIIChecksumLuhn validates the digits of a match with the Luhn
+	// algorithm, e.g. for social insurance numbers.
+	PIIChecksumLuhn string = "luhn"
+)
+
+// DefaultPIIPatterns are the personal information patterns searched when no
+// patterns are configured.
+var DefaultPIIPatterns = []PIIPattern{
+	{
+		Name:     "SIN",
+		Regexp:   `\b\d{3}[ -]?\d{3}[ -]?\d{3}\b`,
+		Checksum: PIIChecksumLuhn,
+		Exclude:  []string{"Personal health number"},
+	},
+	{Name: "Phone number", Regexp: `(?:\(\d{3}\) ?|\b\d{3}[ .-])\d{3}[ .-]\d{4}\b`},
+	{Name: "Email address", Regexp: `\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`},
+	{Name: "Personal health number", Regexp: `\b9\d{3}[ -]?\d{3}[ -]?\d{3}\b`},
+}
+
+type ScanPIIConfig struct {
+	// Enabled adds the personal information scan of the SIP content files to
+	// the preprocessing workflow.
+	Enabled bool
+
+	// Patterns are the personal information patterns searched in the text
+	// of the content files (default: DefaultPIIPatterns).
+	Patterns []PIIPattern
+
+	// MaxFileSize is the size in bytes above which content files are not
+	// scanned (default: 100 MiB).
+	MaxFileSize int64
+
+	// MaxTextSize is the size in bytes of the text, or of the decompressed
+	// PDF streams and Office Open XML parts, above which the text extraction
+	// of a content file stops and the file is not scanned (default: 64 MiB).
+	MaxTextSize int64
+
+	// TimeoutPerGiB is the scan time allowed for each GiB of SIP files,
+	// added to a 10 minute minimum timeout (default: 1m).
+	TimeoutPerGiB time.Duration
+}
+
+// PIIPattern is a named personal information pattern.
+type PIIPattern struct {
+	// Name identifies the pattern in the report, e.g. "SIN" (required).
+	Name string
+
+	// Regexp is the pattern regular expression, in the Go RE2 syntax
+	// (required).
+	Regexp string
+
+	// Checksum is the check digit algorithm the digits of a match must pass
+	// to be counted: "luhn" or empty for no check.
+	Checksum string
+
+	// Exclude lists the names of other patterns whose matches take
+	// precedence: matches overlapping them are not counted, e.g. a personal
+	// health number is not also counted as a SIN.
+	Exclude []string
+}
+
+func (c ScanPIIConfig) Validate() error {
+	var errs error
+	names := map[string]bool{}
+	for i, p := range c.Patterns {
+		name := fmt.Sprintf("Preprocessing.ScanPII.Patterns[%d]", i)
+		if p.Name == "" {
+			errs = errors.Join(errs, fmt.Errorf("%s.Name: missing required value", name))
+		} else if names[p.Name] {
+			errs = errors.Join(errs, fmt.Errorf("%s.Name: duplicate name %q", name, p.Name))
+		}
+		names[p.Name] = true
+
+		if p.Regexp == "" {
+			errs = errors.Join(errs, fmt.Errorf("%s.Regexp: missing required value", name))
+		} else if _, err := regexp.Compile(p.Regexp); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("%s.Regexp: %v", name, err))
+		}
+		if p.Checksum != "" && p.Checksum != PIIChecksumLuhn {
+			errs = errors.Join(errs, fmt.Errorf(
+				"%s.Checksum: unknown value %q, must be %q", name, p.Checksum, PIIChecksumLuhn,
+			))
+		}
+		for j, ex := range p.Exclude {
+			if ex == p.Name || !slices.ContainsFunc(c.Patterns, func(o PIIPattern) bool { return o.Name == ex }) {
+				errs = errors.Join(errs, fmt.Errorf("%s.Exclude[%d]: %q is not another pattern", name, j, ex))
+			}
+		}
+	}
+	if c.MaxFileSize < 0 {
+		errs = errors.Join(errs, fmt.Errorf("Preprocessing.ScanPII.MaxFileSize: %d is negative", c.MaxFileSize))
+	}
+	if c.MaxTextSize < 0 {
+		errs = errors.Join(errs, fmt.Errorf("Preprocessing.ScanPII.MaxTextSize: %d is negative", c.MaxTextSize))
+	}
+	if c.TimeoutPerGiB < 0 {
+		errs = errors.Join(errs, fmt.Errorf("Preprocessing.ScanPII.TimeoutPerGiB: %s is negative", c.TimeoutPerGiB))
+	}
+
+	return errs
+}
+
+// patterns returns the configured patterns, or the default patterns if none
+// are configured.
+func (c ScanPIIConfig) patterns() []PIIPattern {
+	if len(c.Patterns) == 0 {
+		return DefaultPIIPatterns
+	}
+	return c.Patterns
+}
+
+// maxFileSize returns the configured maximum file size, or the default if not
+// set.
+func (c ScanPIIConfig) maxFileSize() int64 {
+	if c.MaxFileSize == 0 {
+		return piiMaxFileSize
+	}
+	return c.MaxFileSize
+}
+
+// maxTextSize returns the configured maximum text size, or the default if not
+// set.
+func (c ScanPIIConfig) maxTextSize() int64 {
+	if c.MaxTextSize == 0 {
+		return piiMaxTextSize
+	}
+	return c.MaxTextSize
+}
+
+// Timeout returns the personal information scan timeout for a SIP of size
+// bytes: 10 minutes plus TimeoutPerGiB for each GiB of SIP files.
+func (c ScanPIIConfig) Timeout(size int64) time.Duration {
+	return sizeTimeout(size, c.TimeoutPerGiB, scanPIITimeoutPerGiB)
+}
+
+// ScanPII is an activity that searches the text of the SIP content files for
+// personal information, e.g. social insurance numbers or email addresses, so
+// SIPs that may need FOIPPA restrictions can be flagged for review.
+//
+// The text of plain text, PDF and Office Open XML files is extracted (see the
+// textextract package) and matched against the configured patterns. The
+// number of matches of each pattern is written to a
+// "metadata/pii-report.json" report in the SIP, by file. Other files, files
+// larger than the maximum size, files with more text than the maximum text
+// size, and files without text, e.g. scanned images in a PDF, are counted as
+// skipped.
+//
+// Matches are a signal for review, not a content error, so the activity only
+// fails on system errors.
+type (
+	ScanPII struct {
+		cfg ScanPIIConfig
+	}
+	ScanPIIParams struct {
+		// Path is the absolute path of the SIP directory.
+		Path string
+	}
+	ScanPIIResult struct {
+		// Path is the path of the report relative to the SIP root.
+		Path string
+
+		Report *PIIReport
+	}
+)
+
+// PIIReport summarizes the personal information found in the SIP content
+// files.
+type PIIReport struct {
+	// Files is the number of content files scanned.
+	Files int `json:"files"`
+
+	// Skipped is the number of content files that were not scanned, because
+	// their text can't be extracted, is empty or is too large, or the files
+	// are too large.
+	Skipped int `json:"skipped"`
+
+	// Hits are the total matches of each pattern with at least one match, in
+	// pattern order.
+	Hits []PIIHits `json:"hits"`
+
+	// Flagged are the content files with at least one match, in path order.
+	Flagged []PIIFile `json:"flagged"`
+}
+
+// PIIHits is the number of matches of a personal information pattern.
+type PIIHits struct {
+	Name string `json:"name"`
+
+	// Matches is the number of matches of the pattern.
+	Matches int `json:"matches"`
+
+	// Files is the number of files with a match of the pattern.
+	Files int `json:"files"`
+}
+
+// PIIFile is a content file with personal information matches.
+type PIIFile struct {
+	// Path is the file path relative to the SIP root.
+	Path string `json:"path"`
+
+	// Hits maps the pattern names to their number of matches in the file.
+	Hits map[string]int `json:"hits"`
+}
+
+// NewScanPII creates a new ScanPII.
+func NewScanPII(cfg ScanPIIConfig) *ScanPII {
+	return &ScanPII{cfg: cfg}
+}
+
+func (a *ScanPII) Execute(ctx context.Context, params *ScanPIIParams) (*ScanPIIResult, error) {
+	patterns := a.cfg.patterns()
+	res := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		re, err := regexp.Compile(p.Regexp)
+		if err != nil {
+			return nil, fmt.Errorf("scan PII: pattern %q: %w", p.Name, err)
+		}
+		res[i] = re
+	}
+
+	names, err := listFiles(params.Path, "content")
+	if err != nil {
+		return nil, fmt.Errorf("scan PII: %w", err)
+	}
+
+	report := &PIIReport{Hits: []PIIHits{}, Flagged: []PIIFile{}}
+	totals := make([]PIIHits, len(patterns))
+	for i, p := range patterns {
+		totals[i].Name = p.Name
+	}
+
+	for _, name := range names {
+		text, ok, err := a.extract(filepath.Join(params.Path, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, fmt.Errorf("scan PII: %s: %w", name, err)
+		}
+		if !ok {
+			report.Skipped++
+			continue
+		}
+		report.Files++
+
+		hits := map[string]int{}
+		for i, n := range countPIIMatches(patterns, res, text) {
+			if n > 0 {
+				hits[patterns[i].Name] = n
+				totals[i].Matches += n
+				totals[i].Files++
+			}
+		}
+		if len(hits) > 0 {
+			report.Flagged = append(report.Flagged, PIIFile{Path: name, Hits: hits})
+		}
+
+		if temporalsdk_activity.IsActivity(ctx) {
+			temporalsdk_activity.RecordHeartbeat(ctx, report.Files+report.Skipped)
+		}
+	}
+
+	for _, t := range totals {
+		if t.Matches > 0 {
+			report.Hits = append(report.Hits, t)
+		}
+	}
+
+	if err := writePIIReport(filepath.Join(params.Path, piiReportPath), report); err != nil {
+		return nil, fmt.Errorf("scan PII: %w", err)
+	}
+
+	return &ScanPIIResult{Path: piiReportPath, Report: report}, nil
+}
+
+// countPIIMatches returns the number of matches in text of each pattern, with
+// their compiled regular expressions res. Matches that fail the pattern
+// checksum, or overlap a match of an excluded pattern, are not counted.
+func countPIIMatches(patterns []PIIPattern, res []*regexp.Regexp, text string) []int {
+	matches := make(map[string][][]int, len(patterns))
+	for i, p := range patterns {
+		for _, m := range res[i].FindAllStringIndex(text, -1) {
+			if p.Checksum == PIIChecksumLuhn && !luhn(text[m[0]:m[1]]) {
+				continue
+			}
+			matches[p.Name] = append(matches[p.Name], m)
+		}
+	}
+
+	counts := make([]int, len(patterns))
+	for i, p := range patterns {
+		for _, m := range matches[p.Name] {
+			if !slices.ContainsFunc(p.Exclude, func(ex string) bool {
+				return slices.ContainsFunc(matches[ex], func(o []int) bool { return m[0] < o[1] && o[0] < m[1] })
+			}) {
+				counts[i]++
+			}
+		}
+	}
+
+	return counts
+}
+
+// luhn reports whether the digits of s pass the Luhn check. Other characters,
+// e.g. separators, are ignored.
+func luhn(s string) bool {
+	var sum, n int
+	for i := len(s) - 1; i >= 0; i-- {
+		d := int(s[i] - '0')
+		if d < 0 || d > 9 {
+			continue
+		}
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+
+	return n > 0 && sum%10 == 0
+}
+
+// extract returns the text of the named file, and false if the file is not
+// scanned because it is too large, or its text can't be extracted, is too
+// large or has only white space.
+func (a *ScanPII) extract(name string) (string, bool, error) {
+	if !textextract.Supported(name) {
+		return "", false, nil
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return "", false, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return "", false, err
+	}
+	if fi.Size() > a.cfg.maxFileSize() {
+		return "", false, nil
+	}
+
+	text, err := textextract.Extract(name, f, fi.Size(), a.cfg.maxTextSize())
+	if err != nil {
+		// A corrupt or encrypted document, or one with too much text, is
+		// skipped rather than failing the SIP.
+		return "", false, nil
+	}
+	if strings.TrimSpace(text) == "" {
+		// No text was found, e.g. in a scanned PDF or one with fonts that
+		// don't have a standard encoding, so the file was not really
+		// scanned.
+		return "", false, nil
+	}
+
+	return text, true, nil
+}
+
+func writePIIReport(path string, report *PIIReport) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("write %s: %w", piiReportPath, err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", piiReportPath, err)
+	}
+
+	return nil
+}
+
+// HitSummary describes the pattern matches, e.g. "2 SIN, 5 Email address", or
+// returns an empty string if there are none.
+func (r *PIIReport) HitSummary() string {
+	s := make([]string, len(r.Hits))
+	for i, h := range r.Hits {
+		s[i] = fmt.Sprintf("%d %s", h.Matches, h.Name)
+	}
+
+	return strings.Join(s, ", ")
+}
+
+// hasHits reports whether the report has pattern matches. It is false for a
+// nil report.
+func (r *PIIReport) hasHits() bool {
+	return r != nil && len(r.Hits) > 0
+}
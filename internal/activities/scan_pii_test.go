@@ -0,0 +1,237 @@
+package activities_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+)
+
+func TestScanPII_Execute(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name       string
+		cfg        activities.ScanPIIConfig
+		ops        []fs.PathOp
+		want       *activities.PIIReport
+		wantReport string
+	}{
+		{
+			name: "reports the personal information found in the content files",
+			cfg:  activities.ScanPIIConfig{MaxFileSize: 100, MaxTextSize: 70},
+			ops: []fs.PathOp{
+				fs.WithDir("content",
+					fs.WithFile("notes.txt", "SIN 046 454 286, call (604) 555-0199 or 604.555.0100.\n"),
+					fs.WithDir("sub",
+						fs.WithFile("contacts.CSV", "name,email\nJane,jane.doe@example.com\nJohn,john@example.ca\n"),
+						fs.WithFile("minutes.txt", "No personal information in minutes 123 456 789.\n"),
+						fs.WithFile("health.txt", "PHN 9876 543 210\n"),
+					),
+					fs.WithFile("large.txt", strings.Repeat("046-454-286 ", 10)),
+					fs.WithFile("long.txt", strings.Repeat("046-454-286\n", 7)),
+					fs.WithFile("scan.pdf", "%PDF-1.4\n%%EOF\n"),
+					fs.WithFile("photo.jpg", "\xff\xd8\xff"),
+					fs.WithFile("broken.docx", "not a zip file"),
+				),
+				fs.WithDir("metadata"),
+			},
+			want: &activities.PIIReport{
+				Files:   4,
+				Skipped: 5,
+				Hits: []activities.PIIHits{
+					{Name: "SIN", Matches: 1, Files: 1},
+					{Name: "Phone number", Matches: 2, Files: 1},
+					{Name: "Email address", Matches: 2, Files: 1},
+					{Name: "Personal health number", Matches: 1, Files: 1},
+				},
+				Flagged: []activities.PIIFile{
+					{Path: "content/notes.txt", Hits: map[string]int{"SIN": 1, "Phone number": 2}},
+					{Path: "content/sub/contacts.CSV", Hits: map[string]int{"Email address": 2}},
+					{Path: "content/sub/health.txt", Hits: map[string]int{"Personal health number": 1}},
+				},
+			},
+			wantReport: `{
+  "files": 4,
+  "skipped": 5,
+  "hits": [
+    {
+      "name": "SIN",
+      "matches": 1,
+      "files": 1
+    },
+    {
+      "name": "Phone number",
+      "matches": 2,
+      "files": 1
+    },
+    {
+      "name": "Email address",
+      "matches": 2,
+      "files": 1
+    },
+    {
+      "name": "Personal health number",
+      "matches": 1,
+      "files": 1
+    }
+  ],
+  "flagged": [
+    {
+      "path": "content/notes.txt",
+      "hits": {
+        "Phone number": 2,
+        "SIN": 1
+      }
+    },
+    {
+      "path": "content/sub/contacts.CSV",
+      "hits": {
+        "Email address": 2
+      }
+    },
+    {
+      "path": "content/sub/health.txt",
+      "hits": {
+        "Personal health number": 1
+      }
+    }
+  ]
+}
+`,
+		},
+		{
+			name: "searches the configured patterns",
+			cfg: activities.ScanPIIConfig{
+				Patterns: []activities.PIIPattern{
+					{Name: "Client file", Regexp: `\bCF-\d{6}\b`},
+				},
+			},
+			ops: []fs.PathOp{
+				fs.WithDir("content",
+					fs.WithFile("notes.txt", "See CF-123456 and CF-654321, SIN 046 454 286.\n"),
+				),
+				fs.WithDir("metadata"),
+			},
+			want: &activities.PIIReport{
+				Files:   1,
+				Hits:    []activities.PIIHits{{Name: "Client file", Matches: 2, Files: 1}},
+				Flagged: []activities.PIIFile{{Path: "content/notes.txt", Hits: map[string]int{"Client file": 2}}},
+			},
+		},
+		{
+			name: "doesn't count the matches of excluded patterns",
+			cfg: activities.ScanPIIConfig{
+				Patterns: []activities.PIIPattern{
+					{Name: "Number", Regexp: `\d{9}`, Checksum: activities.PIIChecksumLuhn, Exclude: []string{"Account"}},
+					{Name: "Account", Regexp: `\bACCT-\d{9}\b`},
+				},
+			},
+			ops: []fs.PathOp{
+				fs.WithDir("content",
+					fs.WithFile("notes.txt", "ACCT-046454286, 046454286 and 123456789.\n"),
+				),
+				fs.WithDir("metadata"),
+			},
+			want: &activities.PIIReport{
+				Files: 1,
+				Hits: []activities.PIIHits{
+					{Name: "Number", Matches: 1, Files: 1},
+					{Name: "Account", Matches: 1, Files: 1},
+				},
+				Flagged: []activities.PIIFile{
+					{Path: "content/notes.txt", Hits: map[string]int{"Number": 1, "Account": 1}},
+				},
+			},
+		},
+		{
+			name: "reports SIPs without personal information",
+			ops: []fs.PathOp{
+				fs.WithDir("content", fs.WithFile("notes.txt", "Nothing to see here.\n")),
+				fs.WithDir("metadata"),
+			},
+			want: &activities.PIIReport{
+				Files:   1,
+				Hits:    []activities.PIIHits{},
+				Flagged: []activities.PIIFile{},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := fs.NewDir(t, "cva-enduro-workflows-test", tc.ops...)
+
+			res, err := activities.NewScanPII(tc.cfg).Execute(
+				t.Context(),
+				&activities.ScanPIIParams{Path: dir.Path()},
+			)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, res, &activities.ScanPIIResult{
+				Path:   "metadata/pii-report.json",
+				Report: tc.want,
+			})
+
+			if tc.wantReport != "" {
+				report, err := os.ReadFile(dir.Join("metadata", "pii-report.json"))
+				assert.NilError(t, err)
+				assert.Equal(t, string(report), tc.wantReport)
+			}
+		})
+	}
+}
+
+func TestScanPIIConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	assert.NilError(t, activities.ScanPIIConfig{}.Validate())
+	assert.Error(t,
+		activities.ScanPIIConfig{
+			Patterns: []activities.PIIPattern{
+				{Name: "SIN", Regexp: `\d{9}`},
+				{Name: "SIN", Regexp: `\d{3}(`, Checksum: "mod11", Exclude: []string{"SIN", "PHN"}},
+				{},
+			},
+			MaxFileSize:   -1,
+			MaxTextSize:   -1,
+			TimeoutPerGiB: -time.Minute,
+		}.Validate(),
+		"Preprocessing.ScanPII.Patterns[1].Name: duplicate name \"SIN\"\n"+
+			"Preprocessing.ScanPII.Patterns[1].Regexp: error parsing regexp: missing closing ): `\\d{3}(`\n"+
+			"Preprocessing.ScanPII.Patterns[1].Checksum: unknown value \"mod11\", must be \"luhn\"\n"+
+			"Preprocessing.ScanPII.Patterns[1].Exclude[0]: \"SIN\" is not another pattern\n"+
+			"Preprocessing.ScanPII.Patterns[1].Exclude[1]: \"PHN\" is not another pattern\n"+
+			"Preprocessing.ScanPII.Patterns[2].Name: missing required value\n"+
+			"Preprocessing.ScanPII.Patterns[2].Regexp: missing required value\n"+
+			"Preprocessing.ScanPII.MaxFileSize: -1 is negative\n"+
+			"Preprocessing.ScanPII.MaxTextSize: -1 is negative\n"+
+			"Preprocessing.ScanPII.TimeoutPerGiB: -1m0s is negative",
+	)
+}
+
+func TestScanPIIConfig_Timeout(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, activities.ScanPIIConfig{}.Timeout(4<<30), 14*time.Minute)
+	assert.Equal(t, activities.ScanPIIConfig{TimeoutPerGiB: 3 * time.Minute}.Timeout(2<<30), 16*time.Minute)
+}
+
+func TestPIIReport_HitSummary(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, (&activities.PIIReport{}).HitSummary(), "")
+	assert.Equal(t,
+		(&activities.PIIReport{
+			Hits: []activities.PIIHits{
+				{Name: "SIN", Matches: 2, Files: 1},
+				{Name: "Email address", Matches: 5, Files: 3},
+			},
+		}).HitSummary(),
+		"2 SIN, 5 Email address",
+	)
+}
@@ -0,0 +1,18 @@
+package activities
+
+import "time"
+
+// minSizeTimeout is the minimum timeout of the activities that process every
+// SIP file, whatever the SIP size.
+const minSizeTimeout = 10 * time.Minute
+
+// sizeTimeout returns the timeout of an activity processing a SIP of size
+// bytes: 10 minutes plus perGiB for each GiB of SIP files. The defaultPerGiB
+// value is used if perGiB is 0.
+func sizeTimeout(size int64, perGiB, defaultPerGiB time.Duration) time.Duration {
+	if perGiB == 0 {
+		perGiB = defaultPerGiB
+	}
+
+	return minSizeTimeout + time.Duration(float64(size)/(1<<30)*float64(perGiB))
+}
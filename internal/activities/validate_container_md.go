@@ -0,0 +1,80 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/types"
+)
+
+const ValidateContainerMDName string = "validate-container-md-activity"
+
+// ValidateContainerMD is an activity that validates the ContainerMetadata.xml
+// file of a SIP against the VanDocs metadata rules (see
+// types.ValidateContainerMD). If the file is missing or not valid a content
+// error listing every problem found is returned.
+type (
+	ValidateContainerMD struct {
+		cfg ValidateContainerMDConfig
+	}
+	ValidateContainerMDConfig struct {
+		// RequiredFields lists the ContainerMetadata.xml fields that must be
+		// present and not empty (default: types.DefaultRequiredFields).
+		RequiredFields []string
+	}
+	ValidateContainerMDParams struct {
+		// Path is the absolute path of the SIP directory.
+		Path string
+	}
+	ValidateContainerMDResult struct{}
+)
+
+func (c ValidateContainerMDConfig) Validate() error {
+	var errs error
+	for _, name := range c.RequiredFields {
+		if !types.IsContainerMDField(name) {
+			errs = errors.Join(errs, fmt.Errorf(
+				"Preprocessing.ValidateContainerMD.RequiredFields: unknown field %q",
+				name,
+			))
+		}
+	}
+
+	return errs
+}
+
+// NewValidateContainerMD creates a new ValidateContainerMD.
+func NewValidateContainerMD(cfg ValidateContainerMDConfig) *ValidateContainerMD {
+	return &ValidateContainerMD{cfg: cfg}
+}
+
+func (a *ValidateContainerMD) Execute(
+	ctx context.Context,
+	params *ValidateContainerMDParams,
+) (*ValidateContainerMDResult, error) {
+	f, err := os.Open(filepath.Join(params.Path, containerMDPath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, NewContentError(
+			fmt.Sprintf("The %s file is missing", containerMDPath),
+			fmt.Sprintf("Missing required file: %q", containerMDPath),
+		)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("validate container metadata: %w", err)
+	}
+	defer f.Close()
+
+	failures, err := types.ValidateContainerMD(f, a.cfg.RequiredFields)
+	if err != nil {
+		return nil, fmt.Errorf("validate container metadata: %w", err)
+	}
+	if len(failures) > 0 {
+		return nil, NewContentError(fmt.Sprintf("The %s file is not valid", containerMDPath), failures...)
+	}
+
+	return &ValidateContainerMDResult{}, nil
+}
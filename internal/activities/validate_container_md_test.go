@@ -0,0 +1,102 @@
+package activities_test
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/types"
+)
+
+func TestValidateContainerMD_Execute(t *testing.T) {
+	t.Parallel()
+
+	withContainerMD := func(xml string) fs.PathOp {
+		return fs.WithDir("metadata",
+			fs.WithDir("submissionDocumentation",
+				fs.WithFile("ContainerMetadata.xml", xml),
+			),
+		)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		cfg      activities.ValidateContainerMDConfig
+		ops      []fs.PathOp
+		wantMsg  string
+		wantErrs []string
+	}{
+		{
+			name: "returns no failures for a valid ContainerMetadata.xml file",
+			cfg:  activities.ValidateContainerMDConfig{RequiredFields: types.DefaultRequiredFields},
+			ops: []fs.PathOp{
+				withContainerMD(sipContainerMetadataXML(containerMDXMLParams{
+					recordNumber:      "01-5000-12/2009-01",
+					titleFreeTextPart: "Test Title 1",
+					dateRegistered:    "2009-01-15T00:00:00Z",
+				})),
+			},
+		},
+		{
+			name: "returns a content error for an invalid ContainerMetadata.xml file",
+			cfg:  activities.ValidateContainerMDConfig{RequiredFields: types.DefaultRequiredFields},
+			ops: []fs.PathOp{
+				withContainerMD(sipContainerMetadataXML(containerMDXMLParams{
+					titleFreeTextPart: "Test Title 1",
+					dateClosed:        "2012-06-31",
+				})),
+			},
+			wantMsg: "The metadata/submissionDocumentation/ContainerMetadata.xml file is not valid",
+			wantErrs: []string{
+				`Missing required field: "RecordNumber"`,
+				`Missing required field: "DateRegistered"`,
+				`Invalid value for field "DateClosed": "2012-06-31" is not a valid date`,
+			},
+		},
+		{
+			name:    "returns a content error when the ContainerMetadata.xml file is missing",
+			wantMsg: "The metadata/submissionDocumentation/ContainerMetadata.xml file is missing",
+			wantErrs: []string{
+				`Missing required file: "metadata/submissionDocumentation/ContainerMetadata.xml"`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := fs.NewDir(t, "cva-enduro-workflows-test", tc.ops...)
+
+			_, err := activities.NewValidateContainerMD(tc.cfg).Execute(
+				t.Context(),
+				&activities.ValidateContainerMDParams{Path: dir.Path()},
+			)
+			if tc.wantMsg != "" {
+				assertContentError(t, err, tc.wantMsg, tc.wantErrs)
+				return
+			}
+
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestValidateContainerMDConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("accepts known fields", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := activities.ValidateContainerMDConfig{RequiredFields: types.DefaultRequiredFields}
+		assert.NilError(t, cfg.Validate())
+	})
+
+	t.Run("rejects unknown fields", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := activities.ValidateContainerMDConfig{RequiredFields: []string{"Title", "Colour", "Size"}}
+		assert.Error(t, cfg.Validate(), `Preprocessing.ValidateContainerMD.RequiredFields: unknown field "Colour"
+Preprocessing.ValidateContainerMD.RequiredFields: unknown field "Size"`)
+	})
+}
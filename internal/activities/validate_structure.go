@@ -0,0 +1,191 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	ValidateStructureName string = "validate-structure-activity"
+
+	// containerMDPath is the path of the ContainerMetadata.xml file relative
+	// to the SIP root.
+	containerMDPath string = "metadata/submissionDocumentation/ContainerMetadata.xml"
+)
+
+// ValidateStructure is an activity that checks that a SIP matches the expected
+// VanDocs export layout:
+//
+//	<SIP>/
+//	├── content/
+//	│   └── ... (one or more files)
+//	└── metadata/
+//	    └── submissionDocumentation/
+//	        └── ContainerMetadata.xml
+//
+// The reports written to the SIP metadata directory by the preprocessing
+// activities can't be supplied with the SIP, so a submitted report is never
+// mistaken for the result of a scan.
+//
+// If the SIP structure is not valid a content error listing every violation
+// found is returned, so the SIP can be rejected with a complete list of the
+// problems. Otherwise the SIP size is returned, so later activities can be
+// given timeouts that suit it.
+type (
+	ValidateStructure       struct{}
+	ValidateStructureParams struct {
+		// Path is the absolute path of the SIP directory.
+		Path string
+	}
+	ValidateStructureResult struct {
+		// Size is the total size in bytes of the SIP files.
+		Size int64
+	}
+)
+
+// NewValidateStructure creates a new ValidateStructure.
+func NewValidateStructure() *ValidateStructure {
+	return &ValidateStructure{}
+}
+
+func (a *ValidateStructure) Execute(
+	ctx context.Context,
+	params *ValidateStructureParams,
+) (*ValidateStructureResult, error) {
+	entries, err := os.ReadDir(params.Path)
+	if err != nil {
+		return nil, fmt.Errorf("validate structure: read SIP directory: %w", err)
+	}
+
+	var failures []string
+
+	// Only the "content" and "metadata" directories are allowed at the top
+	// level of the SIP.
+	for _, e := range entries {
+		switch {
+		case e.Name() == "content" && e.IsDir():
+		case e.Name() == "metadata" && e.IsDir():
+		case e.IsDir():
+			failures = append(failures, fmt.Sprintf("Unexpected top-level directory: %q", e.Name()))
+		default:
+			failures = append(failures, fmt.Sprintf("Unexpected top-level file: %q", e.Name()))
+		}
+	}
+
+	f, err := checkContentDir(filepath.Join(params.Path, "content"))
+	if err != nil {
+		return nil, fmt.Errorf("validate structure: %w", err)
+	}
+	failures = append(failures, f...)
+
+	f, err = checkRequiredFile(params.Path, containerMDPath)
+	if err != nil {
+		return nil, fmt.Errorf("validate structure: %w", err)
+	}
+	failures = append(failures, f...)
+
+	for _, rel := range []string{formatReportPath, piiReportPath} {
+		_, err := os.Lstat(filepath.Join(params.Path, filepath.FromSlash(rel)))
+		if err == nil {
+			failures = append(failures, fmt.Sprintf("Unexpected generated file: %q", rel))
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("validate structure: stat %s: %w", rel, err)
+		}
+	}
+
+	if len(failures) > 0 {
+		return nil, NewContentError("The SIP structure is not valid", failures...)
+	}
+
+	size, err := dirSize(params.Path)
+	if err != nil {
+		return nil, fmt.Errorf("validate structure: %w", err)
+	}
+
+	return &ValidateStructureResult{Size: size}, nil
+}
+
+// dirSize returns the total size in bytes of the regular files in the dir
+// tree.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += fi.Size()
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("measure SIP size: %w", err)
+	}
+
+	return size, nil
+}
+
+// checkContentDir checks that the content directory exists and contains at
+// least one file.
+func checkContentDir(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []string{`Missing required directory: "content"`}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stat content directory: %w", err)
+	}
+	if !fi.IsDir() {
+		// The unexpected file has already been reported by the top-level
+		// check.
+		return []string{`Missing required directory: "content"`}, nil
+	}
+
+	var hasFiles bool
+	err = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			hasFiles = true
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk content directory: %w", err)
+	}
+	if !hasFiles {
+		return []string{`Content directory is empty: "content"`}, nil
+	}
+
+	return nil, nil
+}
+
+// checkRequiredFile checks that the file at relPath, relative to the SIP
+// root, exists and is a regular file.
+func checkRequiredFile(root, relPath string) ([]string, error) {
+	fi, err := os.Stat(filepath.Join(root, relPath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return []string{fmt.Sprintf("Missing required file: %q", relPath)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stat %s: %w", relPath, err)
+	}
+	if !fi.Mode().IsRegular() {
+		return []string{fmt.Sprintf("Not a regular file: %q", relPath)}, nil
+	}
+
+	return nil, nil
+}
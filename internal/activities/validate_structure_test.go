@@ -0,0 +1,139 @@
+package activities_test
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+)
+
+func TestValidateStructure_Execute(t *testing.T) {
+	t.Parallel()
+
+	containerMD := fs.WithDir("metadata",
+		fs.WithDir("submissionDocumentation",
+			fs.WithFile("ContainerMetadata.xml", "<ContainerMetadata/>"),
+		),
+	)
+
+	for _, tc := range []struct {
+		name     string
+		ops      []fs.PathOp
+		want     []string
+		wantSize int64
+	}{
+		{
+			name: "returns no failures for a valid SIP",
+			ops: []fs.PathOp{
+				fs.WithDir("content", fs.WithFile("document.pdf", "data")),
+				containerMD,
+			},
+			wantSize: 24,
+		},
+		{
+			name: "returns no failures when content files are in subdirectories",
+			ops: []fs.PathOp{
+				fs.WithDir("content", fs.WithDir("sub", fs.WithFile("document.pdf", "data"))),
+				containerMD,
+			},
+			wantSize: 24,
+		},
+		{
+			name: "reports a missing content directory",
+			ops:  []fs.PathOp{containerMD},
+			want: []string{`Missing required directory: "content"`},
+		},
+		{
+			name: "reports an empty content directory",
+			ops: []fs.PathOp{
+				fs.WithDir("content", fs.WithDir("empty")),
+				containerMD,
+			},
+			want: []string{`Content directory is empty: "content"`},
+		},
+		{
+			name: "reports a missing ContainerMetadata.xml file",
+			ops: []fs.PathOp{
+				fs.WithDir("content", fs.WithFile("document.pdf", "data")),
+				fs.WithDir("metadata"),
+			},
+			want: []string{
+				`Missing required file: "metadata/submissionDocumentation/ContainerMetadata.xml"`,
+			},
+		},
+		{
+			name: "reports a supplied personal information report",
+			ops: []fs.PathOp{
+				fs.WithDir("content", fs.WithFile("document.pdf", "data")),
+				fs.WithDir("metadata",
+					fs.WithFile("pii-report.json", `{"files":1,"hits":[]}`),
+					fs.WithDir("submissionDocumentation",
+						fs.WithFile("ContainerMetadata.xml", "<xml/>"),
+					),
+				),
+			},
+			want: []string{
+				`Unexpected generated file: "metadata/pii-report.json"`,
+			},
+		},
+		{
+			name: "reports a ContainerMetadata.xml directory",
+			ops: []fs.PathOp{
+				fs.WithDir("content", fs.WithFile("document.pdf", "data")),
+				fs.WithDir("metadata",
+					fs.WithDir("submissionDocumentation",
+						fs.WithDir("ContainerMetadata.xml"),
+					),
+				),
+			},
+			want: []string{
+				`Not a regular file: "metadata/submissionDocumentation/ContainerMetadata.xml"`,
+			},
+		},
+		{
+			name: "reports every violation",
+			ops: []fs.PathOp{
+				fs.WithFile("content", "not a directory"),
+				fs.WithFile("stray.txt", "data"),
+				fs.WithDir("logs"),
+			},
+			want: []string{
+				`Unexpected top-level file: "content"`,
+				`Unexpected top-level directory: "logs"`,
+				`Unexpected top-level file: "stray.txt"`,
+				`Missing required directory: "content"`,
+				`Missing required file: "metadata/submissionDocumentation/ContainerMetadata.xml"`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := fs.NewDir(t, "cva-enduro-workflows-test", tc.ops...)
+
+			res, err := activities.NewValidateStructure().Execute(
+				t.Context(),
+				&activities.ValidateStructureParams{Path: dir.Path()},
+			)
+			if tc.want != nil {
+				assertContentError(t, err, "The SIP structure is not valid", tc.want)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Equal(t, res.Size, tc.wantSize)
+		})
+	}
+
+	t.Run("errors when the SIP directory does not exist", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := activities.NewValidateStructure().Execute(
+			t.Context(),
+			&activities.ValidateStructureParams{Path: "/missing/sip"},
+		)
+		assert.ErrorContains(t, err, "validate structure: read SIP directory: open /missing/sip")
+	})
+}
@@ -0,0 +1,267 @@
+package activities
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const VerifyChecksumsName string = "verify-checksums-activity"
+
+// verifyChecksumsTimeoutPerGiB is the default checksum verification time
+// allowed for each GiB of SIP files.
+const verifyChecksumsTimeoutPerGiB = time.Minute
+
+type VerifyChecksumsConfig struct {
+	// Enabled adds the verification of the checksum manifests supplied with
+	// the SIP to the preprocessing workflow.
+	Enabled bool
+
+	// Required rejects SIPs without a checksum manifest.
+	Required bool
+
+	// TimeoutPerGiB is the verification time allowed for each GiB of SIP
+	// files, added to a 10 minute minimum timeout (default: 1m).
+	TimeoutPerGiB time.Duration
+}
+
+func (c VerifyChecksumsConfig) Validate() error {
+	if c.TimeoutPerGiB < 0 {
+		return fmt.Errorf("Preprocessing.VerifyChecksums.TimeoutPerGiB: %s is negative", c.TimeoutPerGiB)
+	}
+
+	return nil
+}
+
+// Timeout returns the checksum verification timeout for a SIP of size bytes:
+// 10 minutes plus TimeoutPerGiB for each GiB of SIP files.
+func (c VerifyChecksumsConfig) Timeout(size int64) time.Duration {
+	return sizeTimeout(size, c.TimeoutPerGiB, verifyChecksumsTimeoutPerGiB)
+}
+
+// VerifyChecksums is an activity that verifies the SIP content files against
+// the checksum manifests supplied by VanDocs, so corruption in transit to the
+// shared path is detected before the SIP is bagged.
+//
+// The manifests are "metadata/checksum.<algorithm>" files, where algorithm is
+// "md5", "sha1", "sha256" or "sha512", with a "<checksum>  <path>" line for
+// each content file, as written by md5sum or sha256sum. Paths are relative to
+// the SIP root, e.g. "content/report.pdf" or "./content/report.pdf".
+//
+// If a checksum doesn't match, or a file listed in a manifest is missing, or a
+// content file is not listed in every manifest, a content error listing the
+// files is returned. The activity records a heartbeat with the number of files
+// and bytes verified as it goes.
+type (
+	VerifyChecksums struct {
+		cfg VerifyChecksumsConfig
+	}
+	VerifyChecksumsParams struct {
+		// Path is the absolute path of the SIP directory.
+		Path string
+	}
+	VerifyChecksumsResult struct {
+		// Manifests are the paths of the verified manifests relative to the
+		// SIP root, empty if the SIP has no manifest.
+		Manifests []string
+
+		// Files is the number of checksums verified.
+		Files int
+	}
+)
+
+// checksumManifest is a parsed checksum manifest.
+type checksumManifest struct {
+	// path is the manifest path relative to the SIP root.
+	path string
+
+	algorithm string
+
+	// checksums maps the listed file paths to their checksum.
+	checksums map[string]string
+}
+
+// NewVerifyChecksums creates a new VerifyChecksums.
+func NewVerifyChecksums(cfg VerifyChecksumsConfig) *VerifyChecksums {
+	return &VerifyChecksums{cfg: cfg}
+}
+
+func (a *VerifyChecksums) Execute(
+	ctx context.Context,
+	params *VerifyChecksumsParams,
+) (*VerifyChecksumsResult, error) {
+	manifests, failures, err := readChecksumManifests(params.Path)
+	if err != nil {
+		return nil, fmt.Errorf("verify checksums: %w", err)
+	}
+	if len(failures) > 0 {
+		return nil, NewContentError("The checksum manifest is not valid", failures...)
+	}
+
+	res := &VerifyChecksumsResult{Manifests: []string{}}
+	if len(manifests) == 0 {
+		if a.cfg.Required {
+			return nil, NewContentError(
+				"The SIP has no checksum manifest",
+				"Missing file: \"metadata/checksum.<md5|sha1|sha256|sha512>\"",
+			)
+		}
+		return res, nil
+	}
+
+	contentFiles, err := listFiles(params.Path, "content")
+	if err != nil {
+		return nil, fmt.Errorf("verify checksums: %w", err)
+	}
+	onDisk := make(map[string]bool, len(contentFiles))
+	for _, name := range contentFiles {
+		onDisk[name] = true
+	}
+
+	p := &fileProgress{ctx: ctx}
+	for _, m := range manifests {
+		res.Manifests = append(res.Manifests, m.path)
+
+		for _, name := range slices.Sorted(maps.Keys(m.checksums)) {
+			if !onDisk[name] {
+				failures = append(failures, fmt.Sprintf("Missing file: %q (%s)", name, m.path))
+				continue
+			}
+
+			sum, err := hashFile(filepath.Join(params.Path, filepath.FromSlash(name)), checksumAlgorithms[m.algorithm], p)
+			if err != nil {
+				return nil, fmt.Errorf("verify checksums: hash %s: %w", name, err)
+			}
+			p.fileDone()
+			res.Files++
+
+			if !strings.EqualFold(sum, m.checksums[name]) {
+				failures = append(failures, fmt.Sprintf("Checksum mismatch: %q (%s)", name, m.path))
+			}
+		}
+
+		for _, name := range contentFiles {
+			if _, ok := m.checksums[name]; !ok {
+				failures = append(failures, fmt.Sprintf("Unlisted file: %q (%s)", name, m.path))
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		return nil, NewContentError("The SIP files don't match the checksum manifest", failures...)
+	}
+
+	return res, nil
+}
+
+// readChecksumManifests parses the checksum manifests in the SIP metadata
+// directory, in algorithm order. It returns the manifest syntax problems as
+// failures.
+func readChecksumManifests(sipPath string) ([]checksumManifest, []string, error) {
+	var (
+		manifests []checksumManifest
+		failures  []string
+	)
+	for _, alg := range []string{ChecksumMD5, ChecksumSHA1, ChecksumSHA256, ChecksumSHA512} {
+		rel := "metadata/checksum." + alg
+
+		f, err := os.Open(filepath.Join(sipPath, filepath.FromSlash(rel)))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		m, invalid, err := parseChecksumManifest(f, rel, alg)
+		f.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("read %s: %w", rel, err)
+		}
+		manifests = append(manifests, m)
+		failures = append(failures, invalid...)
+	}
+
+	return manifests, failures, nil
+}
+
+// parseChecksumManifest parses the rel checksum manifest read from r, and
+// returns the invalid lines as failures.
+func parseChecksumManifest(r io.Reader, rel, alg string) (checksumManifest, []string, error) {
+	m := checksumManifest{path: rel, algorithm: alg, checksums: map[string]string{}}
+	size := checksumAlgorithms[alg]().Size() * 2
+
+	var failures []string
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+
+		sum, name, ok := strings.Cut(line, " ")
+		name = strings.TrimPrefix(strings.TrimLeft(name, " "), "*")
+		if _, err := hex.DecodeString(sum); !ok || len(sum) != size || err != nil || name == "" {
+			failures = append(failures, fmt.Sprintf("Invalid line %d in %s", n, rel))
+			continue
+		}
+
+		// Accept the "./content/..." paths written by running md5sum in the
+		// SIP root, and reject the paths leaving the content directory.
+		clean := path.Clean(name)
+		if !strings.HasPrefix(clean, "content/") {
+			failures = append(failures, fmt.Sprintf("Invalid path %q in %s", name, rel))
+			continue
+		}
+		name = clean
+
+		if _, ok := m.checksums[name]; ok {
+			failures = append(failures, fmt.Sprintf("Duplicate path %q in %s", name, rel))
+			continue
+		}
+		m.checksums[name] = sum
+	}
+	if err := s.Err(); err != nil {
+		return checksumManifest{}, nil, err
+	}
+
+	return m, failures, nil
+}
+
+// listFiles returns the slash separated paths, relative to root, of the
+// regular files in the dir tree under root, in lexical order.
+func listFiles(root, dir string) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(filepath.Join(root, dir), func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(rel))
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
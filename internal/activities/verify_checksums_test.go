@@ -0,0 +1,150 @@
+package activities_test
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+)
+
+const (
+	// md5 and sha256 checksums of "a" and "b".
+	md5A    = "0cc175b9c0f1b6a831c399e269772661"
+	md5B    = "92eb5ffee6ae2fec3ad71c777531578f"
+	sha256A = "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"
+	sha256B = "3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d"
+)
+
+func TestVerifyChecksums_Execute(t *testing.T) {
+	t.Parallel()
+
+	content := fs.WithDir("content",
+		fs.WithFile("a.pdf", "a"),
+		fs.WithDir("sub", fs.WithFile("b.pdf", "b")),
+	)
+
+	for _, tc := range []struct {
+		name     string
+		cfg      activities.VerifyChecksumsConfig
+		ops      []fs.PathOp
+		want     *activities.VerifyChecksumsResult
+		message  string
+		failures []string
+	}{
+		{
+			name: "verifies the supplied manifests",
+			ops: []fs.PathOp{
+				content,
+				fs.WithDir("metadata",
+					fs.WithFile("checksum.md5", md5A+"  content/a.pdf\n"+md5B+"  content/sub/b.pdf\n"),
+					fs.WithFile("checksum.sha256", "\n"+sha256A+" *content/a.pdf\n"+
+						"3E23E8160039594A33894F6564E1B1348BBD7A0088D42C4ACB73EEAED59C009D  content/sub/b.pdf\n"),
+				),
+			},
+			want: &activities.VerifyChecksumsResult{
+				Manifests: []string{"metadata/checksum.md5", "metadata/checksum.sha256"},
+				Files:     4,
+			},
+		},
+		{
+			name: "verifies manifests with paths relative to the current directory",
+			ops: []fs.PathOp{
+				content,
+				fs.WithDir("metadata",
+					fs.WithFile("checksum.md5", md5A+"  ./content/a.pdf\n"+md5B+"  ./content/sub/b.pdf\n"),
+				),
+			},
+			want: &activities.VerifyChecksumsResult{
+				Manifests: []string{"metadata/checksum.md5"},
+				Files:     2,
+			},
+		},
+		{
+			name: "skips SIPs without a manifest",
+			ops:  []fs.PathOp{content, fs.WithDir("metadata")},
+			want: &activities.VerifyChecksumsResult{Manifests: []string{}},
+		},
+		{
+			name:    "rejects SIPs without a manifest if required",
+			cfg:     activities.VerifyChecksumsConfig{Required: true},
+			ops:     []fs.PathOp{content, fs.WithDir("metadata")},
+			message: "The SIP has no checksum manifest",
+			failures: []string{
+				`Missing file: "metadata/checksum.<md5|sha1|sha256|sha512>"`,
+			},
+		},
+		{
+			name: "reports mismatched, missing and unlisted files",
+			ops: []fs.PathOp{
+				content,
+				fs.WithDir("metadata",
+					fs.WithFile("checksum.md5", md5B+"  content/a.pdf\n"+md5A+"  content/c.pdf\n"),
+				),
+			},
+			message: "The SIP files don't match the checksum manifest",
+			failures: []string{
+				`Checksum mismatch: "content/a.pdf" (metadata/checksum.md5)`,
+				`Missing file: "content/c.pdf" (metadata/checksum.md5)`,
+				`Unlisted file: "content/sub/b.pdf" (metadata/checksum.md5)`,
+			},
+		},
+		{
+			name: "rejects invalid manifests",
+			ops: []fs.PathOp{
+				content,
+				fs.WithDir("metadata",
+					fs.WithFile("checksum.sha1", "not a checksum\n"),
+					fs.WithFile("checksum.md5", md5A+"  content/a.pdf\n"+
+						md5A+"  content/a.pdf\n"+
+						md5B+"  content/../../etc/passwd\n"+
+						md5B+"  metadata/checksum.sha1\n"),
+				),
+			},
+			message: "The checksum manifest is not valid",
+			failures: []string{
+				`Duplicate path "content/a.pdf" in metadata/checksum.md5`,
+				`Invalid path "content/../../etc/passwd" in metadata/checksum.md5`,
+				`Invalid path "metadata/checksum.sha1" in metadata/checksum.md5`,
+				"Invalid line 1 in metadata/checksum.sha1",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := fs.NewDir(t, "cva-enduro-workflows-test", tc.ops...)
+
+			res, err := activities.NewVerifyChecksums(tc.cfg).Execute(
+				t.Context(),
+				&activities.VerifyChecksumsParams{Path: dir.Path()},
+			)
+			if tc.failures != nil {
+				assertContentError(t, err, tc.message, tc.failures)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.DeepEqual(t, res, tc.want)
+		})
+	}
+}
+
+func TestVerifyChecksumsConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	assert.NilError(t, activities.VerifyChecksumsConfig{TimeoutPerGiB: time.Minute}.Validate())
+	assert.Error(t,
+		activities.VerifyChecksumsConfig{TimeoutPerGiB: -time.Minute}.Validate(),
+		"Preprocessing.VerifyChecksums.TimeoutPerGiB: -1m0s is negative",
+	)
+}
+
+func TestVerifyChecksumsConfig_Timeout(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, activities.VerifyChecksumsConfig{}.Timeout(4<<30), 14*time.Minute)
+	assert.Equal(t, activities.VerifyChecksumsConfig{TimeoutPerGiB: 3 * time.Minute}.Timeout(2<<30), 16*time.Minute)
+}
@@ -0,0 +1,188 @@
+// Package clamav implements a client for the ClamAV daemon (clamd) that scans
+// file contents with the INSTREAM command.
+package clamav
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// chunkSize is the maximum size of the INSTREAM chunks sent to clamd.
+const chunkSize = 64 << 10
+
+// sizeLimitReply is the prefix of the clamd reply to streams larger than its
+// StreamMaxLength setting.
+const sizeLimitReply = "INSTREAM size limit exceeded"
+
+// ErrSizeLimit is returned when a stream is larger than the clamd
+// StreamMaxLength setting, so it was not scanned.
+var ErrSizeLimit = errors.New("clamav: " + sizeLimitReply)
+
+// Client is a clamd client. Each scan opens a new connection to the daemon.
+type Client struct {
+	network string
+	address string
+
+	// timeout is the maximum duration of a scan, 0 for no timeout.
+	timeout time.Duration
+}
+
+// NewClient returns a client for the clamd daemon at addr, a
+// "tcp://host:port" or "unix:///path/to/clamd.sock" URL. Scans that take
+// longer than timeout fail, unless timeout is 0.
+func NewClient(addr string, timeout time.Duration) (*Client, error) {
+	network, address, err := ParseAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		network: network,
+		address: address,
+		timeout: timeout,
+	}, nil
+}
+
+// ParseAddress returns the network and address of a "tcp://host:port" or
+// "unix:///path/to/clamd.sock" clamd address.
+func ParseAddress(addr string) (network, address string, err error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid address %q: %v", addr, err)
+	}
+
+	switch u.Scheme {
+	case "tcp":
+		if u.Host == "" {
+			return "", "", fmt.Errorf("invalid address %q: missing host", addr)
+		}
+		return "tcp", u.Host, nil
+	case "unix":
+		if u.Path == "" {
+			return "", "", fmt.Errorf("invalid address %q: missing socket path", addr)
+		}
+		return "unix", u.Path, nil
+	default:
+		return "", "", fmt.Errorf("invalid address %q: scheme must be \"tcp\" or \"unix\"", addr)
+	}
+}
+
+// Scan sends the contents of r to clamd and returns the name of the malware
+// signature found, or an empty string if no malware is found.
+func (c *Client) Scan(ctx context.Context, r io.Reader) (string, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return "", fmt.Errorf("clamav: connect: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := c.deadline(ctx); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return "", fmt.Errorf("clamav: set deadline: %w", err)
+		}
+	}
+
+	if err := stream(conn, r); err != nil {
+		// clamd replies and closes the connection as soon as a stream exceeds
+		// its StreamMaxLength, so the remaining writes can fail.
+		if reply, rErr := readReply(conn); rErr == nil && strings.HasPrefix(reply, sizeLimitReply) {
+			return "", ErrSizeLimit
+		}
+		return "", fmt.Errorf("clamav: %w", err)
+	}
+
+	reply, err := readReply(conn)
+	if err != nil {
+		return "", fmt.Errorf("clamav: read reply: %w", err)
+	}
+
+	return parseReply(reply)
+}
+
+// readReply reads a null terminated clamd reply from r.
+func readReply(r io.Reader) (string, error) {
+	reply, err := bufio.NewReader(r).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		return "", err
+	}
+
+	return strings.TrimRight(reply, "\x00\n"), nil
+}
+
+// deadline returns the earliest of the context deadline and the scan timeout.
+func (c *Client) deadline(ctx context.Context) (time.Time, bool) {
+	deadline, ok := ctx.Deadline()
+	if c.timeout > 0 {
+		if t := time.Now().Add(c.timeout); !ok || t.Before(deadline) {
+			return t, true
+		}
+	}
+
+	return deadline, ok
+}
+
+// stream sends an INSTREAM command with the contents of r to w.
+func stream(w io.Writer, r io.Reader) error {
+	if _, err := io.WriteString(w, "zINSTREAM\x00"); err != nil {
+		return fmt.Errorf("send command: %w", err)
+	}
+
+	buf := make([]byte, chunkSize)
+	var size [4]byte
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size[:], uint32(n)) // #nosec G115 -- n <= chunkSize.
+			if _, err := w.Write(size[:]); err != nil {
+				return fmt.Errorf("send data: %w", err)
+			}
+			if _, err := w.Write(buf[:n]); err != nil {
+				return fmt.Errorf("send data: %w", err)
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read data: %w", err)
+		}
+	}
+
+	// A zero length chunk ends the stream.
+	if _, err := w.Write([]byte{0, 0, 0, 0}); err != nil {
+		return fmt.Errorf("send end of stream: %w", err)
+	}
+
+	return nil
+}
+
+// parseReply parses a clamd INSTREAM reply, e.g. "stream: OK" or
+// "stream: Eicar-Test-Signature FOUND".
+func parseReply(reply string) (string, error) {
+	if strings.HasPrefix(reply, sizeLimitReply) {
+		return "", ErrSizeLimit
+	}
+
+	result, ok := strings.CutPrefix(reply, "stream: ")
+	if !ok {
+		return "", fmt.Errorf("clamav: %s", reply)
+	}
+
+	switch {
+	case result == "OK":
+		return "", nil
+	case strings.HasSuffix(result, " FOUND"):
+		return strings.TrimSuffix(result, " FOUND"), nil
+	default:
+		return "", fmt.Errorf("clamav: %s", result)
+	}
+}
@@ -0,0 +1,218 @@
+package clamav_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/clamav"
+)
+
+// eicar is a marker standing in for the EICAR test file in the stub daemon.
+const eicar = "EICAR-STANDARD-ANTIVIRUS-TEST-FILE"
+
+// startDaemon starts a stub clamd daemon listening on network that replies to
+// INSTREAM commands, and returns its address. The stub reports the
+// "Eicar-Test-Signature" signature for streams containing the eicar marker,
+// and replies with reply, if not empty, to every command.
+func startDaemon(t *testing.T, network, reply string) string {
+	t.Helper()
+
+	addr := "127.0.0.1:0"
+	if network == "unix" {
+		addr = filepath.Join(t.TempDir(), "clamd.sock")
+	}
+
+	l, err := net.Listen(network, addr)
+	assert.NilError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn, reply)
+		}
+	}()
+
+	if network == "unix" {
+		return "unix://" + l.Addr().String()
+	}
+	return "tcp://" + l.Addr().String()
+}
+
+func serve(conn net.Conn, reply string) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	cmd, err := r.ReadString(0)
+	if err != nil || cmd != "zINSTREAM\x00" {
+		io.WriteString(conn, "UNKNOWN COMMAND\x00")
+		return
+	}
+
+	var data bytes.Buffer
+	for {
+		var size uint32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			return
+		}
+		if size == 0 {
+			break
+		}
+		if _, err := io.CopyN(&data, r, int64(size)); err != nil {
+			return
+		}
+	}
+
+	switch {
+	case reply != "":
+		io.WriteString(conn, reply+"\x00")
+	case strings.Contains(data.String(), eicar):
+		io.WriteString(conn, "stream: Eicar-Test-Signature FOUND\x00")
+	default:
+		io.WriteString(conn, "stream: OK\x00")
+	}
+}
+
+func TestClient_Scan(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name    string
+		network string
+		reply   string
+		data    string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "returns no signature for a clean stream",
+			network: "tcp",
+			data:    "clean",
+		},
+		{
+			name:    "returns the signature of an infected stream",
+			network: "tcp",
+			data:    "X5O!P%@AP " + eicar,
+			want:    "Eicar-Test-Signature",
+		},
+		{
+			name:    "scans a stream larger than a chunk",
+			network: "tcp",
+			data:    strings.Repeat("a", 100_000) + eicar,
+			want:    "Eicar-Test-Signature",
+		},
+		{
+			name:    "scans over a unix socket",
+			network: "unix",
+			data:    eicar,
+			want:    "Eicar-Test-Signature",
+		},
+		{
+			name:    "errors when the stream exceeds the daemon size limit",
+			network: "tcp",
+			reply:   "INSTREAM size limit exceeded. ERROR",
+			data:    "big",
+			wantErr: "clamav: INSTREAM size limit exceeded",
+		},
+		{
+			name:    "errors when the daemon reports an error",
+			network: "tcp",
+			reply:   "UNKNOWN COMMAND",
+			data:    "data",
+			wantErr: "clamav: UNKNOWN COMMAND",
+		},
+		{
+			name:    "errors when the stream can't be scanned",
+			network: "tcp",
+			reply:   "stream: Can't allocate memory ERROR",
+			data:    "data",
+			wantErr: "clamav: Can't allocate memory ERROR",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := clamav.NewClient(startDaemon(t, tc.network, tc.reply), time.Minute)
+			assert.NilError(t, err)
+
+			got, err := c.Scan(t.Context(), strings.NewReader(tc.data))
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Equal(t, got, tc.want)
+		})
+	}
+
+	t.Run("errors when the daemon is not running", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := clamav.NewClient("unix://"+filepath.Join(t.TempDir(), "missing.sock"), 0)
+		assert.NilError(t, err)
+
+		_, err = c.Scan(t.Context(), strings.NewReader("data"))
+		assert.ErrorContains(t, err, "clamav: connect: ")
+	})
+}
+
+func TestParseAddress(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		addr        string
+		wantNetwork string
+		wantAddress string
+		wantErr     string
+	}{
+		{
+			addr:        "tcp://clamav:3310",
+			wantNetwork: "tcp",
+			wantAddress: "clamav:3310",
+		},
+		{
+			addr:        "unix:///var/run/clamav/clamd.ctl",
+			wantNetwork: "unix",
+			wantAddress: "/var/run/clamav/clamd.ctl",
+		},
+		{
+			addr:    "clamav:3310",
+			wantErr: `invalid address "clamav:3310": scheme must be "tcp" or "unix"`,
+		},
+		{
+			addr:    "tcp://",
+			wantErr: `invalid address "tcp://": missing host`,
+		},
+		{
+			addr:    "unix://",
+			wantErr: `invalid address "unix://": missing socket path`,
+		},
+	} {
+		t.Run(tc.addr, func(t *testing.T) {
+			t.Parallel()
+
+			network, address, err := clamav.ParseAddress(tc.addr)
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Equal(t, network, tc.wantNetwork)
+			assert.Equal(t, address, tc.wantAddress)
+		})
+	}
+}
@@ -3,12 +3,21 @@ package config
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"strings"
+	"time"
+	// Embed the IANA time zone database so VanDocs.TimeZone can be loaded on
+	// systems without tzdata installed.
+	_ "time/tzdata"
 
-	"github.com/artefactual-sdps/temporal-activities/bagcreate"
+	"github.com/artefactual-sdps/temporal-activities/bucketupload"
 	"github.com/spf13/viper"
 	"go.artefactual.dev/tools/bucket"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/types"
 )
 
 type Config struct {
@@ -32,6 +41,9 @@ type Config struct {
 	// Postbatch configures the postbatch workflow.
 	Postbatch PostbatchConfig
 
+	// VanDocs configures how VanDocs metadata is interpreted.
+	VanDocs VanDocsConfig
+
 	// IngestBucket configuration.
 	IngestBucket *bucket.Config
 }
@@ -42,6 +54,7 @@ func (c Config) Validate() error {
 		c.Worker.Validate(),
 		c.Preprocessing.Validate(),
 		c.Postbatch.Validate(),
+		c.VanDocs.Validate(),
 	)
 }
 
@@ -98,13 +111,49 @@ type PreprocessingConfig struct {
 	// WorkflowName is the preprocessing Temporal workflow name (required).
 	WorkflowName string
 
-	// BagCreate configures the bagcreate activity used in the preprocessing
-	// workflow.
-	BagCreate bagcreate.Config
+	// BagCreate configures the bag creation activity used in the
+	// preprocessing workflow.
+	BagCreate activities.CreateBagConfig
 
 	// SharedPath is the shared directory where Enduro puts SIPs for
 	// preprocessing (required).
 	SharedPath string
+
+	// ValidateContainerMD configures the ContainerMetadata.xml validation
+	// activity used in the preprocessing workflow.
+	ValidateContainerMD activities.ValidateContainerMDConfig
+
+	// DCMetadata configures the Dublin Core metadata file written to the SIP
+	// by the preprocessing workflow.
+	DCMetadata activities.DCMetadataConfig
+
+	// MalwareScan configures the optional malware scan of the SIP files in
+	// the preprocessing workflow.
+	MalwareScan activities.MalwareScanConfig
+
+	// IdentifyFormats configures the optional format identification and
+	// policy check of the SIP content files.
+	IdentifyFormats activities.IdentifyFormatsConfig
+
+	// VerifyChecksums configures the optional verification of the SIP
+	// content files against the checksum manifests supplied by VanDocs.
+	VerifyChecksums activities.VerifyChecksumsConfig
+
+	// ScanPII configures the optional personal information scan of the SIP
+	// content files.
+	ScanPII activities.ScanPIIConfig
+
+	// LegalHold configures the optional check that fails or quarantines the
+	// SIPs whose ContainerMetadata.xml file puts them on a legal hold.
+	LegalHold activities.LegalHoldConfig
+
+	// Retention configures the optional retention schedule and disposition
+	// check of the SIP ContainerMetadata.xml file.
+	Retention activities.RetentionConfig
+
+	// Activities configures the timeouts and retry policy of the
+	// preprocessing activities, by activity name.
+	Activities ActivitiesConfig
 }
 
 func (c PreprocessingConfig) Validate() error {
@@ -118,6 +167,29 @@ func (c PreprocessingConfig) Validate() error {
 	}
 
 	errs = errors.Join(errs, c.BagCreate.Validate())
+	errs = errors.Join(errs, c.ValidateContainerMD.Validate())
+	errs = errors.Join(errs, c.DCMetadata.Validate())
+	errs = errors.Join(errs, c.VerifyChecksums.Validate())
+	errs = errors.Join(errs, c.MalwareScan.Validate())
+	errs = errors.Join(errs, c.IdentifyFormats.Validate())
+	errs = errors.Join(errs, c.ScanPII.Validate())
+	errs = errors.Join(errs, c.LegalHold.Validate())
+	errs = errors.Join(errs, c.Retention.Validate())
+	errs = errors.Join(errs, c.Activities.Validate("Preprocessing.Activities", []string{
+		activities.ValidateStructureName,
+		activities.VerifyChecksumsName,
+		activities.ValidateContainerMDName,
+		activities.CheckLegalHoldName,
+		activities.CheckRetentionName,
+		activities.ScanMalwareName,
+		activities.IdentifyFormatsName,
+		activities.ScanPIIName,
+		bucketupload.Name,
+		activities.CreateSIPCSVName,
+		activities.CreateInventoryName,
+		activities.CreateDCMetadataName,
+		activities.CreateBagName,
+	}))
 
 	return errs
 }
@@ -125,16 +197,189 @@ func (c PreprocessingConfig) Validate() error {
 type PostbatchConfig struct {
 	// WorkflowName is the postbatch Temporal workflow name (required).
 	WorkflowName string
+
+	// CreateCSV configures the AtoM CSV columns written by the postbatch
+	// workflow.
+	CreateCSV activities.CreateCSVConfig
+
+	// DigitalObjectCSV configures the optional AtoM digital object CSV file
+	// written by the postbatch workflow.
+	DigitalObjectCSV activities.DigitalObjectCSVConfig
+
+	// EAD configures the optional EAD finding aid written by the postbatch
+	// workflow.
+	EAD activities.EADConfig
+
+	// Activities configures the timeouts and retry policy of the postbatch
+	// activities, by activity name.
+	Activities ActivitiesConfig
 }
 
 func (c PostbatchConfig) Validate() error {
+	var errs error
+
 	if c.WorkflowName == "" {
-		return errRequired("Postbatch.WorkflowName")
+		errs = errors.Join(errs, errRequired("Postbatch.WorkflowName"))
+	}
+
+	errs = errors.Join(errs, c.CreateCSV.Validate())
+	errs = errors.Join(errs, c.DigitalObjectCSV.Validate())
+	errs = errors.Join(errs, c.EAD.Validate())
+	errs = errors.Join(errs, c.Activities.Validate("Postbatch.Activities", []string{
+		activities.CreateCSVName,
+		activities.CreateDigitalObjectCSVName,
+		activities.CreateEADName,
+		activities.CreateBatchReportName,
+		activities.DeleteSIPFilesName,
+	}))
+
+	return errs
+}
+
+// ActivitiesConfig maps activity names to their activity configuration.
+// Activities that are not listed use the workflow defaults.
+type ActivitiesConfig map[string]ActivityConfig
+
+// Validate returns an error if an activity name is not one of the known
+// names, or if an activity configuration is invalid. The prefix is the
+// configuration path used in error messages.
+func (c ActivitiesConfig) Validate(prefix string, known []string) error {
+	var errs error
+
+	names := slices.Sorted(maps.Keys(c))
+	for _, name := range names {
+		if !slices.Contains(known, name) {
+			errs = errors.Join(errs, fmt.Errorf("%s: unknown activity %q", prefix, name))
+			continue
+		}
+		errs = errors.Join(errs, c[name].Validate(fmt.Sprintf("%s[%q]", prefix, name)))
+	}
+
+	return errs
+}
+
+// ActivityConfig configures the Temporal timeouts and retry policy of an
+// activity. Zero values use the workflow defaults, which give each activity a
+// single attempt and a ScheduleToCloseTimeout suited to its workload.
+type ActivityConfig struct {
+	// ScheduleToCloseTimeout is the maximum time allowed for the activity,
+	// including retries.
+	ScheduleToCloseTimeout time.Duration
+
+	// StartToCloseTimeout is the maximum time allowed for a single attempt
+	// of the activity.
+	StartToCloseTimeout time.Duration
+
+	// HeartbeatTimeout is the maximum time allowed between activity
+	// heartbeats. Only set it for activities that send heartbeats.
+	HeartbeatTimeout time.Duration
+
+	// Retry configures the activity retry policy.
+	Retry RetryConfig
+}
+
+// Validate returns an error if the activity configuration is invalid. The
+// prefix is the configuration path used in error messages.
+func (c ActivityConfig) Validate(prefix string) error {
+	var errs error
+
+	if c.ScheduleToCloseTimeout < 0 {
+		errs = errors.Join(errs, errNegative(prefix+".ScheduleToCloseTimeout", c.ScheduleToCloseTimeout))
+	}
+	if c.StartToCloseTimeout < 0 {
+		errs = errors.Join(errs, errNegative(prefix+".StartToCloseTimeout", c.StartToCloseTimeout))
+	}
+	if c.HeartbeatTimeout < 0 {
+		errs = errors.Join(errs, errNegative(prefix+".HeartbeatTimeout", c.HeartbeatTimeout))
+	}
+
+	return errors.Join(errs, c.Retry.Validate(prefix+".Retry"))
+}
+
+// RetryConfig configures an activity retry policy.
+type RetryConfig struct {
+	// InitialInterval is the delay before the first retry (default: 1s).
+	InitialInterval time.Duration
+
+	// BackoffCoefficient multiplies the retry interval after each retry
+	// (default: 2.0).
+	BackoffCoefficient float64
+
+	// MaximumInterval caps the retry interval, must not be less than
+	// InitialInterval (default: 100 times InitialInterval).
+	MaximumInterval time.Duration
+
+	// MaximumAttempts is the maximum number of attempts, including the first
+	// one. Zero uses the default of a single attempt, it does not mean
+	// unlimited attempts (default: 1).
+	MaximumAttempts int32
+
+	// NonRetryableErrorTypes lists the error types that are never retried.
+	NonRetryableErrorTypes []string
+}
+
+// initialInterval returns the configured initial interval, or Temporal's
+// default of one second if not set.
+func (c RetryConfig) initialInterval() time.Duration {
+	if c.InitialInterval == 0 {
+		return time.Second
+	}
+	return c.InitialInterval
+}
+
+// Validate returns an error if the retry policy is invalid. The prefix is the
+// configuration path used in error messages.
+func (c RetryConfig) Validate(prefix string) error {
+	var errs error
+
+	if c.InitialInterval < 0 {
+		errs = errors.Join(errs, errNegative(prefix+".InitialInterval", c.InitialInterval))
+	}
+	if c.BackoffCoefficient != 0 && c.BackoffCoefficient < 1 {
+		errs = errors.Join(errs, fmt.Errorf(
+			"%s.BackoffCoefficient: %g is less than the minimum value (1)",
+			prefix, c.BackoffCoefficient,
+		))
+	}
+	if c.MaximumInterval < 0 {
+		errs = errors.Join(errs, errNegative(prefix+".MaximumInterval", c.MaximumInterval))
+	}
+	if c.MaximumInterval > 0 && c.MaximumInterval < c.initialInterval() {
+		errs = errors.Join(errs, fmt.Errorf(
+			"%s.MaximumInterval: %s is less than the initial interval (%s)",
+			prefix, c.MaximumInterval, c.initialInterval(),
+		))
+	}
+	if c.MaximumAttempts < 0 {
+		errs = errors.Join(errs, fmt.Errorf(
+			"%s.MaximumAttempts: %d is negative, use 0 for the default (1 attempt)",
+			prefix, c.MaximumAttempts,
+		))
+	}
+
+	return errs
+}
+
+type VanDocsConfig struct {
+	// TimeZone is the IANA time zone name used to interpret VanDocs dates
+	// that have no time zone offset, e.g. "America/Vancouver" (default:
+	// "UTC").
+	TimeZone string
+}
+
+func (c VanDocsConfig) Validate() error {
+	if _, err := c.Location(); err != nil {
+		return fmt.Errorf("VanDocs.TimeZone: %v", err)
 	}
 
 	return nil
 }
 
+// Location returns the time zone location for TimeZone.
+func (c VanDocsConfig) Location() (*time.Location, error) {
+	return time.LoadLocation(c.TimeZone)
+}
+
 func Read(config *Config, configFile string) (found bool, configFileUsed string, err error) {
 	v := viper.New()
 
@@ -150,6 +395,9 @@ func Read(config *Config, configFile string) (found bool, configFileUsed string,
 	v.SetDefault("Temporal.Namespace", "default")
 	v.SetDefault("Worker.MaxConcurrentSessions", 1)
 	v.SetDefault("Preprocessing.BagCreate.ChecksumAlgorithm", "sha512")
+	v.SetDefault("Preprocessing.BagCreate.TimeoutPerGiB", "2m")
+	v.SetDefault("Preprocessing.ValidateContainerMD.RequiredFields", types.DefaultRequiredFields)
+	v.SetDefault("VanDocs.TimeZone", "UTC")
 
 	if configFile != "" {
 		// Viper will not return a viper.ConfigFileNotFoundError error when
@@ -186,3 +434,7 @@ func Read(config *Config, configFile string) (found bool, configFileUsed string,
 func errRequired(name string) error {
 	return fmt.Errorf("%s: missing required value", name)
 }
+
+func errNegative(name string, d time.Duration) error {
+	return fmt.Errorf("%s: %s is negative", name, d)
+}
@@ -1,13 +1,15 @@
 package config_test
 
 import (
+	"strings"
 	"testing"
+	"time"
 
-	"github.com/artefactual-sdps/temporal-activities/bagcreate"
 	"go.artefactual.dev/tools/bucket"
 	"gotest.tools/v3/assert"
 	"gotest.tools/v3/fs"
 
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
 	"github.com/artefactual-sdps/cva-enduro-workflows/internal/config"
 )
 
@@ -34,6 +36,8 @@ sharedPath = "/home/enduro/shared"
 checksumAlgorithm = "sha256"
 [postbatch]
 workflowName = "postbatch"
+[vanDocs]
+timeZone = "America/Vancouver"
 `
 
 func TestConfig(t *testing.T) {
@@ -69,11 +73,96 @@ func TestConfig(t *testing.T) {
 				Preprocessing: config.PreprocessingConfig{
 					WorkflowName: "preprocessing",
 					SharedPath:   "/home/enduro/shared",
-					BagCreate: bagcreate.Config{
+					BagCreate: activities.CreateBagConfig{
 						ChecksumAlgorithm: "sha256",
+						TimeoutPerGiB:     2 * time.Minute,
+					},
+					ValidateContainerMD: activities.ValidateContainerMDConfig{
+						RequiredFields: []string{
+							"RecordNumber",
+							"Classification",
+							"TitleFreeTextPart",
+							"DateRegistered",
+						},
 					},
 				},
 				Postbatch: config.PostbatchConfig{WorkflowName: "postbatch"},
+				VanDocs:   config.VanDocsConfig{TimeZone: "America/Vancouver"},
+				IngestBucket: &bucket.Config{
+					Endpoint:  "http://minio.enduro-sdps:9000",
+					PathStyle: true,
+					AccessKey: "minio",
+					SecretKey: "minio123",
+					Region:    "us-west-1",
+					Bucket:    "enduro-ingest",
+				},
+			},
+		},
+		{
+			name:       "Loads activity options from a TOML file",
+			configFile: "cva-enduro-worker.toml",
+			toml: testConfig + `[preprocessing.activities.create-bag-activity]
+scheduleToCloseTimeout = "2h"
+heartbeatTimeout = "1m"
+[postbatch.activities.create-csv-activity]
+startToCloseTimeout = "5m"
+[postbatch.activities.create-csv-activity.retry]
+initialInterval = "10s"
+backoffCoefficient = 1.5
+maximumInterval = "1m"
+maximumAttempts = 5
+nonRetryableErrorTypes = ["ContentError"]
+`,
+			wantFound: true,
+			wantCfg: config.Config{
+				Debug:     true,
+				Verbosity: 2,
+				Temporal: config.TemporalConfig{
+					Address:   "temporal.enduro-sdps:7233",
+					Namespace: "default",
+				},
+				Worker: config.WorkerConfig{
+					MaxConcurrentSessions: 1,
+					TaskQueue:             "cva-enduro",
+				},
+				Preprocessing: config.PreprocessingConfig{
+					WorkflowName: "preprocessing",
+					SharedPath:   "/home/enduro/shared",
+					BagCreate: activities.CreateBagConfig{
+						ChecksumAlgorithm: "sha256",
+						TimeoutPerGiB:     2 * time.Minute,
+					},
+					ValidateContainerMD: activities.ValidateContainerMDConfig{
+						RequiredFields: []string{
+							"RecordNumber",
+							"Classification",
+							"TitleFreeTextPart",
+							"DateRegistered",
+						},
+					},
+					Activities: config.ActivitiesConfig{
+						activities.CreateBagName: {
+							ScheduleToCloseTimeout: 2 * time.Hour,
+							HeartbeatTimeout:       time.Minute,
+						},
+					},
+				},
+				Postbatch: config.PostbatchConfig{
+					WorkflowName: "postbatch",
+					Activities: config.ActivitiesConfig{
+						activities.CreateCSVName: {
+							StartToCloseTimeout: 5 * time.Minute,
+							Retry: config.RetryConfig{
+								InitialInterval:        10 * time.Second,
+								BackoffCoefficient:     1.5,
+								MaximumInterval:        time.Minute,
+								MaximumAttempts:        5,
+								NonRetryableErrorTypes: []string{"ContentError"},
+							},
+						},
+					},
+				},
+				VanDocs: config.VanDocsConfig{TimeZone: "America/Vancouver"},
 				IngestBucket: &bucket.Config{
 					Endpoint:  "http://minio.enduro-sdps:9000",
 					PathStyle: true,
@@ -123,6 +212,155 @@ workflowName = "postbatch"
 			wantFound: true,
 			wantErr: `invalid configuration
 Worker.MaxConcurrentSessions: -1 is less than the minimum value (1)`,
+		},
+		{
+			name:       "Errors when a required ContainerMetadata.xml field is unknown",
+			configFile: "cva-enduro-worker.toml",
+			toml: testConfig + `[preprocessing.validateContainerMD]
+requiredFields = ["RecordNumber", "Colour"]
+`,
+			wantFound: true,
+			wantErr: `invalid configuration
+Preprocessing.ValidateContainerMD.RequiredFields: unknown field "Colour"`,
+		},
+		{
+			name:       "Errors when a CSV column source is unknown",
+			configFile: "cva-enduro-worker.toml",
+			toml: testConfig + `[[postbatch.createCSV.columns]]
+name = "identifier"
+source = "Identifier"
+[[postbatch.createCSV.columns]]
+name = "colour"
+source = "Container.Colour"
+`,
+			wantFound: true,
+			wantErr: `invalid configuration
+Postbatch.CreateCSV.Columns[1]: Source: unknown source "Container.Colour"`,
+		},
+		{
+			name:       "Errors when the CSV metadata error behaviour is unknown",
+			configFile: "cva-enduro-worker.toml",
+			toml: testConfig + `[postbatch.createCSV]
+onMetadataError = "ignore"
+`,
+			wantFound: true,
+			wantErr: `invalid configuration
+Postbatch.CreateCSV.OnMetadataError: unknown value "ignore", must be "fail", "skip" or "placeholder"`,
+		},
+		{
+			name:       "Errors when a CSV access rule is invalid",
+			configFile: "cva-enduro-worker.toml",
+			toml: testConfig + `[[postbatch.createCSV.accessRules]]
+name = "Open"
+personalInformationBank = false
+security = ["Unclassified"]
+publicationStatus = "public"
+`,
+			wantFound: true,
+			wantErr: `invalid configuration
+Postbatch.CreateCSV.AccessRules[0]: PublicationStatus: unknown value "public", must be "draft" or "published"`,
+		},
+		{
+			name:       "Errors when the legal hold action is unknown",
+			configFile: "cva-enduro-worker.toml",
+			toml: testConfig + `[preprocessing.legalHold]
+enabled = true
+action = "delete"
+`,
+			wantFound: true,
+			wantErr: `invalid configuration
+Preprocessing.LegalHold.Action: unknown value "delete", must be "fail" or "quarantine"`,
+		},
+		{
+			name:       "Errors when a retention policy is invalid",
+			configFile: "cva-enduro-worker.toml",
+			toml: testConfig + `[preprocessing.retention]
+enabled = true
+[[preprocessing.retention.schedules]]
+schedule = "ARCS 100-01"
+tolerance = "720h"
+onViolation = "ignore"
+`,
+			wantFound: true,
+			wantErr: `invalid configuration
+Preprocessing.Retention.Schedules[0].OnViolation: unknown value "ignore", must be "fail" or "warn"`,
+		},
+		{
+			name:       "Errors when a CSV event rule is invalid",
+			configFile: "cva-enduro-worker.toml",
+			toml: testConfig + `[[postbatch.createCSV.events]]
+type = "Accumulation"
+start = "DateCreated"
+actor = ["Owner"]
+[[postbatch.createCSV.events]]
+type = "Publication"
+start = "Title"
+`,
+			wantFound: true,
+			wantErr: `invalid configuration
+Postbatch.CreateCSV.Events[1]: Start: "Title" is not a date field`,
+		},
+		{
+			name:       "Errors when the EAD version is unknown",
+			configFile: "cva-enduro-worker.toml",
+			toml: testConfig + `[postbatch.ead]
+enabled = true
+version = "ead1"
+`,
+			wantFound: true,
+			wantErr: `invalid configuration
+Postbatch.EAD.Version: unknown version "ead1", must be "ead2002" or "ead3"`,
+		},
+		{
+			name:       "Errors when the malware scan address is missing",
+			configFile: "cva-enduro-worker.toml",
+			toml: testConfig + `[preprocessing.malwareScan]
+enabled = true
+`,
+			wantFound: true,
+			wantErr: `invalid configuration
+Preprocessing.MalwareScan.Address: missing required value`,
+		},
+		{
+			name:       "Errors when the format policy is not valid",
+			configFile: "cva-enduro-worker.toml",
+			toml: testConfig + `[preprocessing.identifyFormats]
+enabled = true
+onViolation = "ignore"
+`,
+			wantFound: true,
+			wantErr: `invalid configuration
+Preprocessing.IdentifyFormats.OnViolation: unknown value "ignore", must be "fail" or "warn"`,
+		},
+		{
+			name:       "Errors when activity options are not valid",
+			configFile: "cva-enduro-worker.toml",
+			toml: testConfig + `[preprocessing.activities.create-bag-activity]
+scheduleToCloseTimeout = "-1m"
+[preprocessing.activities.create-bag-activity.retry]
+backoffCoefficient = 0.5
+[preprocessing.activities.verify-checksums-activity.retry]
+initialInterval = "10s"
+maximumInterval = "5s"
+maximumAttempts = -1
+[postbatch.activities.create-csv]
+maximumAttempts = 3
+`,
+			wantFound: true,
+			wantErr: `invalid configuration
+Preprocessing.Activities["create-bag-activity"].ScheduleToCloseTimeout: -1m0s is negative
+Preprocessing.Activities["create-bag-activity"].Retry.BackoffCoefficient: 0.5 is less than the minimum value (1)
+Preprocessing.Activities["verify-checksums-activity"].Retry.MaximumInterval: 5s is less than the initial interval (10s)
+Preprocessing.Activities["verify-checksums-activity"].Retry.MaximumAttempts: -1 is negative, use 0 for the default (1 attempt)
+Postbatch.Activities: unknown activity "create-csv"`,
+		},
+		{
+			name:       "Errors when the VanDocs time zone is unknown",
+			configFile: "cva-enduro-worker.toml",
+			toml:       strings.Replace(testConfig, "America/Vancouver", "Mars/Olympus_Mons", 1),
+			wantFound:  true,
+			wantErr: `invalid configuration
+VanDocs.TimeZone: unknown time zone Mars/Olympus_Mons`,
 		},
 		{
 			name:       "Errors when TOML is invalid",
@@ -3,5 +3,7 @@ package enums
 // ENUM(
 // Creation
 // Recordkeeping
+// Accumulation
+// Publication
 // ).
 type EventType string
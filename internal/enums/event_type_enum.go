@@ -18,6 +18,10 @@ const (
 	EventTypeCreation EventType = "Creation"
 	// EventTypeRecordkeeping is a EventType of type Recordkeeping.
 	EventTypeRecordkeeping EventType = "Recordkeeping"
+	// EventTypeAccumulation is a EventType of type Accumulation.
+	EventTypeAccumulation EventType = "Accumulation"
+	// EventTypePublication is a EventType of type Publication.
+	EventTypePublication EventType = "Publication"
 )
 
 var ErrInvalidEventType = fmt.Errorf("not a valid EventType, try [%s]", strings.Join(_EventTypeNames, ", "))
@@ -25,6 +29,8 @@ var ErrInvalidEventType = fmt.Errorf("not a valid EventType, try [%s]", strings.
 var _EventTypeNames = []string{
 	string(EventTypeCreation),
 	string(EventTypeRecordkeeping),
+	string(EventTypeAccumulation),
+	string(EventTypePublication),
 }
 
 // EventTypeNames returns a list of possible string values of EventType.
@@ -49,6 +55,8 @@ func (x EventType) IsValid() bool {
 var _EventTypeValue = map[string]EventType{
 	"Creation":      EventTypeCreation,
 	"Recordkeeping": EventTypeRecordkeeping,
+	"Accumulation":  EventTypeAccumulation,
+	"Publication":   EventTypePublication,
 }
 
 // ParseEventType attempts to convert a string to a EventType.
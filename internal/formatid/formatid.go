@@ -0,0 +1,293 @@
+// Package formatid identifies file formats with PRONOM-style byte sequence
+// signatures, and reports their PRONOM unique identifiers (PUIDs).
+//
+// A subset of the PRONOM signatures covering the formats exported by VanDocs
+// is bundled with the package. Other signatures can be loaded from a JSON file
+// with the same structure as the bundled "signatures.json" file.
+package formatid
+
+import (
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Identification bases, from the most to the least reliable.
+const (
+	BasisSignatureAndExtension string = "signature and extension match"
+	BasisSignature             string = "signature match"
+	BasisExtension             string = "extension match"
+	BasisNone                  string = "no match"
+)
+
+// UnknownPUID is the PUID reported for files that don't match any signature.
+const UnknownPUID string = "UNKNOWN"
+
+// maxHeaderSize is the maximum number of bytes read from the start of a file
+// to match the signature byte sequences.
+const maxHeaderSize = 64 << 10
+
+//go:embed signatures.json
+var bundled []byte
+
+type (
+	// Signatures is a versioned set of format signatures.
+	Signatures struct {
+		// Version identifies the signature set in identification reports.
+		Version    string      `json:"version"`
+		Signatures []Signature `json:"signatures"`
+	}
+
+	// Signature identifies a file format.
+	Signature struct {
+		PUID       string   `json:"puid"`
+		Name       string   `json:"name"`
+		MIMEType   string   `json:"mimeType"`
+		Extensions []string `json:"extensions"`
+
+		// Patterns are the byte sequences that must all be found in a file
+		// of this format. A signature without patterns matches files by
+		// extension only.
+		Patterns []Pattern `json:"patterns"`
+
+		// RequireExtension limits the signature to files with one of its
+		// extensions, e.g. to tell apart the formats of ZIP based files.
+		// Files matching it are identified tentatively, the byte sequences
+		// don't tell the formats apart.
+		RequireExtension bool `json:"requireExtension"`
+
+		// Generic marks signatures whose byte sequences are shared by a
+		// family of formats, e.g. any JPEG or ZIP file. Files matching it are
+		// identified tentatively.
+		Generic bool `json:"generic"`
+	}
+
+	// Pattern is a byte sequence found at an offset from the start of a
+	// file.
+	Pattern struct {
+		Offset int `json:"offset"`
+
+		// Hex is the hexadecimal byte sequence, "??" matches any byte.
+		Hex string `json:"hex"`
+	}
+
+	// Result is the format identification of a file.
+	Result struct {
+		PUID     string
+		Format   string
+		MIMEType string
+		Basis    string
+
+		// Tentative is true if the PUID is a best guess: the file matches by
+		// extension only, or its format is told apart from other formats by
+		// extension only, or it matches a generic signature.
+		Tentative bool
+	}
+)
+
+// Identifier identifies file formats with a set of signatures.
+type Identifier struct {
+	version    string
+	signatures []signature
+	headerSize int
+}
+
+// signature is a Signature with its patterns decoded.
+type signature struct {
+	Signature
+	patterns []pattern
+	length   int
+}
+
+type pattern struct {
+	offset int
+	bytes  []byte
+	// mask is false for the bytes that match any value.
+	mask []bool
+}
+
+// Load returns an Identifier using the signatures from the JSON file at path,
+// or the bundled signatures if path is empty.
+func Load(path string) (*Identifier, error) {
+	data := bundled
+	if path != "" {
+		var err error
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("formatid: %w", err)
+		}
+	}
+
+	var sigs Signatures
+	if err := json.Unmarshal(data, &sigs); err != nil {
+		return nil, fmt.Errorf("formatid: parse signatures: %w", err)
+	}
+
+	return New(sigs)
+}
+
+// New returns an Identifier using sigs.
+func New(sigs Signatures) (*Identifier, error) {
+	id := &Identifier{version: sigs.Version}
+
+	var errs error
+	for i, s := range sigs.Signatures {
+		if s.PUID == "" {
+			errs = errors.Join(errs, fmt.Errorf("formatid: signature %d: missing PUID", i))
+			continue
+		}
+
+		sig := signature{Signature: s}
+		for _, p := range s.Patterns {
+			dp, err := decodePattern(p)
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("formatid: signature %d (%s): %v", i, s.PUID, err))
+				continue
+			}
+			sig.patterns = append(sig.patterns, dp)
+			sig.length += len(dp.bytes)
+			id.headerSize = max(id.headerSize, dp.offset+len(dp.bytes))
+		}
+		id.signatures = append(id.signatures, sig)
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	if id.headerSize > maxHeaderSize {
+		return nil, fmt.Errorf("formatid: pattern offsets exceed %d bytes", maxHeaderSize)
+	}
+
+	return id, nil
+}
+
+func decodePattern(p Pattern) (pattern, error) {
+	if p.Offset < 0 {
+		return pattern{}, fmt.Errorf("negative offset %d", p.Offset)
+	}
+	if p.Hex == "" || len(p.Hex)%2 != 0 {
+		return pattern{}, fmt.Errorf("invalid hex sequence %q", p.Hex)
+	}
+
+	dp := pattern{offset: p.Offset}
+	for i := 0; i < len(p.Hex); i += 2 {
+		h := p.Hex[i : i+2]
+		if h == "??" {
+			dp.bytes = append(dp.bytes, 0)
+			dp.mask = append(dp.mask, false)
+			continue
+		}
+
+		b, err := hex.DecodeString(h)
+		if err != nil {
+			return pattern{}, fmt.Errorf("invalid hex sequence %q", p.Hex)
+		}
+		dp.bytes = append(dp.bytes, b[0])
+		dp.mask = append(dp.mask, true)
+	}
+
+	return dp, nil
+}
+
+func (p pattern) match(header []byte) bool {
+	if p.offset+len(p.bytes) > len(header) {
+		return false
+	}
+
+	for i, b := range p.bytes {
+		if p.mask[i] && header[p.offset+i] != b {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Version returns the version of the signature set.
+func (id *Identifier) Version() string {
+	return id.version
+}
+
+// Identify returns the format of the file with the given name and contents r.
+//
+// Signatures with byte sequences found in r take precedence over extension
+// only signatures. When several signatures match, the ones that also match
+// the file extension, then the ones with the longest byte sequences, win.
+func (id *Identifier) Identify(name string, r io.Reader) (Result, error) {
+	header := make([]byte, id.headerSize)
+	n, err := io.ReadFull(r, header)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return Result{}, fmt.Errorf("formatid: read %s: %w", name, err)
+	}
+	header = header[:n]
+
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+
+	var (
+		best      *signature
+		bestByExt bool
+	)
+	for i := range id.signatures {
+		sig := &id.signatures[i]
+		if len(sig.patterns) == 0 {
+			continue
+		}
+
+		byExt := slices.Contains(sig.Extensions, ext)
+		if sig.RequireExtension && !byExt {
+			continue
+		}
+		if !sig.match(header) {
+			continue
+		}
+
+		if best == nil ||
+			(byExt && !bestByExt) ||
+			(byExt == bestByExt && sig.length > best.length) {
+			best, bestByExt = sig, byExt
+		}
+	}
+
+	if best != nil {
+		basis := BasisSignature
+		if bestByExt {
+			basis = BasisSignatureAndExtension
+		}
+		return best.result(basis, best.RequireExtension || best.Generic), nil
+	}
+
+	for i := range id.signatures {
+		sig := &id.signatures[i]
+		if len(sig.patterns) == 0 && slices.Contains(sig.Extensions, ext) {
+			return sig.result(BasisExtension, true), nil
+		}
+	}
+
+	return Result{PUID: UnknownPUID, Basis: BasisNone}, nil
+}
+
+func (s *signature) match(header []byte) bool {
+	for _, p := range s.patterns {
+		if !p.match(header) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *signature) result(basis string, tentative bool) Result {
+	return Result{
+		PUID:      s.PUID,
+		Format:    s.Name,
+		MIMEType:  s.MIMEType,
+		Basis:     basis,
+		Tentative: tentative,
+	}
+}
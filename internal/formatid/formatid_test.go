@@ -0,0 +1,198 @@
+package formatid_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/formatid"
+)
+
+func TestIdentifier_Identify(t *testing.T) {
+	t.Parallel()
+
+	id, err := formatid.Load("")
+	assert.NilError(t, err)
+	assert.Equal(t, id.Version(), "cva-2026-10")
+
+	for _, tc := range []struct {
+		name string
+		file string
+		data string
+		want formatid.Result
+	}{
+		{
+			name: "identifies a PDF file",
+			file: "report.pdf",
+			data: "%PDF-1.7\n%âãÏÓ\n",
+			want: formatid.Result{
+				PUID:     "fmt/276",
+				Format:   "Acrobat PDF 1.7 - Portable Document Format",
+				MIMEType: "application/pdf",
+				Basis:    formatid.BasisSignatureAndExtension,
+			},
+		},
+		{
+			name: "identifies a file by signature with the wrong extension",
+			file: "report.txt",
+			data: "%PDF-1.4\n",
+			want: formatid.Result{
+				PUID:     "fmt/18",
+				Format:   "Acrobat PDF 1.4 - Portable Document Format",
+				MIMEType: "application/pdf",
+				Basis:    formatid.BasisSignature,
+			},
+		},
+		{
+			name: "prefers the most specific signature",
+			file: "photo.JPG",
+			data: "\xff\xd8\xff\xe0\x00\x10JFIF\x00\x01\x01\x00",
+			want: formatid.Result{
+				PUID:     "fmt/43",
+				Format:   "JPEG File Interchange Format 1.01",
+				MIMEType: "image/jpeg",
+				Basis:    formatid.BasisSignatureAndExtension,
+			},
+		},
+		{
+			name: "tells ZIP based formats apart by extension",
+			file: "letter.docx",
+			data: "PK\x03\x04\x14\x00",
+			want: formatid.Result{
+				PUID:      "fmt/412",
+				Format:    "Microsoft Word for Windows 2007 onwards",
+				MIMEType:  "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+				Basis:     formatid.BasisSignatureAndExtension,
+				Tentative: true,
+			},
+		},
+		{
+			name: "identifies a ZIP file with an unknown extension",
+			file: "archive.bin",
+			data: "PK\x03\x04\x14\x00",
+			want: formatid.Result{
+				PUID:      "x-fmt/263",
+				Format:    "ZIP Format",
+				MIMEType:  "application/zip",
+				Basis:     formatid.BasisSignature,
+				Tentative: true,
+			},
+		},
+		{
+			name: "identifies a JPEG file without JFIF header tentatively",
+			file: "photo.jpg",
+			data: "\xff\xd8\xff\xe1\x00\x16Exif\x00\x00MM\x00*",
+			want: formatid.Result{
+				PUID:      "fmt/41",
+				Format:    "Raw JPEG Stream",
+				MIMEType:  "image/jpeg",
+				Basis:     formatid.BasisSignatureAndExtension,
+				Tentative: true,
+			},
+		},
+		{
+			name: "matches patterns at an offset",
+			file: "audio.wav",
+			data: "RIFF\x24\x08\x00\x00WAVEfmt ",
+			want: formatid.Result{
+				PUID:     "fmt/141",
+				Format:   "Waveform Audio",
+				MIMEType: "audio/x-wav",
+				Basis:    formatid.BasisSignatureAndExtension,
+			},
+		},
+		{
+			name: "identifies a file by extension",
+			file: "notes.txt",
+			data: "Meeting notes",
+			want: formatid.Result{
+				PUID:      "x-fmt/111",
+				Format:    "Plain Text File",
+				MIMEType:  "text/plain",
+				Basis:     formatid.BasisExtension,
+				Tentative: true,
+			},
+		},
+		{
+			name: "reports unknown formats",
+			file: "data.bin",
+			data: "\x00\x01\x02",
+			want: formatid.Result{
+				PUID:  formatid.UnknownPUID,
+				Basis: formatid.BasisNone,
+			},
+		},
+		{
+			name: "reports empty files as unknown",
+			file: "empty.pdf",
+			want: formatid.Result{
+				PUID:  formatid.UnknownPUID,
+				Basis: formatid.BasisNone,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := id.Identify(tc.file, strings.NewReader(tc.data))
+			assert.NilError(t, err)
+			assert.DeepEqual(t, got, tc.want)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	t.Run("loads signatures from a file", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "signatures.json")
+		err := os.WriteFile(path, []byte(`{
+  "version": "test",
+  "signatures": [
+    {"puid": "fmt/999", "name": "Test", "extensions": ["tst"], "patterns": [{"offset": 2, "hex": "54??54"}]}
+  ]
+}`), 0o600)
+		assert.NilError(t, err)
+
+		id, err := formatid.Load(path)
+		assert.NilError(t, err)
+		assert.Equal(t, id.Version(), "test")
+
+		got, err := id.Identify("a.dat", strings.NewReader("xxTeT"))
+		assert.NilError(t, err)
+		assert.DeepEqual(t, got, formatid.Result{
+			PUID:   "fmt/999",
+			Format: "Test",
+			Basis:  formatid.BasisSignature,
+		})
+	})
+
+	t.Run("errors when the file is missing", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := formatid.Load(filepath.Join(t.TempDir(), "missing.json"))
+		assert.ErrorContains(t, err, "formatid: open ")
+	})
+
+	t.Run("errors when the signatures are not valid", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := formatid.New(formatid.Signatures{
+			Signatures: []formatid.Signature{
+				{Name: "No PUID"},
+				{PUID: "fmt/1", Patterns: []formatid.Pattern{{Hex: "ABC"}}},
+				{PUID: "fmt/2", Patterns: []formatid.Pattern{{Offset: -1, Hex: "AB"}}},
+				{PUID: "fmt/3", Patterns: []formatid.Pattern{{Hex: "ZZ"}}},
+			},
+		})
+		assert.Error(t, err, `formatid: signature 0: missing PUID
+formatid: signature 1 (fmt/1): invalid hex sequence "ABC"
+formatid: signature 2 (fmt/2): negative offset -1
+formatid: signature 3 (fmt/3): invalid hex sequence "ZZ"`)
+	})
+}
@@ -0,0 +1,388 @@
+// Package textextract extracts the text of plain text, PDF and Office Open XML
+// (DOCX, XLSX and PPTX) files, so it can be searched for sensitive content.
+//
+// The extraction is best effort: PDF text is read from the content stream
+// text operators, which only gives readable text for fonts with a standard
+// encoding, and the layout of the text is not preserved.
+package textextract
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupported is returned for files with an extension that is not
+// supported.
+var ErrUnsupported = errors.New("unsupported file type")
+
+// ErrLimit is returned when more text, or decompressed PDF streams and Office
+// Open XML parts, than the extraction limit would be read from a file.
+var ErrLimit = errors.New("extraction limit exceeded")
+
+// plainTextExtensions are the extensions of the files read as plain text.
+var plainTextExtensions = []string{
+	".csv", ".eml", ".htm", ".html", ".json", ".log", ".md", ".tsv", ".txt", ".xml",
+}
+
+// officeExtensions maps the Office Open XML extensions to the directory of
+// the package parts holding the document text.
+var officeExtensions = map[string]string{
+	".docx": "word/",
+	".pptx": "ppt/",
+	".xlsx": "xl/",
+}
+
+// officeBreaks are the local names of the Office Open XML elements (paragraphs,
+// table cells and rows, spreadsheet cells and shared strings) that separate
+// words.
+var officeBreaks = []string{"br", "c", "p", "row", "si", "tab", "tc", "tr"}
+
+// wordGap is the TJ array displacement, in thousandths of a text space unit,
+// from which two PDF strings are taken to be separate words.
+const wordGap = -150
+
+// Supported reports whether the text of the named file can be extracted.
+func Supported(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	_, office := officeExtensions[ext]
+
+	return office || ext == ".pdf" || slices.Contains(plainTextExtensions, ext)
+}
+
+// Extract returns the text of the named file of size bytes read from r. The
+// file type is chosen by the name extension, ErrUnsupported is returned if it
+// is not supported.
+//
+// The limit is the maximum number of bytes of plain text, or of decompressed
+// PDF streams and Office Open XML parts, read from the file, so compressed
+// files can't exhaust the memory. ErrLimit is returned if it is exceeded.
+func Extract(name string, r io.ReaderAt, size, limit int64) (string, error) {
+	l := &readLimit{left: limit}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	switch {
+	case slices.Contains(plainTextExtensions, ext):
+		b, err := io.ReadAll(l.reader(io.NewSectionReader(r, 0, size)))
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	case ext == ".pdf":
+		b, err := io.ReadAll(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return "", err
+		}
+		return pdfText(b, l)
+	case officeExtensions[ext] != "":
+		return officeText(r, size, officeExtensions[ext], l)
+	default:
+		return "", ErrUnsupported
+	}
+}
+
+// readLimit is the number of bytes that can still be read from the readers
+// of a file.
+type readLimit struct {
+	left int64
+}
+
+// reader returns a reader of r that fails with ErrLimit if more than the
+// bytes left are read.
+func (l *readLimit) reader(r io.Reader) io.Reader {
+	return &limitedReader{r: io.LimitReader(r, l.left+1), l: l}
+}
+
+type limitedReader struct {
+	r io.Reader
+	l *readLimit
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.l.left -= int64(n)
+	if r.l.left < 0 {
+		return n, ErrLimit
+	}
+
+	return n, err
+}
+
+// officeText returns the text of the XML parts in the dir directory of an
+// Office Open XML package, in part name order.
+func officeText(r io.ReaderAt, size int64, dir string, l *readLimit) (string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return "", fmt.Errorf("open package: %w", err)
+	}
+
+	files := slices.Clone(zr.File)
+	slices.SortFunc(files, func(a, b *zip.File) int { return strings.Compare(a.Name, b.Name) })
+
+	var sb strings.Builder
+	for _, f := range files {
+		if !strings.HasPrefix(f.Name, dir) || !strings.HasSuffix(f.Name, ".xml") ||
+			strings.Contains(f.Name, "/_rels/") {
+			continue
+		}
+		if err := xmlText(f, &sb, l); err != nil {
+			return "", fmt.Errorf("read %s: %w", f.Name, err)
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// xmlText writes the character data of the XML part f to sb.
+func xmlText(f *zip.File, sb *strings.Builder, l *readLimit) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	dec := xml.NewDecoder(l.reader(rc))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if l.left < 0 {
+			return ErrLimit
+		}
+		if err != nil {
+			return err
+		}
+
+		switch tok := tok.(type) {
+		case xml.CharData:
+			sb.Write(tok)
+		case xml.EndElement:
+			if slices.Contains(officeBreaks, tok.Name.Local) {
+				sb.WriteByte('\n')
+			}
+		}
+	}
+}
+
+// pdfText returns the text shown by the text operators of the PDF content
+// streams in data. Streams compressed with a filter other than FlateDecode,
+// e.g. images, are skipped.
+func pdfText(data []byte, l *readLimit) (string, error) {
+	var sb strings.Builder
+	for rest := data; ; {
+		start := bytes.Index(rest, []byte("stream"))
+		if start < 0 {
+			break
+		}
+
+		// Skip the "endstream" keywords.
+		if start >= 3 && string(rest[start-3:start]) == "end" {
+			rest = rest[start+len("stream"):]
+			continue
+		}
+
+		dict := rest[:start]
+		if i := bytes.LastIndex(dict, []byte("obj")); i >= 0 {
+			dict = dict[i:]
+		}
+
+		body := rest[start+len("stream"):]
+		body = bytes.TrimPrefix(body, []byte("\r"))
+		body = bytes.TrimPrefix(body, []byte("\n"))
+		end := bytes.Index(body, []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		rest = body[end+len("endstream"):]
+
+		content, ok, err := pdfStreamContent(dict, body[:end], l)
+		if err != nil {
+			return "", err
+		}
+		if ok && bytes.Contains(content, []byte("BT")) {
+			pdfContentText(content, &sb)
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// pdfStreamContent returns the decoded content of a PDF stream with the given
+// dictionary, and false if the stream can't be decoded. It returns ErrLimit if
+// the decompressed stream exceeds the limit.
+func pdfStreamContent(dict, stream []byte, l *readLimit) ([]byte, bool, error) {
+	if !bytes.Contains(dict, []byte("/Filter")) {
+		return stream, true, nil
+	}
+
+	filters := bytes.Count(dict, []byte("Decode")) - bytes.Count(dict, []byte("DecodeParms"))
+	if filters != 1 || !bytes.Contains(dict, []byte("/FlateDecode")) {
+		return nil, false, nil
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(stream))
+	if err != nil {
+		return nil, false, nil
+	}
+	defer zr.Close()
+
+	// Use what can be read from a truncated or corrupt stream.
+	b, err := io.ReadAll(l.reader(zr))
+	if errors.Is(err, ErrLimit) {
+		return nil, false, err
+	}
+
+	return b, len(b) > 0, nil
+}
+
+// pdfContentText writes the strings shown between the BT and ET operators of
+// the PDF content stream c to sb.
+func pdfContentText(c []byte, sb *strings.Builder) {
+	inText := false
+	for i := 0; i < len(c); {
+		ch := c[i]
+		switch {
+		case ch == '%':
+			for i < len(c) && c[i] != '\n' && c[i] != '\r' {
+				i++
+			}
+		case ch == '(':
+			s, n := pdfLiteralString(c[i:])
+			if inText {
+				sb.Write(s)
+			}
+			i += n
+		case ch == '<' && i+1 < len(c) && c[i+1] != '<':
+			s, n := pdfHexString(c[i:])
+			if inText {
+				sb.Write(s)
+			}
+			i += n
+		case ch == '-' || ch == '+' || ch == '.' || (ch >= '0' && ch <= '9'):
+			n := 1
+			for i+n < len(c) && (c[i+n] == '.' || (c[i+n] >= '0' && c[i+n] <= '9')) {
+				n++
+			}
+			if v, err := strconv.ParseFloat(string(c[i:i+n]), 64); inText && err == nil && v <= wordGap {
+				sb.WriteByte(' ')
+			}
+			i += n
+		case ch == '\'' || ch == '"':
+			if inText {
+				sb.WriteByte(' ')
+			}
+			i++
+		case (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') || ch == '*':
+			n := 1
+			for i+n < len(c) && ((c[i+n] >= 'A' && c[i+n] <= 'Z') || (c[i+n] >= 'a' && c[i+n] <= 'z') || c[i+n] == '*') {
+				n++
+			}
+			switch string(c[i : i+n]) {
+			case "BT":
+				inText = true
+			case "ET":
+				inText = false
+				sb.WriteByte('\n')
+			case "Tj", "TJ", "Td", "TD", "T*", "Tm":
+				if inText {
+					sb.WriteByte(' ')
+				}
+			}
+			i += n
+		default:
+			i++
+		}
+	}
+}
+
+// pdfLiteralString decodes the PDF literal string at the start of b, e.g.
+// "(a\(b\))", and returns it with the number of bytes read.
+func pdfLiteralString(b []byte) ([]byte, int) {
+	var s []byte
+	depth := 0
+	for i := 0; i < len(b); i++ {
+		switch ch := b[i]; ch {
+		case '(':
+			if depth > 0 {
+				s = append(s, ch)
+			}
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s, i + 1
+			}
+			s = append(s, ch)
+		case '\\':
+			i++
+			if i >= len(b) {
+				return s, i
+			}
+			switch e := b[i]; e {
+			case 'n':
+				s = append(s, '\n')
+			case 'r':
+				s = append(s, '\r')
+			case 't':
+				s = append(s, '\t')
+			case 'b', 'f':
+				// Backspace and form feed are not useful in the extracted text.
+			case '\r', '\n':
+				// Line continuation.
+			default:
+				if e >= '0' && e <= '7' {
+					v, n := 0, 0
+					for n < 3 && i+n < len(b) && b[i+n] >= '0' && b[i+n] <= '7' {
+						v = v*8 + int(b[i+n]-'0')
+						n++
+					}
+					s = append(s, byte(v))
+					i += n - 1
+				} else {
+					s = append(s, e)
+				}
+			}
+		default:
+			s = append(s, ch)
+		}
+	}
+
+	return s, len(b)
+}
+
+// pdfHexString decodes the PDF hexadecimal string at the start of b, e.g.
+// "<48656C6C6F>", and returns it with the number of bytes read. NUL bytes
+// are dropped, so two byte encodings of ASCII text are readable.
+func pdfHexString(b []byte) ([]byte, int) {
+	end := bytes.IndexByte(b, '>')
+	if end < 0 {
+		return nil, len(b)
+	}
+
+	digits := bytes.Map(func(r rune) rune {
+		if strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return r
+		}
+		return -1
+	}, b[1:end])
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+
+	s, err := hex.DecodeString(string(digits))
+	if err != nil {
+		return nil, end + 1
+	}
+
+	return bytes.ReplaceAll(s, []byte{0}, nil), end + 1
+}
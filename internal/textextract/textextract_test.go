@@ -0,0 +1,180 @@
+package textextract_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/textextract"
+)
+
+// pdf returns a minimal PDF file with the given content streams, compressed
+// with FlateDecode if flate is true.
+func pdf(t *testing.T, flate bool, streams ...string) []byte {
+	t.Helper()
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n")
+	for i, s := range streams {
+		data := []byte(s)
+		dict := "<< /Length %d >>"
+		if flate {
+			var z bytes.Buffer
+			zw := zlib.NewWriter(&z)
+			_, err := zw.Write(data)
+			assert.NilError(t, err)
+			assert.NilError(t, zw.Close())
+			data = z.Bytes()
+			dict = "<< /Length %d /Filter /FlateDecode >>"
+		}
+
+		b.WriteString(string(rune('4'+i)) + " 0 obj\n")
+		b.WriteString(dict + "\nstream\n")
+		b.Write(data)
+		b.WriteString("\nendstream\nendobj\n")
+	}
+	b.WriteString("%%EOF\n")
+
+	return b.Bytes()
+}
+
+// office returns an Office Open XML package with the given parts.
+func office(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		assert.NilError(t, err)
+		_, err = w.Write([]byte(content))
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, zw.Close())
+
+	return b.Bytes()
+}
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name    string
+		file    string
+		data    []byte
+		limit   int64
+		want    string
+		wantErr error
+	}{
+		{
+			name: "extracts plain text",
+			file: "notes.TXT",
+			data: []byte("Call 604-555-0199\n"),
+			want: "Call 604-555-0199\n",
+		},
+		{
+			name: "extracts the text of compressed PDF content streams",
+			file: "letter.pdf",
+			data: pdf(t, true,
+				"BT /F1 12 Tf 72 700 Td (SIN: 046 454 286) Tj ET",
+				"BT [(j)20(ane)-300(doe\\(at\\)example.com)] TJ <0045006E0064> Tj ET",
+			),
+			want: " SIN: 046 454 286 \njane doe(at)example.com End \n",
+		},
+		{
+			name: "extracts the text of uncompressed PDF content streams",
+			file: "letter.pdf",
+			data: pdf(t, false, "q 1 0 0 1 0 0 cm Q BT (Line one) ' (\\124wo) Tj ET"),
+			want: "Line one Two \n",
+		},
+		{
+			name: "ignores text outside of PDF text objects",
+			file: "scan.pdf",
+			data: pdf(t, false, "(not shown) BT (shown) Tj ET"),
+			want: "shown \n",
+		},
+		{
+			name: "extracts the text of a DOCX file",
+			file: "memo.docx",
+			data: office(t, map[string]string{
+				"[Content_Types].xml":          `<Types/>`,
+				"word/_rels/document.xml.rels": `<Relationships>ignored</Relationships>`,
+				"word/document.xml": `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+					`<w:body><w:p><w:r><w:t>PHN 9876</w:t></w:r><w:r><w:t xml:space="preserve"> 543 210</w:t></w:r></w:p>` +
+					`<w:p><w:r><w:t>Second</w:t></w:r></w:p></w:body></w:document>`,
+			}),
+			want: "PHN 9876 543 210\nSecond\n",
+		},
+		{
+			name: "extracts the text of an XLSX file",
+			file: "list.xlsx",
+			data: office(t, map[string]string{
+				"xl/sharedStrings.xml":     `<sst><si><t>Name</t></si><si><t>Email</t></si></sst>`,
+				"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row><c><v>0</v></c><c><v>1</v></c></row></sheetData></worksheet>`,
+			}),
+			want: "Name\nEmail\n0\n1\n\n",
+		},
+		{
+			name:    "errors when the plain text exceeds the limit",
+			file:    "notes.txt",
+			data:    []byte("Call 604-555-0199\n"),
+			limit:   10,
+			wantErr: textextract.ErrLimit,
+		},
+		{
+			name:    "errors when a decompressed PDF stream exceeds the limit",
+			file:    "letter.pdf",
+			data:    pdf(t, true, "BT ("+strings.Repeat("a", 4096)+") Tj ET"),
+			limit:   1024,
+			wantErr: textextract.ErrLimit,
+		},
+		{
+			name: "errors when the decompressed Office parts exceed the limit",
+			file: "memo.docx",
+			data: office(t, map[string]string{
+				"word/document.xml": "<w:document><w:t>" + strings.Repeat("a", 2048) + "</w:t></w:document>",
+				"word/footer1.xml":  "<w:ftr><w:t>" + strings.Repeat("b", 2048) + "</w:t></w:ftr>",
+			}),
+			limit:   3072,
+			wantErr: textextract.ErrLimit,
+		},
+		{
+			name:    "errors when the file type is not supported",
+			file:    "photo.jpg",
+			data:    []byte{0xff, 0xd8, 0xff},
+			wantErr: textextract.ErrUnsupported,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, textextract.Supported(tc.file), tc.wantErr != textextract.ErrUnsupported)
+
+			limit := tc.limit
+			if limit == 0 {
+				limit = 1 << 20
+			}
+
+			got, err := textextract.Extract(tc.file, bytes.NewReader(tc.data), int64(len(tc.data)), limit)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Equal(t, got, tc.want)
+		})
+	}
+}
+
+func TestExtract_InvalidOfficeFile(t *testing.T) {
+	t.Parallel()
+
+	data := []byte("not a zip file")
+	_, err := textextract.Extract("memo.docx", bytes.NewReader(data), int64(len(data)), 1<<20)
+	assert.ErrorContains(t, err, "open package: zip: not a valid zip file")
+}
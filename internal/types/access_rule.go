@@ -0,0 +1,132 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// AtoM description publication statuses.
+const (
+	PublicationStatusDraft     string = "draft"
+	PublicationStatusPublished string = "published"
+)
+
+// AccessRule maps a combination of the ContainerMetadata.xml access fields to
+// the access conditions, publication status and restriction note of the AtoM
+// description. A rule matches a container when all its conditions match, and
+// an unset condition matches any value, so a rule without conditions matches
+// every container.
+type AccessRule struct {
+	// Name identifies the rule, e.g. "Personal information bank" (optional).
+	Name string
+
+	// PersonalInformationBank matches the PersonalInformationBank field value
+	// if set.
+	PersonalInformationBank *bool
+
+	// HasHolds matches the HasHolds field value if set.
+	HasHolds *bool
+
+	// Security matches if the Security field is one of the listed values,
+	// compared case-insensitively. Values can be path.Match patterns, e.g.
+	// "Protected *".
+	Security []string
+
+	// AccessControl matches if the AccessControl field is one of the listed
+	// values, as for Security.
+	AccessControl []string
+
+	// AccessConditions is the AtoM access conditions text of the matching
+	// containers (optional).
+	AccessConditions string
+
+	// PublicationStatus is the AtoM publication status of the matching
+	// containers: "draft" or "published" (optional).
+	PublicationStatus string
+
+	// RestrictionNote is a note about the access restrictions of the matching
+	// containers (optional).
+	RestrictionNote string
+}
+
+// Validate returns an error describing every problem of the rule.
+func (r AccessRule) Validate() error {
+	var errs error
+	for _, f := range []struct {
+		name     string
+		patterns []string
+	}{
+		{"Security", r.Security},
+		{"AccessControl", r.AccessControl},
+	} {
+		for i, p := range f.patterns {
+			if _, err := path.Match(p, ""); err != nil {
+				errs = errors.Join(errs, fmt.Errorf("%s[%d]: invalid pattern %q: %v", f.name, i, p, err))
+			}
+		}
+	}
+
+	statuses := []string{"", PublicationStatusDraft, PublicationStatusPublished}
+	if !slices.Contains(statuses, r.PublicationStatus) {
+		errs = errors.Join(errs, fmt.Errorf(
+			"PublicationStatus: unknown value %q, must be %q or %q",
+			r.PublicationStatus, PublicationStatusDraft, PublicationStatusPublished,
+		))
+	}
+
+	if r.AccessConditions == "" && r.PublicationStatus == "" && r.RestrictionNote == "" {
+		errs = errors.Join(errs, errors.New(
+			"one of AccessConditions, PublicationStatus or RestrictionNote must be set",
+		))
+	}
+
+	return errs
+}
+
+// MatchesAccessRule reports whether the container matches all the conditions
+// of rule r.
+func (md ContainerMD) MatchesAccessRule(r AccessRule) bool {
+	c := md.Container
+	if r.PersonalInformationBank != nil && *r.PersonalInformationBank != c.PersonalInformationBank {
+		return false
+	}
+	if r.HasHolds != nil && *r.HasHolds != c.HasHolds {
+		return false
+	}
+	if len(r.Security) > 0 && !matchAny(r.Security, c.Security) {
+		return false
+	}
+	if len(r.AccessControl) > 0 && !matchAny(r.AccessControl, c.AccessControl) {
+		return false
+	}
+
+	return true
+}
+
+// MatchAccessRule returns the first of rules matched by the container, and
+// false if none match.
+func (md ContainerMD) MatchAccessRule(rules []AccessRule) (AccessRule, bool) {
+	for _, r := range rules {
+		if md.MatchesAccessRule(r) {
+			return r, true
+		}
+	}
+
+	return AccessRule{}, false
+}
+
+// matchAny reports whether value matches one of the case-insensitive
+// path.Match patterns. Invalid patterns don't match.
+func matchAny(patterns []string, value string) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), value); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,122 @@
+package types_test
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/types"
+)
+
+func TestMatchesAccessRule(t *testing.T) {
+	t.Parallel()
+
+	yes, no := true, false
+	md := types.ContainerMD{
+		Container: types.ContainerMDRecord{
+			AccessControl:           "Department only",
+			Security:                "Protected B",
+			PersonalInformationBank: true,
+		},
+	}
+
+	for _, tc := range []struct {
+		name string
+		rule types.AccessRule
+		want bool
+	}{
+		{
+			name: "matches any container when the rule has no conditions",
+			rule: types.AccessRule{AccessConditions: "Open"},
+			want: true,
+		},
+		{
+			name: "matches when all the conditions match",
+			rule: types.AccessRule{
+				PersonalInformationBank: &yes,
+				HasHolds:                &no,
+				Security:                []string{"Unclassified", "protected b"},
+				AccessControl:           []string{"Department *"},
+			},
+			want: true,
+		},
+		{
+			name: "doesn't match a different PersonalInformationBank value",
+			rule: types.AccessRule{PersonalInformationBank: &no},
+		},
+		{
+			name: "doesn't match a different HasHolds value",
+			rule: types.AccessRule{HasHolds: &yes},
+		},
+		{
+			name: "doesn't match an unlisted Security value",
+			rule: types.AccessRule{Security: []string{"Unclassified", "Protected A"}},
+		},
+		{
+			name: "doesn't match an unlisted AccessControl value",
+			rule: types.AccessRule{
+				Security:      []string{"Protected *"},
+				AccessControl: []string{"Public"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.want, md.MatchesAccessRule(tc.rule))
+		})
+	}
+}
+
+func TestMatchAccessRule(t *testing.T) {
+	t.Parallel()
+
+	yes := true
+	rules := []types.AccessRule{
+		{Name: "Holds", HasHolds: &yes, PublicationStatus: "draft"},
+		{Name: "PIB", PersonalInformationBank: &yes, AccessConditions: "Restricted"},
+		{Name: "Protected", Security: []string{"Protected *"}, AccessConditions: "Closed"},
+	}
+
+	t.Run("returns the first matching rule", func(t *testing.T) {
+		t.Parallel()
+
+		md := types.ContainerMD{
+			Container: types.ContainerMDRecord{
+				PersonalInformationBank: true,
+				Security:                "Protected A",
+			},
+		}
+		got, ok := md.MatchAccessRule(rules)
+		assert.Assert(t, ok)
+		assert.Equal(t, "PIB", got.Name)
+	})
+
+	t.Run("returns false when no rule matches", func(t *testing.T) {
+		t.Parallel()
+
+		md := types.ContainerMD{Container: types.ContainerMDRecord{Security: "Unclassified"}}
+		got, ok := md.MatchAccessRule(rules)
+		assert.Assert(t, !ok)
+		assert.Equal(t, "", got.Name)
+	})
+}
+
+func TestAccessRule_Validate(t *testing.T) {
+	t.Parallel()
+
+	assert.NilError(t, types.AccessRule{PublicationStatus: "published"}.Validate())
+	assert.Error(t,
+		types.AccessRule{
+			Security:          []string{"Protected [A"},
+			AccessControl:     []string{"Public", "["},
+			PublicationStatus: "public",
+		}.Validate(),
+		`Security[0]: invalid pattern "Protected [A": syntax error in pattern`+"\n"+
+			`AccessControl[1]: invalid pattern "[": syntax error in pattern`+"\n"+
+			`PublicationStatus: unknown value "public", must be "draft" or "published"`,
+	)
+	assert.Error(t,
+		types.AccessRule{Name: "Empty"}.Validate(),
+		"one of AccessConditions, PublicationStatus or RestrictionNote must be set",
+	)
+}
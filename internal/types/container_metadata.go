@@ -2,7 +2,10 @@ package types
 
 import (
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+	"reflect"
 	"strings"
 	"time"
 
@@ -23,50 +26,101 @@ type ContainerMD struct {
 // ContainerMDRecord holds the individual metadata fields for a VanDocs
 // container.
 type ContainerMDRecord struct {
-	AccessControl               string    `xml:"AccessControl"`
-	AccessionNumber             int       `xml:"AccessionNumber"`
-	AllVersions                 string    `xml:"AllVersions"`
-	Assignee                    string    `xml:"Assignee"`
-	AssigneeDate                time.Time `xml:"AssigneeDate"`
-	Classification              string    `xml:"Classification"`
-	Consignment                 string    `xml:"Consignment"`
-	ContainedRecords            string    `xml:"ContainedRecords"`
-	Creator                     string    `xml:"Creator"`
-	DateClosed                  time.Time `xml:"DateClosed"`
-	DateCreated                 time.Time `xml:"DateCreated"`
-	DateDeclaredAsFinal         time.Time `xml:"DateDeclaredAsFinal"`
-	DateDueforDestruction       time.Time `xml:"DateDueforDestruction"`
-	DateDueforInactive          time.Time `xml:"DateDueforInactive"`
-	DateDueforPermanentArchival time.Time `xml:"DateDueforPermanentArchival"`
-	DateInactive                time.Time `xml:"DateInactive"`
-	DateLastUpdated             time.Time `xml:"DateLastUpdated"`
-	DateRegistered              time.Time `xml:"DateRegistered"`
-	DatePublished               time.Time `xml:"DatePublished"`
-	Department                  string    `xml:"Department"`
-	Disposition                 string    `xml:"Disposition"`
-	ExpandedNumber              string    `xml:"ExpandedNumber"`
-	ExternalID                  string    `xml:"ExternalID"`
-	FullClassificationNumber    string    `xml:"FullClassificationNumber"`
-	HomeLocation                string    `xml:"HomeLocation"`
-	IsContainer                 bool      `xml:"IsContainer"`
-	IsElectronic                bool      `xml:"IsElectronic"`
-	HasHolds                    bool      `xml:"HasHolds"`
-	Notes                       string    `xml:"Notes"`
-	OPR                         string    `xml:"OPR"`
-	Owner                       string    `xml:"Owner"`
-	OwnerLocationType           string    `xml:"OwnerLocationType"`
-	PaperFolderExists           bool      `xml:"PaperFolderExists"`
-	PersonalInformationBank     bool      `xml:"PersonalInformationBank"`
-	RecordClass                 string    `xml:"RecordClass"`
-	RecordNumber                string    `xml:"RecordNumber"`
-	RecordType                  string    `xml:"RecordType"`
-	RelatedRecords              string    `xml:"RelatedRecords"`
-	RetentionSchedule           string    `xml:"RetentionSchedule"`
-	Security                    string    `xml:"Security"`
-	Title                       string    `xml:"Title"`
-	TitleFreeTextPart           string    `xml:"TitleFreeTextPart"`
-	TitleStructuredPart         string    `xml:"TitleStructuredPart"`
-	UniqueIdentifier            int64     `xml:"UniqueIdentifier"`
+	AccessControl               string `xml:"AccessControl"`
+	AccessionNumber             int    `xml:"AccessionNumber"`
+	AllVersions                 string `xml:"AllVersions"`
+	Assignee                    string `xml:"Assignee"`
+	AssigneeDate                Date   `xml:"AssigneeDate"`
+	Classification              string `xml:"Classification"`
+	Consignment                 string `xml:"Consignment"`
+	ContainedRecords            string `xml:"ContainedRecords"`
+	Creator                     string `xml:"Creator"`
+	DateClosed                  Date   `xml:"DateClosed"`
+	DateCreated                 Date   `xml:"DateCreated"`
+	DateDeclaredAsFinal         Date   `xml:"DateDeclaredAsFinal"`
+	DateDueforDestruction       Date   `xml:"DateDueforDestruction"`
+	DateDueforInactive          Date   `xml:"DateDueforInactive"`
+	DateDueforPermanentArchival Date   `xml:"DateDueforPermanentArchival"`
+	DateInactive                Date   `xml:"DateInactive"`
+	DateLastUpdated             Date   `xml:"DateLastUpdated"`
+	DateRegistered              Date   `xml:"DateRegistered"`
+	DatePublished               Date   `xml:"DatePublished"`
+	Department                  string `xml:"Department"`
+	Disposition                 string `xml:"Disposition"`
+	ExpandedNumber              string `xml:"ExpandedNumber"`
+	ExternalID                  string `xml:"ExternalID"`
+	FullClassificationNumber    string `xml:"FullClassificationNumber"`
+	HomeLocation                string `xml:"HomeLocation"`
+	IsContainer                 bool   `xml:"IsContainer"`
+	IsElectronic                bool   `xml:"IsElectronic"`
+	HasHolds                    bool   `xml:"HasHolds"`
+	Notes                       string `xml:"Notes"`
+	OPR                         string `xml:"OPR"`
+	Owner                       string `xml:"Owner"`
+	OwnerLocationType           string `xml:"OwnerLocationType"`
+	PaperFolderExists           bool   `xml:"PaperFolderExists"`
+	PersonalInformationBank     bool   `xml:"PersonalInformationBank"`
+	RecordClass                 string `xml:"RecordClass"`
+	RecordNumber                string `xml:"RecordNumber"`
+	RecordType                  string `xml:"RecordType"`
+	RelatedRecords              string `xml:"RelatedRecords"`
+	RetentionSchedule           string `xml:"RetentionSchedule"`
+	Security                    string `xml:"Security"`
+	Title                       string `xml:"Title"`
+	TitleFreeTextPart           string `xml:"TitleFreeTextPart"`
+	TitleStructuredPart         string `xml:"TitleStructuredPart"`
+	UniqueIdentifier            int64  `xml:"UniqueIdentifier"`
+}
+
+// ParseContainerMD decodes the ContainerMetadata.xml document read from r.
+//
+// Date values without a time zone offset are interpreted in loc (see
+// ParseDate). If any date can't be parsed an error naming every offending
+// field is returned.
+func ParseContainerMD(r io.Reader, loc *time.Location) (*ContainerMD, error) {
+	var md ContainerMD
+	if err := xml.NewDecoder(r).Decode(&md); err != nil {
+		return nil, fmt.Errorf("decode XML: %w", err)
+	}
+
+	v := reflect.ValueOf(&md.Container).Elem()
+	var errs error
+	for i := range v.NumField() {
+		d, ok := v.Field(i).Addr().Interface().(*Date)
+		if !ok {
+			continue
+		}
+		if err := d.parse(loc); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("%s: %w", v.Type().Field(i).Name, err))
+		}
+	}
+	if errs != nil {
+		return nil, fmt.Errorf("parse dates: %w", errs)
+	}
+
+	return &md, nil
+}
+
+// FieldValue returns the string representation of the ContainerMetadata.xml
+// field with the given XML element name (e.g. "RecordNumber"). Dates are
+// formatted as "YYYY-MM-DD", or an empty string if zero. The second return
+// value reports whether name is a known field.
+func (md ContainerMD) FieldValue(name string) (string, bool) {
+	f, ok := containerMDFields[name]
+	if !ok {
+		return "", false
+	}
+
+	v := reflect.ValueOf(md.Container).FieldByIndex(f.Index)
+	switch v := v.Interface().(type) {
+	case Date:
+		if v.IsZero() {
+			return "", true
+		}
+		return v.Format(eventDateFmt), true
+	default:
+		return fmt.Sprint(v), true
+	}
 }
 
 // Acquisition maps the Consignment field to the acquisition column of the Batch
@@ -100,9 +154,22 @@ func (md ContainerMD) AlternativeIdentifiers(aipID uuid.UUID) (ids, labels []str
 func (md ContainerMD) CreationEvent() Event {
 	return Event{
 		Type:  enums.EventTypeCreation,
-		Start: md.Container.DateRegistered,
-		End:   md.Container.DateClosed,
+		Start: md.Container.DateRegistered.Time,
+		End:   md.Container.DateClosed.Time,
+	}
+}
+
+// Events returns the non-zero Creation and Recordkeeping events, in that
+// order.
+func (md ContainerMD) Events() []Event {
+	events := make([]Event, 0, 2)
+	for _, e := range []Event{md.CreationEvent(), md.RecordkeepingEvent()} {
+		if !e.IsZero() {
+			events = append(events, e)
+		}
 	}
+
+	return events
 }
 
 // Identifier maps the RecordNumber field to the identifier CSVcolumn.
@@ -142,6 +209,17 @@ func (md ContainerMD) RecordkeepingEvent() Event {
 	}
 }
 
+// SourceOrganization returns the organization that transferred the records:
+// the Department field, or the OPR (office of primary responsibility) field if
+// Department is empty.
+func (md ContainerMD) SourceOrganization() string {
+	if md.Container.Department != "" {
+		return md.Container.Department
+	}
+
+	return md.Container.OPR
+}
+
 // Title maps the TitleFreeTextPart field to the title column.
 func (md ContainerMD) Title() string {
 	return md.Container.TitleFreeTextPart
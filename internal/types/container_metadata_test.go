@@ -125,8 +125,8 @@ func TestCreationDate(t *testing.T) {
 			name: "returns a creation event",
 			md: types.ContainerMD{
 				Container: types.ContainerMDRecord{
-					DateRegistered: time.Date(2020, 3, 15, 0, 0, 0, 0, time.UTC),
-					DateClosed:     time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+					DateRegistered: types.NewDate(time.Date(2020, 3, 15, 0, 0, 0, 0, time.UTC)),
+					DateClosed:     types.NewDate(time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)),
 				},
 			},
 			want: types.Event{
@@ -143,6 +143,46 @@ func TestCreationDate(t *testing.T) {
 	}
 }
 
+func TestEvents(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		md   types.ContainerMD
+		want []types.Event
+	}{
+		{
+			name: "returns no events when ContainerMD is empty",
+			md:   types.ContainerMD{},
+			want: []types.Event{},
+		},
+		{
+			name: "returns creation and recordkeeping events",
+			md: types.ContainerMD{
+				Container: types.ContainerMDRecord{
+					DateRegistered: types.NewDate(time.Date(2020, 3, 15, 0, 0, 0, 0, time.UTC)),
+					HomeLocation:   "City Clerk's Office",
+				},
+			},
+			want: []types.Event{
+				{
+					Type:  enums.EventTypeCreation,
+					Start: time.Date(2020, 3, 15, 0, 0, 0, 0, time.UTC),
+				},
+				{
+					Type:  enums.EventTypeRecordkeeping,
+					Actor: "City Clerk's Office",
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.DeepEqual(t, tc.want, tc.md.Events())
+		})
+	}
+}
+
 func TestIdentifier(t *testing.T) {
 	t.Parallel()
 
@@ -262,6 +302,33 @@ func TestQubitParentSlug(t *testing.T) {
 	}
 }
 
+func TestSourceOrganization(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Returns the department", func(t *testing.T) {
+		t.Parallel()
+
+		md := types.ContainerMD{
+			Container: types.ContainerMDRecord{
+				Department: "Engineering Services",
+				OPR:        "COV - Office of Custody (OPR)",
+			},
+		}
+		assert.Equal(t, "Engineering Services", md.SourceOrganization())
+	})
+
+	t.Run("Returns the OPR if the department is empty", func(t *testing.T) {
+		t.Parallel()
+
+		md := types.ContainerMD{
+			Container: types.ContainerMDRecord{
+				OPR: "COV - Office of Custody (OPR)",
+			},
+		}
+		assert.Equal(t, "COV - Office of Custody (OPR)", md.SourceOrganization())
+	})
+}
+
 func TestTitle(t *testing.T) {
 	t.Parallel()
 
@@ -276,3 +343,38 @@ func TestTitle(t *testing.T) {
 		assert.Equal(t, "Test Title", md.Title())
 	})
 }
+
+func TestFieldValue(t *testing.T) {
+	t.Parallel()
+
+	md := types.ContainerMD{
+		Container: types.ContainerMDRecord{
+			AccessionNumber: 12,
+			DateRegistered:  types.NewDate(time.Date(2020, 3, 15, 10, 0, 0, 0, time.UTC)),
+			HasHolds:        true,
+			RecordNumber:    "01-1000-30/0000007",
+		},
+	}
+
+	for _, tc := range []struct {
+		name   string
+		field  string
+		want   string
+		wantOK bool
+	}{
+		{name: "returns a string field", field: "RecordNumber", want: "01-1000-30/0000007", wantOK: true},
+		{name: "returns an integer field", field: "AccessionNumber", want: "12", wantOK: true},
+		{name: "returns a boolean field", field: "HasHolds", want: "true", wantOK: true},
+		{name: "returns a formatted date field", field: "DateRegistered", want: "2020-03-15", wantOK: true},
+		{name: "returns an empty string for a zero date", field: "DateClosed", want: "", wantOK: true},
+		{name: "returns false for an unknown field", field: "Colour", want: "", wantOK: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := md.FieldValue(tc.field)
+			assert.Equal(t, got, tc.want)
+			assert.Equal(t, ok, tc.wantOK)
+		})
+	}
+}
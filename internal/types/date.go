@@ -0,0 +1,88 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// zonedDateLayouts are the date layouts that include a time zone offset.
+var zonedDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+}
+
+// localDateLayouts are the date layouts without a time zone offset, which are
+// interpreted in the configured VanDocs time zone.
+var localDateLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+}
+
+// Date is a date value from a VanDocs ContainerMetadata.xml file.
+//
+// VanDocs exports dates in a variety of formats, so the XML text is only
+// stored when the document is decoded, and parsed afterwards by
+// ParseContainerMD using the VanDocs time zone for values without a time zone
+// offset. An empty element is parsed as the zero time.
+type Date struct {
+	time.Time
+
+	// raw is the XML text value pending parsing.
+	raw string
+}
+
+// NewDate returns a Date for t.
+func NewDate(t time.Time) Date {
+	return Date{Time: t}
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler by storing the text value
+// for parsing.
+func (d *Date) UnmarshalText(text []byte) error {
+	d.Time = time.Time{}
+	d.raw = strings.TrimSpace(string(text))
+	return nil
+}
+
+// parse parses the raw text value of d, interpreting values without a time
+// zone offset in loc.
+func (d *Date) parse(loc *time.Location) error {
+	t, err := ParseDate(d.raw, loc)
+	if err != nil {
+		return err
+	}
+	d.Time = t
+	d.raw = ""
+
+	return nil
+}
+
+// ParseDate parses a VanDocs date value. Values with a time zone offset (e.g.
+// "2019-03-04T10:22:00Z") are parsed as is, while date-only and local
+// timestamp values (e.g. "2019-03-04" or "2019-03-04 10:22:00") are
+// interpreted in loc. An empty value returns the zero time.
+func ParseDate(s string, loc *time.Location) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range zonedDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range localDateLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%q is not a valid date", s)
+}
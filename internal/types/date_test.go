@@ -0,0 +1,142 @@
+package types_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/types"
+)
+
+func TestParseDate(t *testing.T) {
+	t.Parallel()
+
+	vancouver, err := time.LoadLocation("America/Vancouver")
+	assert.NilError(t, err)
+
+	for _, tc := range []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr string
+	}{
+		{
+			name:  "returns the zero time for an empty value",
+			value: "",
+		},
+		{
+			name:  "parses an RFC 3339 timestamp",
+			value: "2019-03-04T10:22:00Z",
+			want:  time.Date(2019, 3, 4, 10, 22, 0, 0, time.UTC),
+		},
+		{
+			name:  "parses an RFC 3339 timestamp with an offset and fractional seconds",
+			value: "2019-03-04T10:22:00.5-08:00",
+			want:  time.Date(2019, 3, 4, 18, 22, 0, 500_000_000, time.UTC),
+		},
+		{
+			name:  "parses a timestamp with a space separator and an offset",
+			value: "2019-03-04 10:22:00 -0800",
+			want:  time.Date(2019, 3, 4, 18, 22, 0, 0, time.UTC),
+		},
+		{
+			name:  "parses a local timestamp in the given time zone",
+			value: "2019-03-04 10:22:00",
+			want:  time.Date(2019, 3, 4, 10, 22, 0, 0, vancouver),
+		},
+		{
+			name:  "parses a local timestamp with a T separator",
+			value: "2019-03-04T10:22:00",
+			want:  time.Date(2019, 3, 4, 10, 22, 0, 0, vancouver),
+		},
+		{
+			name:  "parses a local timestamp without seconds",
+			value: "2019-03-04 10:22",
+			want:  time.Date(2019, 3, 4, 10, 22, 0, 0, vancouver),
+		},
+		{
+			name:  "parses a date-only value",
+			value: "2019-03-04",
+			want:  time.Date(2019, 3, 4, 0, 0, 0, 0, vancouver),
+		},
+		{
+			name:  "parses a date-only value with slashes",
+			value: "2019/03/04",
+			want:  time.Date(2019, 3, 4, 0, 0, 0, 0, vancouver),
+		},
+		{
+			name:    "errors on an unparseable value",
+			value:   "04/03/2019",
+			wantErr: `"04/03/2019" is not a valid date`,
+		},
+		{
+			name:    "errors on an invalid date",
+			value:   "2019-02-30",
+			wantErr: `"2019-02-30" is not a valid date`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := types.ParseDate(tc.value, vancouver)
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Assert(t, got.Equal(tc.want), "got %s, want %s", got, tc.want)
+		})
+	}
+}
+
+func TestParseContainerMD(t *testing.T) {
+	t.Parallel()
+
+	vancouver, err := time.LoadLocation("America/Vancouver")
+	assert.NilError(t, err)
+
+	t.Run("parses VanDocs dates", func(t *testing.T) {
+		t.Parallel()
+
+		md, err := types.ParseContainerMD(strings.NewReader(`<ContainerMetadata>
+  <Container>
+    <DateClosed/>
+    <DateCreated>2019-03-04 10:22:00</DateCreated>
+    <DateRegistered>2009-01-15</DateRegistered>
+    <DatePublished>2020-01-01T08:00:00Z</DatePublished>
+    <RecordNumber>01-5000-12/2009-01</RecordNumber>
+  </Container>
+</ContainerMetadata>`), vancouver)
+		assert.NilError(t, err)
+
+		assert.Assert(t, md.Container.DateClosed.IsZero())
+		assert.Assert(t, md.Container.DateCreated.Equal(time.Date(2019, 3, 4, 10, 22, 0, 0, vancouver)))
+		assert.Assert(t, md.Container.DateRegistered.Equal(time.Date(2009, 1, 15, 0, 0, 0, 0, vancouver)))
+		assert.Assert(t, md.Container.DatePublished.Equal(time.Date(2020, 1, 1, 8, 0, 0, 0, time.UTC)))
+		assert.Equal(t, md.Container.RecordNumber, "01-5000-12/2009-01")
+	})
+
+	t.Run("errors naming every unparseable date field", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := types.ParseContainerMD(strings.NewReader(`<ContainerMetadata>
+  <Container>
+    <DateClosed>soon</DateClosed>
+    <DateRegistered>2009-01-15</DateRegistered>
+    <DatePublished>2019-02-30</DatePublished>
+  </Container>
+</ContainerMetadata>`), vancouver)
+		assert.Error(t, err, `parse dates: DateClosed: "soon" is not a valid date
+DatePublished: "2019-02-30" is not a valid date`)
+	})
+
+	t.Run("errors on malformed XML", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := types.ParseContainerMD(strings.NewReader("<ContainerMetadata>"), vancouver)
+		assert.Error(t, err, "decode XML: XML syntax error on line 1: unexpected EOF")
+	})
+}
@@ -0,0 +1,123 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/enums"
+)
+
+// EventRule derives an AtoM event from ContainerMetadata.xml fields, e.g. an
+// Accumulation event from the DateCreated and DateLastUpdated fields.
+type EventRule struct {
+	// Type is the AtoM event type (required).
+	Type enums.EventType
+
+	// Start is the name of the date field holding the event start date, e.g.
+	// "DateCreated" (optional).
+	Start string
+
+	// End is the name of the date field holding the event end date, e.g.
+	// "DateLastUpdated" (optional).
+	End string
+
+	// Actor lists the names of the text fields holding the event actor, in
+	// order of preference: the first field with a value is used, e.g.
+	// ["Creator", "OPR"] (optional).
+	Actor []string
+}
+
+// Validate returns an error describing every problem of the rule.
+func (r EventRule) Validate() error {
+	var errs error
+	if r.Type == "" {
+		errs = errors.Join(errs, errors.New("Type: missing required value"))
+	} else if !r.Type.IsValid() {
+		errs = errors.Join(errs, fmt.Errorf(
+			"Type: unknown event type %q, must be one of %s",
+			r.Type, strings.Join(enums.EventTypeNames(), ", "),
+		))
+	}
+
+	for _, f := range []struct{ name, field string }{{"Start", r.Start}, {"End", r.End}} {
+		if f.field != "" && !isFieldOfType[Date](f.field) {
+			errs = errors.Join(errs, fmt.Errorf("%s: %q is not a date field", f.name, f.field))
+		}
+	}
+	for i, field := range r.Actor {
+		if !isFieldOfType[string](field) {
+			errs = errors.Join(errs, fmt.Errorf("Actor[%d]: %q is not a text field", i, field))
+		}
+	}
+
+	if r.Start == "" && r.End == "" && len(r.Actor) == 0 {
+		errs = errors.Join(errs, errors.New("one of Start, End or Actor must be set"))
+	}
+
+	return errs
+}
+
+// isFieldOfType reports whether name is a ContainerMetadata.xml field of type
+// T.
+func isFieldOfType[T any](name string) bool {
+	f, ok := containerMDFields[name]
+	return ok && f.Type == reflect.TypeFor[T]()
+}
+
+// Event returns the event derived from the container by rule r. Unknown
+// fields are ignored.
+func (md ContainerMD) Event(r EventRule) Event {
+	e := Event{
+		Type:  r.Type,
+		Start: md.dateField(r.Start).Time,
+		End:   md.dateField(r.End).Time,
+	}
+	for _, name := range r.Actor {
+		if v := strings.TrimSpace(md.textField(name)); v != "" {
+			e.Actor = v
+			break
+		}
+	}
+
+	return e
+}
+
+// DeriveEvents returns the non-zero events derived from the container by
+// rules, in rule order. If rules is empty the default Creation and
+// Recordkeeping events are returned (see Events).
+func (md ContainerMD) DeriveEvents(rules []EventRule) []Event {
+	if len(rules) == 0 {
+		return md.Events()
+	}
+
+	events := make([]Event, 0, len(rules))
+	for _, r := range rules {
+		if e := md.Event(r); !e.IsZero() {
+			events = append(events, e)
+		}
+	}
+
+	return events
+}
+
+// dateField returns the value of the named date field, or a zero Date if name
+// is not a date field.
+func (md ContainerMD) dateField(name string) Date {
+	if !isFieldOfType[Date](name) {
+		return Date{}
+	}
+
+	return reflect.ValueOf(md.Container).FieldByIndex(containerMDFields[name].Index).Interface().(Date)
+}
+
+// textField returns the value of the named text field, or an empty string if
+// name is not a text field.
+func (md ContainerMD) textField(name string) string {
+	if !isFieldOfType[string](name) {
+		return ""
+	}
+
+	return reflect.ValueOf(md.Container).FieldByIndex(containerMDFields[name].Index).String()
+}
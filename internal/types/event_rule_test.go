@@ -0,0 +1,87 @@
+package types_test
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/enums"
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/types"
+)
+
+func TestDeriveEvents(t *testing.T) {
+	t.Parallel()
+
+	date := func(year int) types.Date {
+		return types.Date{Time: time.Date(year, 1, 2, 0, 0, 0, 0, time.UTC)}
+	}
+	md := types.ContainerMD{
+		Container: types.ContainerMDRecord{
+			DateRegistered:  date(2001),
+			DateClosed:      date(2002),
+			DateCreated:     date(2003),
+			DateLastUpdated: date(2004),
+			HomeLocation:    "City Clerk's Office",
+			Owner:           " ",
+			OPR:             "Finance (FIN)",
+		},
+	}
+
+	for _, tc := range []struct {
+		name  string
+		rules []types.EventRule
+		want  []types.Event
+	}{
+		{
+			name: "returns the Creation and Recordkeeping events by default",
+			want: []types.Event{
+				{Type: enums.EventTypeCreation, Start: date(2001).Time, End: date(2002).Time},
+				{Type: enums.EventTypeRecordkeeping, Actor: "City Clerk's Office"},
+			},
+		},
+		{
+			name: "returns the non-zero events derived by the rules",
+			rules: []types.EventRule{
+				{
+					Type:  enums.EventTypeAccumulation,
+					Start: "DateCreated",
+					End:   "DateLastUpdated",
+					Actor: []string{"Creator", "Owner", "OPR"},
+				},
+				{Type: enums.EventTypePublication, Start: "DatePublished"},
+				{Type: enums.EventTypeCreation, End: "DateClosed", Actor: []string{"Department"}},
+			},
+			want: []types.Event{
+				{
+					Type:  enums.EventTypeAccumulation,
+					Start: date(2003).Time,
+					End:   date(2004).Time,
+					Actor: "Finance (FIN)",
+				},
+				{Type: enums.EventTypeCreation, End: date(2002).Time},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.DeepEqual(t, tc.want, md.DeriveEvents(tc.rules))
+		})
+	}
+}
+
+func TestEventRule_Validate(t *testing.T) {
+	t.Parallel()
+
+	assert.NilError(t, types.EventRule{
+		Type:  enums.EventTypePublication,
+		Start: "DatePublished",
+		Actor: []string{"Creator", "OPR"},
+	}.Validate())
+	assert.Error(t,
+		types.EventRule{End: "Consignment", Actor: []string{"Colour"}}.Validate(),
+		"Type: missing required value\n"+
+			`End: "Consignment" is not a date field`+"\n"+
+			`Actor[0]: "Colour" is not a text field`,
+	)
+}
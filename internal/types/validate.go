@@ -0,0 +1,186 @@
+package types
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRequiredFields lists the ContainerMetadata.xml fields that must have
+// a value for a SIP to be described in AtoM.
+var DefaultRequiredFields = []string{
+	"RecordNumber",
+	"Classification",
+	"TitleFreeTextPart",
+	"DateRegistered",
+}
+
+// containerMDFields maps the XML element name of each ContainerMDRecord field
+// to the field.
+var containerMDFields = func() map[string]reflect.StructField {
+	t := reflect.TypeFor[ContainerMDRecord]()
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("xml"), ",")
+		fields[name] = f
+	}
+	return fields
+}()
+
+// ContainerMDFields returns the names of all the known ContainerMetadata.xml
+// <Container> child elements in alphabetical order.
+func ContainerMDFields() []string {
+	names := make([]string, 0, len(containerMDFields))
+	for name := range containerMDFields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// IsContainerMDField reports whether name is a known ContainerMetadata.xml
+// <Container> child element.
+func IsContainerMDField(name string) bool {
+	_, ok := containerMDFields[name]
+	return ok
+}
+
+// ValidateContainerMD checks the ContainerMetadata.xml document read from r
+// and returns a list of all the problems found:
+//
+//   - the document must be well-formed XML with a <ContainerMetadata> root
+//     element wrapping a single <Container> element;
+//   - every <Container> child element must be a known field;
+//   - every field listed in required must be present and not empty;
+//   - every value must be parseable as the type of its field.
+//
+// An error is only returned if r can't be read.
+func ValidateContainerMD(r io.Reader, required []string) ([]string, error) {
+	values, failures, err := readContainerMDValues(r)
+	if err != nil || values == nil {
+		return failures, err
+	}
+
+	for _, name := range required {
+		if v, ok := values[name]; !ok || v == "" {
+			failures = append(failures, fmt.Sprintf("Missing required field: %q", name))
+		}
+	}
+
+	for _, name := range ContainerMDFields() {
+		v, ok := values[name]
+		if !ok {
+			continue
+		}
+		if err := checkFieldValue(containerMDFields[name].Type, v); err != nil {
+			failures = append(failures, fmt.Sprintf("Invalid value for field %q: %v", name, err))
+		}
+	}
+
+	return failures, nil
+}
+
+// readContainerMDValues reads the raw text value of each <Container> child
+// element. Structural problems are returned as failures; a nil values map
+// means the document could not be read far enough to check its fields.
+func readContainerMDValues(r io.Reader) (values map[string]string, failures []string, err error) {
+	dec := xml.NewDecoder(r)
+
+	var (
+		path    []string
+		current strings.Builder
+		seen    bool
+	)
+	values = make(map[string]string)
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var syntaxErr *xml.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				return nil, []string{fmt.Sprintf("Not well-formed XML: %v", syntaxErr)}, nil
+			}
+			return nil, nil, fmt.Errorf("read XML: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			path = append(path, t.Name.Local)
+			switch len(path) {
+			case 1:
+				if t.Name.Local != "ContainerMetadata" {
+					return nil, []string{
+						fmt.Sprintf("Unexpected root element: %q, expected \"ContainerMetadata\"", t.Name.Local),
+					}, nil
+				}
+			case 2:
+				if t.Name.Local != "Container" {
+					failures = append(failures, fmt.Sprintf("Unexpected element: %q", t.Name.Local))
+				} else if seen {
+					failures = append(failures, `Duplicate element: "Container"`)
+				}
+				seen = true
+			case 3:
+				current.Reset()
+				if path[1] == "Container" && !IsContainerMDField(t.Name.Local) {
+					failures = append(failures, fmt.Sprintf("Unknown field: %q", t.Name.Local))
+				}
+			}
+		case xml.CharData:
+			if len(path) == 3 {
+				current.Write(t)
+			}
+		case xml.EndElement:
+			if len(path) == 3 && path[1] == "Container" && IsContainerMDField(path[2]) {
+				if _, ok := values[path[2]]; ok {
+					failures = append(failures, fmt.Sprintf("Duplicate field: %q", path[2]))
+				}
+				values[path[2]] = strings.TrimSpace(current.String())
+			}
+			path = path[:len(path)-1]
+		}
+	}
+
+	if !seen {
+		return nil, append(failures, `Missing required element: "Container"`), nil
+	}
+
+	return values, failures, nil
+}
+
+// checkFieldValue checks that v can be decoded into a value of type t.
+func checkFieldValue(t reflect.Type, v string) error {
+	if t == reflect.TypeFor[Date]() {
+		// The time zone doesn't affect whether a date can be parsed.
+		_, err := ParseDate(v, time.UTC)
+		return err
+	}
+
+	// encoding/xml decodes empty elements as the zero value of basic types.
+	if v == "" {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if _, err := strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("%q is not a valid boolean", v)
+		}
+	case reflect.Int, reflect.Int64:
+		if _, err := strconv.ParseInt(v, 10, t.Bits()); err != nil {
+			return fmt.Errorf("%q is not a valid integer", v)
+		}
+	}
+
+	return nil
+}
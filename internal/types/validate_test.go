@@ -0,0 +1,140 @@
+package types_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/types"
+)
+
+const validContainerMD = `<?xml version="1.0" encoding="utf-8"?>
+<ContainerMetadata xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <Container>
+    <AccessionNumber>12</AccessionNumber>
+    <Classification>01-5000-12</Classification>
+    <DateRegistered>2009-01-15T00:00:00Z</DateRegistered>
+    <HasHolds>false</HasHolds>
+    <RecordNumber>01-5000-12/2009-01</RecordNumber>
+    <TitleFreeTextPart>Test Title</TitleFreeTextPart>
+    <UniqueIdentifier>9876543210</UniqueIdentifier>
+  </Container>
+</ContainerMetadata>`
+
+func TestValidateContainerMD(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		xml      string
+		required []string
+		want     []string
+	}{
+		{
+			name:     "returns no failures for a valid document",
+			xml:      validContainerMD,
+			required: types.DefaultRequiredFields,
+		},
+		{
+			name: "returns no failures when no fields are required",
+			xml:  "<ContainerMetadata><Container></Container></ContainerMetadata>",
+		},
+		{
+			name:     "reports missing and empty required fields",
+			xml:      "<ContainerMetadata><Container><RecordNumber> </RecordNumber></Container></ContainerMetadata>",
+			required: types.DefaultRequiredFields,
+			want: []string{
+				`Missing required field: "RecordNumber"`,
+				`Missing required field: "Classification"`,
+				`Missing required field: "TitleFreeTextPart"`,
+				`Missing required field: "DateRegistered"`,
+			},
+		},
+		{
+			name: "reports every type error",
+			xml: `<ContainerMetadata><Container>
+<AccessionNumber>twelve</AccessionNumber>
+<DateClosed>2019-03-04 10:22:00</DateClosed>
+<DateCreated>04/03/2019</DateCreated>
+<DateRegistered/>
+<DatePublished>2019-02-30</DatePublished>
+<HasHolds>maybe</HasHolds>
+<IsContainer></IsContainer>
+</Container></ContainerMetadata>`,
+			want: []string{
+				`Invalid value for field "AccessionNumber": "twelve" is not a valid integer`,
+				`Invalid value for field "DateCreated": "04/03/2019" is not a valid date`,
+				`Invalid value for field "DatePublished": "2019-02-30" is not a valid date`,
+				`Invalid value for field "HasHolds": "maybe" is not a valid boolean`,
+			},
+		},
+		{
+			name: "reports unknown and duplicate fields",
+			xml: `<ContainerMetadata><Container>
+<Title>One</Title>
+<Title>Two</Title>
+<Colour>Blue</Colour>
+</Container></ContainerMetadata>`,
+			want: []string{
+				`Duplicate field: "Title"`,
+				`Unknown field: "Colour"`,
+			},
+		},
+		{
+			name: "reports unexpected and duplicate container elements",
+			xml: `<ContainerMetadata>
+<Container></Container>
+<Container></Container>
+<Folder></Folder>
+</ContainerMetadata>`,
+			want: []string{
+				`Duplicate element: "Container"`,
+				`Unexpected element: "Folder"`,
+			},
+		},
+		{
+			name:     "reports a missing Container element",
+			xml:      "<ContainerMetadata></ContainerMetadata>",
+			required: types.DefaultRequiredFields,
+			want:     []string{`Missing required element: "Container"`},
+		},
+		{
+			name: "reports an unexpected root element",
+			xml:  "<Metadata><Container></Container></Metadata>",
+			want: []string{`Unexpected root element: "Metadata", expected "ContainerMetadata"`},
+		},
+		{
+			name: "reports malformed XML",
+			xml:  "<ContainerMetadata><Container></ContainerMetadata>",
+			want: []string{
+				"Not well-formed XML: XML syntax error on line 1: element <Container> closed by </ContainerMetadata>",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := types.ValidateContainerMD(strings.NewReader(tc.xml), tc.required)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, got, tc.want)
+		})
+	}
+
+	t.Run("errors when the reader fails", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := types.ValidateContainerMD(iotest.ErrReader(errors.New("boom")), nil)
+		assert.Error(t, err, "read XML: boom")
+	})
+}
+
+func TestIsContainerMDField(t *testing.T) {
+	t.Parallel()
+
+	assert.Assert(t, types.IsContainerMDField("RecordNumber"))
+	assert.Assert(t, !types.IsContainerMDField("Colour"))
+	assert.Equal(t, len(types.ContainerMDFields()), 44)
+}
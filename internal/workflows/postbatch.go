@@ -5,7 +5,7 @@ import (
 	"time"
 
 	"github.com/artefactual-sdps/enduro/pkg/childwf"
-	"github.com/artefactual-sdps/temporal-activities/bucketdelete"
+	"github.com/google/uuid"
 	temporalsdk_workflow "go.temporal.io/sdk/workflow"
 
 	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
@@ -28,7 +28,7 @@ func (w *Postbatch) Execute(
 	logger.Debug("Postbatch workflow running!", "params", params)
 
 	// Create an AtoM CSV file for all the SIPs in the batch.
-	fsCtx := withFilesysOpts(ctx, 10*time.Minute)
+	fsCtx := withActivityOpts(ctx, w.cfg.Activities, activities.CreateCSVName, 10*time.Minute)
 	var csvResult activities.CreateCSVResult
 	err := temporalsdk_workflow.ExecuteActivity(
 		fsCtx,
@@ -42,19 +42,97 @@ func (w *Postbatch) Execute(
 		return nil, fmt.Errorf("create CSV: %w", err)
 	}
 
-	// Delete the ContainerMetadata.xml file for each SIP in the batch.
+	// The CSV file is only partial if the metadata of some SIPs could not be
+	// read. The batch files are still created for the other SIPs, so the
+	// postbatch succeeds, and the problem SIPs are logged and listed in the
+	// batch report. The SIPs left out of the CSV file are left out of the
+	// digital object CSV file.
+	var skipped []uuid.UUID
+	if len(csvResult.MetadataErrors) > 0 {
+		logger.Warn(
+			"AtoM CSV file created without the metadata of some SIPs",
+			"key", csvResult.Key,
+			"metadataErrors", csvResult.MetadataErrors,
+		)
+
+		for _, e := range csvResult.MetadataErrors {
+			if e.Skipped {
+				skipped = append(skipped, e.SIPID)
+			}
+		}
+	}
+
+	// Create an AtoM digital object CSV file linking each description to its
+	// AIP, if enabled.
+	if w.cfg.DigitalObjectCSV.Enabled {
+		fsCtx := withActivityOpts(ctx, w.cfg.Activities, activities.CreateDigitalObjectCSVName, 10*time.Minute)
+		var doResult activities.CreateDigitalObjectCSVResult
+		err := temporalsdk_workflow.ExecuteActivity(
+			fsCtx,
+			activities.CreateDigitalObjectCSVName,
+			activities.CreateDigitalObjectCSVParams{
+				Batch:   params.Batch,
+				SIPs:    params.SIPs,
+				Skipped: skipped,
+			},
+		).Get(fsCtx, &doResult)
+		if err != nil {
+			return nil, fmt.Errorf("create digital object CSV: %w", err)
+		}
+	}
+
+	// Create an EAD finding aid for all the SIPs in the batch, if enabled.
+	if w.cfg.EAD.Enabled {
+		fsCtx := withActivityOpts(ctx, w.cfg.Activities, activities.CreateEADName, 10*time.Minute)
+		var eadResult activities.CreateEADResult
+		err := temporalsdk_workflow.ExecuteActivity(
+			fsCtx,
+			activities.CreateEADName,
+			activities.CreateEADParams{
+				Batch:     params.Batch,
+				SIPs:      params.SIPs,
+				CreatedAt: temporalsdk_workflow.Now(ctx),
+			},
+		).Get(fsCtx, &eadResult)
+		if err != nil {
+			return nil, fmt.Errorf("create EAD: %w", err)
+		}
+	}
+
+	// Create a summary report listing the outcome of every SIP in the batch,
+	// including the SIPs left out of the AtoM CSV file.
+	fsCtx = withActivityOpts(ctx, w.cfg.Activities, activities.CreateBatchReportName, 10*time.Minute)
+	var reportResult activities.CreateBatchReportResult
+	err = temporalsdk_workflow.ExecuteActivity(
+		fsCtx,
+		activities.CreateBatchReportName,
+		activities.CreateBatchReportParams{
+			Batch:          params.Batch,
+			SIPs:           params.SIPs,
+			CreatedAt:      temporalsdk_workflow.Now(ctx),
+			MetadataErrors: csvResult.MetadataErrors,
+		},
+	).Get(fsCtx, &reportResult)
+	if err != nil {
+		return nil, fmt.Errorf("create batch report: %w", err)
+	}
+
+	// Delete the ContainerMetadata.xml file and file inventory uploaded by the
+	// preprocessing workflow for each preserved SIP in the batch. The files
+	// of the SIPs without an AIP are kept for their reprocessing.
 	for _, sip := range params.SIPs {
-		key := fmt.Sprintf("%s_ContainerMetadata.xml", sip.UUID)
-		fsCtx := withFilesysOpts(ctx, 1*time.Minute)
+		if sip.AIPID == nil || *sip.AIPID == uuid.Nil {
+			continue
+		}
+
+		fsCtx := withActivityOpts(ctx, w.cfg.Activities, activities.DeleteSIPFilesName, 1*time.Minute)
 		err = temporalsdk_workflow.ExecuteActivity(
 			fsCtx,
-			bucketdelete.Name,
-			bucketdelete.Params{
-				Key: key,
-			},
+			activities.DeleteSIPFilesName,
+			activities.DeleteSIPFilesParams{SIPID: sip.UUID},
 		).Get(fsCtx, nil)
 		if err != nil {
-			return nil, fmt.Errorf("delete %s from ingest bucket: %v", key, err)
+			return nil, fmt.Errorf("delete SIP %s files from ingest bucket: %v", sip.UUID, err)
 		}
 	}
 
@@ -1,11 +1,12 @@
 package workflows_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/artefactual-sdps/enduro/pkg/childwf"
-	"github.com/artefactual-sdps/temporal-activities/bucketdelete"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -33,6 +34,9 @@ type PostbatchTestSuite struct {
 
 	// bucket is a blobs.Bucket used for reports in tests.
 	bucket *blob.Bucket
+
+	// startTime is the test time at which the workflow is started.
+	startTime time.Time
 }
 
 func TestPostbatch(t *testing.T) {
@@ -41,19 +45,36 @@ func TestPostbatch(t *testing.T) {
 
 func (s *PostbatchTestSuite) SetupWorkflowTest(cfg config.Config) {
 	s.env = s.NewTestWorkflowEnvironment()
+	s.startTime = time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	s.env.SetStartTime(s.startTime)
 
 	b, err := bucket.NewWithConfig(s.T().Context(), cfg.IngestBucket)
 	s.Require().NoError(err)
 	s.bucket = b
 
 	s.env.RegisterActivityWithOptions(
-		activities.NewCreateCSV(s.bucket).Execute,
+		activities.NewCreateCSV(s.bucket, time.UTC, cfg.Postbatch.CreateCSV).Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.CreateCSVName},
 	)
 
 	s.env.RegisterActivityWithOptions(
-		bucketdelete.New(s.bucket).Execute,
-		temporalsdk_activity.RegisterOptions{Name: bucketdelete.Name},
+		activities.NewCreateDigitalObjectCSV(s.bucket, cfg.Postbatch.DigitalObjectCSV).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CreateDigitalObjectCSVName},
+	)
+
+	s.env.RegisterActivityWithOptions(
+		activities.NewCreateEAD(s.bucket, time.UTC, cfg.Postbatch.CreateCSV, cfg.Postbatch.EAD).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CreateEADName},
+	)
+
+	s.env.RegisterActivityWithOptions(
+		activities.NewCreateBatchReport(s.bucket, time.UTC).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CreateBatchReportName},
+	)
+
+	s.env.RegisterActivityWithOptions(
+		activities.NewDeleteSIPFiles(s.bucket).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.DeleteSIPFilesName},
 	)
 
 	s.workflow = workflows.NewPostbatch(cfg.Postbatch)
@@ -63,6 +84,48 @@ func (s *PostbatchTestSuite) TearDownTest() {
 	s.bucket.Close()
 }
 
+// mockDeleteSIPFiles mocks the successful deletion of the files uploaded to
+// the ingest bucket by the preprocessing workflow for sip.
+func (s *PostbatchTestSuite) mockDeleteSIPFiles(sip *childwf.PostbatchSIP) {
+	s.env.OnActivity(
+		activities.DeleteSIPFilesName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.DeleteSIPFilesParams{SIPID: sip.UUID},
+	).Return(
+		&activities.DeleteSIPFilesResult{
+			Deleted: []string{
+				fmt.Sprintf("%s_ContainerMetadata.xml", sip.UUID),
+				fmt.Sprintf("%s_inventory.json", sip.UUID),
+			},
+		},
+		nil,
+	)
+}
+
+// mockCreateBatchReport mocks a successful batch report creation.
+func (s *PostbatchTestSuite) mockCreateBatchReport(
+	batch *childwf.PostbatchBatch,
+	sips []*childwf.PostbatchSIP,
+	metadataErrors []activities.SIPMetadataError,
+) {
+	s.env.OnActivity(
+		activities.CreateBatchReportName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.CreateBatchReportParams{
+			Batch:          batch,
+			SIPs:           sips,
+			CreatedAt:      s.startTime,
+			MetadataErrors: metadataErrors,
+		},
+	).Return(
+		&activities.CreateBatchReportResult{
+			JSONKey: fmt.Sprintf("reports/batch_%s_report.json", batch.UUID),
+			TextKey: fmt.Sprintf("reports/batch_%s_report.txt", batch.UUID),
+		},
+		nil,
+	)
+}
+
 func (s *PostbatchTestSuite) TestHappyPath() {
 	batch := &childwf.PostbatchBatch{
 		UUID:      uuid.MustParse("8fdfaea1-06ed-4cf6-8bdf-d15d80420f35"),
@@ -92,13 +155,131 @@ func (s *PostbatchTestSuite) TestHappyPath() {
 		nil,
 	)
 
+	s.mockCreateBatchReport(batch, []*childwf.PostbatchSIP{sip}, nil)
+
+	s.mockDeleteSIPFiles(sip)
+
+	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PostbatchParams{
+		Batch: batch,
+		SIPs:  []*childwf.PostbatchSIP{sip},
+	})
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var result childwf.PostbatchResult
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(childwf.OutcomeSuccess, result.Outcome)
+}
+
+func (s *PostbatchTestSuite) TestKeepsFilesOfSIPsWithoutAIP() {
+	batch := &childwf.PostbatchBatch{
+		UUID:      uuid.MustParse("8fdfaea1-06ed-4cf6-8bdf-d15d80420f35"),
+		SIPSCount: 2,
+	}
+	sips := []*childwf.PostbatchSIP{
+		{
+			UUID:  uuid.MustParse("22222222-3333-4444-5555-666666666666"),
+			Name:  "Test SIP 1",
+			AIPID: ref.New(uuid.MustParse("11111111-2222-3333-4444-555555555555")),
+		},
+		{
+			UUID: uuid.MustParse("33333333-4444-5555-6666-777777777777"),
+			Name: "Test SIP 2",
+		},
+	}
+
+	s.SetupWorkflowTest(config.Config{
+		IngestBucket: &bucket.Config{URL: "mem://"},
+	})
+
+	s.env.OnActivity(
+		activities.CreateCSVName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.CreateCSVParams{
+			Batch: batch,
+			SIPs:  sips,
+		},
+	).Return(
+		&activities.CreateCSVResult{
+			Key: fmt.Sprintf("batch_%s.csv", batch.UUID),
+		},
+		nil,
+	)
+
+	s.mockCreateBatchReport(batch, sips, nil)
+
+	s.mockDeleteSIPFiles(sips[0])
+	s.env.OnActivity(
+		activities.DeleteSIPFilesName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.DeleteSIPFilesParams{SIPID: sips[1].UUID},
+	).Never()
+
+	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PostbatchParams{
+		Batch: batch,
+		SIPs:  sips,
+	})
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var result childwf.PostbatchResult
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(childwf.OutcomeSuccess, result.Outcome)
+	s.env.AssertExpectations(s.T())
+}
+
+func (s *PostbatchTestSuite) TestDigitalObjectCSV() {
+	batch := &childwf.PostbatchBatch{
+		UUID:      uuid.MustParse("8fdfaea1-06ed-4cf6-8bdf-d15d80420f35"),
+		SIPSCount: 1,
+	}
+	sip := &childwf.PostbatchSIP{
+		UUID:  uuid.MustParse("22222222-3333-4444-5555-666666666666"),
+		Name:  "Test SIP",
+		AIPID: ref.New(uuid.MustParse("11111111-2222-3333-4444-555555555555")),
+	}
+
+	s.SetupWorkflowTest(config.Config{
+		IngestBucket: &bucket.Config{URL: "mem://"},
+		Postbatch: config.PostbatchConfig{
+			DigitalObjectCSV: activities.DigitalObjectCSVConfig{
+				Enabled:     true,
+				URITemplate: "https://storage.example.org/aips/{{.AIPID}}",
+			},
+		},
+	})
+
+	s.env.OnActivity(
+		activities.CreateCSVName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.CreateCSVParams{
+			Batch: batch,
+			SIPs:  []*childwf.PostbatchSIP{sip},
+		},
+	).Return(
+		&activities.CreateCSVResult{
+			Key: fmt.Sprintf("reports/batch_%s.csv", batch.UUID),
+		},
+		nil,
+	)
+
 	s.env.OnActivity(
-		bucketdelete.Name,
+		activities.CreateDigitalObjectCSVName,
 		mock.AnythingOfType("*context.timerCtx"),
-		&bucketdelete.Params{
-			Key: fmt.Sprintf("%s_ContainerMetadata.xml", sip.UUID),
+		&activities.CreateDigitalObjectCSVParams{
+			Batch: batch,
+			SIPs:  []*childwf.PostbatchSIP{sip},
 		},
-	).Return(nil, nil)
+	).Return(
+		&activities.CreateDigitalObjectCSVResult{
+			Key: fmt.Sprintf("reports/batch_%s_digital_objects.csv", batch.UUID),
+		},
+		nil,
+	)
+
+	s.mockCreateBatchReport(batch, []*childwf.PostbatchSIP{sip}, nil)
+
+	s.mockDeleteSIPFiles(sip)
 
 	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PostbatchParams{
 		Batch: batch,
@@ -110,4 +291,264 @@ func (s *PostbatchTestSuite) TestHappyPath() {
 	var result childwf.PostbatchResult
 	s.NoError(s.env.GetWorkflowResult(&result))
 	s.Equal(childwf.OutcomeSuccess, result.Outcome)
+	s.env.AssertExpectations(s.T())
+}
+
+func (s *PostbatchTestSuite) TestEAD() {
+	batch := &childwf.PostbatchBatch{
+		UUID:      uuid.MustParse("8fdfaea1-06ed-4cf6-8bdf-d15d80420f35"),
+		SIPSCount: 1,
+	}
+	sip := &childwf.PostbatchSIP{
+		UUID:  uuid.MustParse("22222222-3333-4444-5555-666666666666"),
+		Name:  "Test SIP",
+		AIPID: ref.New(uuid.MustParse("11111111-2222-3333-4444-555555555555")),
+	}
+
+	s.SetupWorkflowTest(config.Config{
+		IngestBucket: &bucket.Config{URL: "mem://"},
+		Postbatch: config.PostbatchConfig{
+			EAD: activities.EADConfig{
+				Enabled: true,
+				Version: activities.EADVersion3,
+			},
+		},
+	})
+
+	s.env.OnActivity(
+		activities.CreateCSVName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.CreateCSVParams{
+			Batch: batch,
+			SIPs:  []*childwf.PostbatchSIP{sip},
+		},
+	).Return(
+		&activities.CreateCSVResult{
+			Key: fmt.Sprintf("reports/batch_%s.csv", batch.UUID),
+		},
+		nil,
+	)
+
+	s.env.OnActivity(
+		activities.CreateEADName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.CreateEADParams{
+			Batch:     batch,
+			SIPs:      []*childwf.PostbatchSIP{sip},
+			CreatedAt: s.startTime,
+		},
+	).Return(
+		&activities.CreateEADResult{
+			Key: fmt.Sprintf("reports/batch_%s_ead.xml", batch.UUID),
+		},
+		nil,
+	)
+
+	s.mockCreateBatchReport(batch, []*childwf.PostbatchSIP{sip}, nil)
+
+	s.mockDeleteSIPFiles(sip)
+
+	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PostbatchParams{
+		Batch: batch,
+		SIPs:  []*childwf.PostbatchSIP{sip},
+	})
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var result childwf.PostbatchResult
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(childwf.OutcomeSuccess, result.Outcome)
+	s.env.AssertExpectations(s.T())
+}
+
+func (s *PostbatchTestSuite) TestMetadataErrors() {
+	batch := &childwf.PostbatchBatch{
+		UUID:      uuid.MustParse("8fdfaea1-06ed-4cf6-8bdf-d15d80420f35"),
+		SIPSCount: 2,
+	}
+	sips := []*childwf.PostbatchSIP{
+		{
+			UUID:  uuid.MustParse("22222222-3333-4444-5555-666666666666"),
+			Name:  "Test SIP 1",
+			AIPID: ref.New(uuid.MustParse("11111111-2222-3333-4444-555555555555")),
+		},
+		{
+			UUID:  uuid.MustParse("33333333-4444-5555-6666-777777777777"),
+			Name:  "Test SIP 2",
+			AIPID: ref.New(uuid.MustParse("44444444-5555-6666-7777-888888888888")),
+		},
+	}
+
+	s.SetupWorkflowTest(config.Config{
+		IngestBucket: &bucket.Config{URL: "mem://"},
+		Postbatch: config.PostbatchConfig{
+			CreateCSV: activities.CreateCSVConfig{
+				OnMetadataError: activities.OnMetadataErrorSkip,
+			},
+			DigitalObjectCSV: activities.DigitalObjectCSVConfig{
+				Enabled:     true,
+				URITemplate: "https://storage.example.org/aips/{{.AIPID}}",
+			},
+		},
+	})
+
+	metadataErrors := []activities.SIPMetadataError{
+		{
+			SIPID:   sips[1].UUID,
+			Name:    sips[1].Name,
+			Error:   "parse container metadata: not found",
+			Skipped: true,
+		},
+	}
+
+	s.env.OnActivity(
+		activities.CreateCSVName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.CreateCSVParams{
+			Batch: batch,
+			SIPs:  sips,
+		},
+	).Return(
+		&activities.CreateCSVResult{
+			Key:            fmt.Sprintf("batch_%s.csv", batch.UUID),
+			MetadataErrors: metadataErrors,
+		},
+		nil,
+	)
+
+	// The SIP left out of the AtoM CSV file is left out of the digital object
+	// CSV file, and listed in the batch report.
+	s.env.OnActivity(
+		activities.CreateDigitalObjectCSVName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.CreateDigitalObjectCSVParams{
+			Batch:   batch,
+			SIPs:    sips,
+			Skipped: []uuid.UUID{sips[1].UUID},
+		},
+	).Return(
+		&activities.CreateDigitalObjectCSVResult{
+			Key: fmt.Sprintf("reports/batch_%s_digital_objects.csv", batch.UUID),
+		},
+		nil,
+	)
+
+	s.mockCreateBatchReport(batch, sips, metadataErrors)
+
+	for _, sip := range sips {
+		s.mockDeleteSIPFiles(sip)
+	}
+
+	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PostbatchParams{
+		Batch: batch,
+		SIPs:  sips,
+	})
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var result childwf.PostbatchResult
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(childwf.OutcomeSuccess, result.Outcome)
+	s.env.AssertExpectations(s.T())
+}
+
+func (s *PostbatchTestSuite) TestActivityRetries() {
+	batch := &childwf.PostbatchBatch{
+		UUID:      uuid.MustParse("8fdfaea1-06ed-4cf6-8bdf-d15d80420f35"),
+		SIPSCount: 1,
+	}
+	sip := &childwf.PostbatchSIP{
+		UUID:  uuid.MustParse("22222222-3333-4444-5555-666666666666"),
+		Name:  "Test SIP",
+		AIPID: ref.New(uuid.MustParse("11111111-2222-3333-4444-555555555555")),
+	}
+
+	s.SetupWorkflowTest(config.Config{
+		IngestBucket: &bucket.Config{URL: "mem://"},
+		Postbatch: config.PostbatchConfig{
+			Activities: config.ActivitiesConfig{
+				activities.CreateCSVName: {
+					Retry: config.RetryConfig{
+						InitialInterval: time.Second,
+						MaximumAttempts: 3,
+					},
+				},
+			},
+		},
+	})
+
+	params := &activities.CreateCSVParams{
+		Batch: batch,
+		SIPs:  []*childwf.PostbatchSIP{sip},
+	}
+	s.env.OnActivity(
+		activities.CreateCSVName,
+		mock.AnythingOfType("*context.timerCtx"),
+		params,
+	).Return(nil, errors.New("connection reset by peer")).Twice()
+	s.env.OnActivity(
+		activities.CreateCSVName,
+		mock.AnythingOfType("*context.timerCtx"),
+		params,
+	).Return(
+		&activities.CreateCSVResult{
+			Key: fmt.Sprintf("batch_%s.csv", batch.UUID),
+		},
+		nil,
+	).Once()
+
+	// The retries delay the report creation time, so don't match it.
+	s.env.OnActivity(
+		activities.CreateBatchReportName,
+		mock.AnythingOfType("*context.timerCtx"),
+		mock.AnythingOfType("*activities.CreateBatchReportParams"),
+	).Return(&activities.CreateBatchReportResult{}, nil)
+
+	s.mockDeleteSIPFiles(sip)
+
+	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PostbatchParams{
+		Batch: batch,
+		SIPs:  []*childwf.PostbatchSIP{sip},
+	})
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var result childwf.PostbatchResult
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(childwf.OutcomeSuccess, result.Outcome)
+	s.env.AssertExpectations(s.T())
+}
+
+func (s *PostbatchTestSuite) TestActivityFailsWithoutRetries() {
+	batch := &childwf.PostbatchBatch{
+		UUID:      uuid.MustParse("8fdfaea1-06ed-4cf6-8bdf-d15d80420f35"),
+		SIPSCount: 1,
+	}
+	sip := &childwf.PostbatchSIP{
+		UUID:  uuid.MustParse("22222222-3333-4444-5555-666666666666"),
+		Name:  "Test SIP",
+		AIPID: ref.New(uuid.MustParse("11111111-2222-3333-4444-555555555555")),
+	}
+
+	s.SetupWorkflowTest(config.Config{
+		IngestBucket: &bucket.Config{URL: "mem://"},
+	})
+
+	s.env.OnActivity(
+		activities.CreateCSVName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.CreateCSVParams{
+			Batch: batch,
+			SIPs:  []*childwf.PostbatchSIP{sip},
+		},
+	).Return(nil, errors.New("connection reset by peer")).Once()
+
+	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PostbatchParams{
+		Batch: batch,
+		SIPs:  []*childwf.PostbatchSIP{sip},
+	})
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.ErrorContains(s.env.GetWorkflowError(), "connection reset by peer")
+	s.env.AssertExpectations(s.T())
 }
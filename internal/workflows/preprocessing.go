@@ -3,17 +3,33 @@ package workflows
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/artefactual-sdps/enduro/pkg/childwf"
-	"github.com/artefactual-sdps/temporal-activities/bagcreate"
 	"github.com/artefactual-sdps/temporal-activities/bucketupload"
 	"github.com/google/uuid"
 	temporalsdk_workflow "go.temporal.io/sdk/workflow"
 
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
 	"github.com/artefactual-sdps/cva-enduro-workflows/internal/config"
 )
 
+const (
+	// heartbeatTimeout is the default heartbeat timeout of the activities
+	// that process every SIP file, e.g. the bag creation.
+	heartbeatTimeout = time.Minute
+
+	// sessionCreationTimeout is the maximum time to wait for a worker to
+	// start a preprocessing session, e.g. while the worker is already running
+	// Worker.MaxConcurrentSessions sessions.
+	sessionCreationTimeout = 24 * time.Hour
+
+	// sessionExecutionTimeout is the maximum duration of a preprocessing
+	// session.
+	sessionExecutionTimeout = 7 * 24 * time.Hour
+)
+
 type Preprocesssing struct {
 	cfg config.PreprocessingConfig
 }
@@ -34,18 +50,296 @@ func (w *Preprocesssing) Execute(
 	logger := temporalsdk_workflow.GetLogger(ctx)
 	logger.Debug("Preprocessing workflow running!", "params", params)
 
-	// Upload the ContainerMetadata.xml file only if this SIP is part of a
-	// batch; single SIPs don't write a Batch CSV file, so the metadata is
-	// not needed.
+	sipPath := filepath.Join(w.cfg.SharedPath, params.RelativePath)
+
+	// Run the activities in a session, so all the work on the SIP happens on
+	// the worker host that shares the SIP filesystem, and the number of SIPs
+	// processed concurrently is limited by Worker.MaxConcurrentSessions.
+	sessCtx, err := temporalsdk_workflow.CreateSession(ctx, &temporalsdk_workflow.SessionOptions{
+		CreationTimeout:  sessionCreationTimeout,
+		ExecutionTimeout: sessionExecutionTimeout,
+	})
+	if err != nil {
+		sessionTask := result.NewTask(temporalsdk_workflow.Now(ctx), "Start preprocessing session")
+		failTask(
+			ctx,
+			&result,
+			sessionTask,
+			fmt.Errorf("create session: %w", err),
+			"An error occurred when starting the preprocessing session. Please try again, or ask a system administrator to investigate.",
+		)
+		return &result, nil
+	}
+	defer temporalsdk_workflow.CompleteSession(sessCtx)
+
+	// Check the SIP is not on a legal hold, if enabled, before any of it is
+	// validated, read or changed by the other steps. A SIP on hold fails with
+	// a content error, or is moved to quarantine for review and not bagged,
+	// even if it has structure or metadata errors.
+	if w.cfg.LegalHold.Enabled {
+		holdTask := result.NewTask(temporalsdk_workflow.Now(ctx), "Check legal holds")
+
+		var checkHold activities.CheckLegalHoldResult
+		err = temporalsdk_workflow.ExecuteActivity(
+			withActivityOpts(sessCtx, w.cfg.Activities, activities.CheckLegalHoldName, 1*time.Minute),
+			activities.CheckLegalHoldName,
+			&activities.CheckLegalHoldParams{Path: sipPath, SIPID: params.SIPID},
+		).Get(sessCtx, &checkHold)
+		if err != nil {
+			failTask(
+				ctx,
+				&result,
+				holdTask,
+				err,
+				"An error occurred when checking the SIP legal holds. Please try again, or ask a system administrator to investigate.",
+			)
+			return &result, nil
+		}
+
+		if checkHold.QuarantinePath != "" {
+			logger.Info("SIP moved to quarantine", "path", checkHold.QuarantinePath, "reasons", checkHold.Reasons)
+			result.ValidationError(
+				temporalsdk_workflow.Now(ctx),
+				holdTask,
+				fmt.Sprintf("The SIP is on a legal hold and was moved to %s for review", checkHold.QuarantinePath),
+				checkHold.Reasons,
+			)
+			return &result, nil
+		}
+		holdTask.Succeed(temporalsdk_workflow.Now(ctx), "The SIP is not on a legal hold")
+	}
+
+	// Validate the SIP structure before any of the SIP files are read, so a
+	// malformed transfer is reported as a content error.
+	structureTask := result.NewTask(temporalsdk_workflow.Now(ctx), "Validate SIP structure")
+
+	var validateStructure activities.ValidateStructureResult
+	err = temporalsdk_workflow.ExecuteActivity(
+		withActivityOpts(sessCtx, w.cfg.Activities, activities.ValidateStructureName, 1*time.Minute),
+		activities.ValidateStructureName,
+		&activities.ValidateStructureParams{Path: sipPath},
+	).Get(sessCtx, &validateStructure)
+	if err != nil {
+		failTask(
+			ctx,
+			&result,
+			structureTask,
+			err,
+			"An error occurred when validating the SIP structure. Please try again, or ask a system administrator to investigate.",
+		)
+		return &result, nil
+	}
+	structureTask.Succeed(temporalsdk_workflow.Now(ctx), "SIP structure is valid")
+
+	// Validate the ContainerMetadata.xml file against the VanDocs metadata
+	// rules.
+	containerMDTask := result.NewTask(temporalsdk_workflow.Now(ctx), "Validate ContainerMetadata.xml")
+
+	var validateContainerMD activities.ValidateContainerMDResult
+	err = temporalsdk_workflow.ExecuteActivity(
+		withActivityOpts(sessCtx, w.cfg.Activities, activities.ValidateContainerMDName, 1*time.Minute),
+		activities.ValidateContainerMDName,
+		&activities.ValidateContainerMDParams{Path: sipPath},
+	).Get(sessCtx, &validateContainerMD)
+	if err != nil {
+		failTask(
+			ctx,
+			&result,
+			containerMDTask,
+			err,
+			"An error occurred when validating the ContainerMetadata.xml file. Please try again, or ask a system administrator to investigate.",
+		)
+		return &result, nil
+	}
+	containerMDTask.Succeed(temporalsdk_workflow.Now(ctx), "ContainerMetadata.xml is valid")
+
+	// Verify the SIP content files against the supplied checksum manifests, if
+	// enabled, to detect corruption in transit from VanDocs. The verification
+	// timeout depends on the SIP size.
+	if w.cfg.VerifyChecksums.Enabled {
+		checksumsTask := result.NewTask(temporalsdk_workflow.Now(ctx), "Verify checksums")
+
+		var verifyChecksums activities.VerifyChecksumsResult
+		err = temporalsdk_workflow.ExecuteActivity(
+			withHeartbeatActivityOpts(
+				sessCtx, w.cfg.Activities, activities.VerifyChecksumsName,
+				w.cfg.VerifyChecksums.Timeout(validateStructure.Size),
+			),
+			activities.VerifyChecksumsName,
+			&activities.VerifyChecksumsParams{Path: sipPath},
+		).Get(sessCtx, &verifyChecksums)
+		if err != nil {
+			failTask(
+				ctx,
+				&result,
+				checksumsTask,
+				err,
+				"An error occurred when verifying the SIP checksums. Please try again, or ask a system administrator to investigate.",
+			)
+			return &result, nil
+		}
+
+		msg := "No checksum manifest found"
+		if len(verifyChecksums.Manifests) > 0 {
+			msg = fmt.Sprintf(
+				"Verified %d checksums from %s",
+				verifyChecksums.Files, strings.Join(verifyChecksums.Manifests, ", "),
+			)
+		}
+		checksumsTask.Succeed(temporalsdk_workflow.Now(ctx), msg)
+	}
+
+	// Scan the SIP files for malware, if enabled, before the content files
+	// are parsed or copied. The scan timeout depends on the SIP size.
+	if w.cfg.MalwareScan.Enabled {
+		scanTask := result.NewTask(temporalsdk_workflow.Now(ctx), "Scan for malware")
+
+		var scanMalware activities.ScanMalwareResult
+		err = temporalsdk_workflow.ExecuteActivity(
+			withHeartbeatActivityOpts(
+				sessCtx, w.cfg.Activities, activities.ScanMalwareName,
+				w.cfg.MalwareScan.Timeout(validateStructure.Size),
+			),
+			activities.ScanMalwareName,
+			&activities.ScanMalwareParams{Path: sipPath},
+		).Get(sessCtx, &scanMalware)
+		if err != nil {
+			failTask(
+				ctx,
+				&result,
+				scanTask,
+				err,
+				"An error occurred when scanning the SIP for malware. Please try again, or ask a system administrator to investigate.",
+			)
+			return &result, nil
+		}
+		msg := fmt.Sprintf("No malware found in %d files", scanMalware.Files)
+		if len(scanMalware.Unscanned) > 0 {
+			msg += "\nFiles not scanned, larger than the ClamAV StreamMaxLength limit:\n" +
+				strings.Join(scanMalware.Unscanned, "\n")
+		}
+		scanTask.Succeed(temporalsdk_workflow.Now(ctx), msg)
+	}
+
+	// Identify the SIP content file formats, if enabled, and check them
+	// against the format policy. The identification timeout depends on the
+	// SIP size.
+	if w.cfg.IdentifyFormats.Enabled {
+		formatsTask := result.NewTask(temporalsdk_workflow.Now(ctx), "Identify file formats")
+
+		var identifyFormats activities.IdentifyFormatsResult
+		err = temporalsdk_workflow.ExecuteActivity(
+			withHeartbeatActivityOpts(
+				sessCtx, w.cfg.Activities, activities.IdentifyFormatsName,
+				w.cfg.IdentifyFormats.Timeout(validateStructure.Size),
+			),
+			activities.IdentifyFormatsName,
+			&activities.IdentifyFormatsParams{Path: sipPath},
+		).Get(sessCtx, &identifyFormats)
+		if err != nil {
+			failTask(
+				ctx,
+				&result,
+				formatsTask,
+				err,
+				"An error occurred when identifying the SIP file formats. Please try again, or ask a system administrator to investigate.",
+			)
+			return &result, nil
+		}
+
+		msg := fmt.Sprintf(
+			"Identified the format of %d files, report written to %s",
+			identifyFormats.Files, identifyFormats.Path,
+		)
+		if len(identifyFormats.Warnings) > 0 {
+			msg += "\nFormat policy warnings:\n" + strings.Join(identifyFormats.Warnings, "\n")
+		}
+		formatsTask.Succeed(temporalsdk_workflow.Now(ctx), msg)
+	}
+
+	// Scan the SIP content files for personal information, if enabled. The
+	// report is added to the file inventory, so the AtoM CSV file can flag
+	// SIPs for FOIPPA review, and passed to the activities that restrict the
+	// access of SIPs with personal information.
+	var piiReport *activities.PIIReport
+	if w.cfg.ScanPII.Enabled {
+		piiTask := result.NewTask(temporalsdk_workflow.Now(ctx), "Scan for personal information")
+
+		var scanPII activities.ScanPIIResult
+		err = temporalsdk_workflow.ExecuteActivity(
+			withHeartbeatActivityOpts(
+				sessCtx, w.cfg.Activities, activities.ScanPIIName,
+				w.cfg.ScanPII.Timeout(validateStructure.Size),
+			),
+			activities.ScanPIIName,
+			&activities.ScanPIIParams{Path: sipPath},
+		).Get(sessCtx, &scanPII)
+		if err != nil {
+			failTask(
+				ctx,
+				&result,
+				piiTask,
+				err,
+				"An error occurred when scanning the SIP for personal information. Please try again, or ask a system administrator to investigate.",
+			)
+			return &result, nil
+		}
+
+		msg := fmt.Sprintf(
+			"Scanned %d files for personal information, report written to %s",
+			scanPII.Report.Files, scanPII.Path,
+		)
+		if len(scanPII.Report.Flagged) > 0 {
+			msg += fmt.Sprintf(
+				"\nPotential personal information found in %d files: %s",
+				len(scanPII.Report.Flagged), scanPII.Report.HitSummary(),
+			)
+		}
+		piiTask.Succeed(temporalsdk_workflow.Now(ctx), msg)
+		piiReport = scanPII.Report
+	}
+
+	// Check the SIP retention schedule and disposition, if enabled, so
+	// records slated for destruction are not preserved.
+	if w.cfg.Retention.Enabled {
+		retentionTask := result.NewTask(temporalsdk_workflow.Now(ctx), "Check retention schedule")
+
+		var checkRetention activities.CheckRetentionResult
+		err = temporalsdk_workflow.ExecuteActivity(
+			withActivityOpts(sessCtx, w.cfg.Activities, activities.CheckRetentionName, 1*time.Minute),
+			activities.CheckRetentionName,
+			&activities.CheckRetentionParams{Path: sipPath},
+		).Get(sessCtx, &checkRetention)
+		if err != nil {
+			failTask(
+				ctx,
+				&result,
+				retentionTask,
+				err,
+				"An error occurred when checking the SIP retention schedule. Please try again, or ask a system administrator to investigate.",
+			)
+			return &result, nil
+		}
+
+		msg := "The SIP is due for permanent archival"
+		if len(checkRetention.Warnings) > 0 {
+			msg = "Retention policy warnings:\n" + strings.Join(checkRetention.Warnings, "\n")
+		}
+		retentionTask.Succeed(temporalsdk_workflow.Now(ctx), msg)
+	}
+
+	// Upload the ContainerMetadata.xml file if this SIP is part of a batch,
+	// so the postbatch workflow can write the batch CSV file.
 	if params.BatchID != uuid.Nil {
 		uploadTask := result.NewTask(temporalsdk_workflow.Now(ctx), "Upload ContainerMetadata.xml")
 
-		err = w.uploadContainerMDFile(ctx, params)
+		err = w.uploadContainerMDFile(sessCtx, params)
 		if err != nil {
-			logger.Error("Task failed with error", "task", uploadTask.Name, "error", err)
-			result.SystemError(
-				temporalsdk_workflow.Now(ctx),
+			failTask(
+				ctx,
+				&result,
 				uploadTask,
+				err,
 				"An error occurred when uploading the ContainerMetadata.xml file to the Enduro ingest bucket. Please try again, or ask a system administrator to investigate.",
 			)
 			return &result, nil
@@ -54,24 +348,112 @@ func (w *Preprocesssing) Execute(
 			temporalsdk_workflow.Now(ctx),
 			"ContainerMetadata.xml file uploaded to the Enduro ingest bucket",
 		)
+
+		// Upload an inventory of the SIP content files, so the postbatch
+		// workflow can describe the SIP extent in the batch CSV file.
+		inventoryTask := result.NewTask(temporalsdk_workflow.Now(ctx), "Create file inventory")
+
+		var createInventory activities.CreateInventoryResult
+		err = temporalsdk_workflow.ExecuteActivity(
+			withActivityOpts(sessCtx, w.cfg.Activities, activities.CreateInventoryName, 10*time.Minute),
+			activities.CreateInventoryName,
+			&activities.CreateInventoryParams{Path: sipPath, SIPID: params.SIPID, PII: piiReport},
+		).Get(sessCtx, &createInventory)
+		if err != nil {
+			failTask(
+				ctx,
+				&result,
+				inventoryTask,
+				err,
+				"An error occurred when creating the file inventory. Please try again, or ask a system administrator to investigate.",
+			)
+			return &result, nil
+		}
+		inventoryTask.Succeed(
+			temporalsdk_workflow.Now(ctx),
+			fmt.Sprintf(
+				"File inventory of %d files (%s) uploaded to the Enduro ingest bucket",
+				createInventory.Inventory.Files, createInventory.Inventory.Size(),
+			),
+		)
+	} else {
+		// Single SIPs have no postbatch run, so write their AtoM CSV file
+		// now.
+		csvTask := result.NewTask(temporalsdk_workflow.Now(ctx), "Create AtoM CSV file")
+
+		var createCSV activities.CreateSIPCSVResult
+		err = temporalsdk_workflow.ExecuteActivity(
+			withActivityOpts(sessCtx, w.cfg.Activities, activities.CreateSIPCSVName, 10*time.Minute),
+			activities.CreateSIPCSVName,
+			&activities.CreateSIPCSVParams{
+				Path:  sipPath,
+				SIPID: params.SIPID,
+				Name:  filepath.Base(params.RelativePath),
+				PII:   piiReport,
+			},
+		).Get(sessCtx, &createCSV)
+		if err != nil {
+			failTask(
+				ctx,
+				&result,
+				csvTask,
+				err,
+				"An error occurred when creating the AtoM CSV file. Please try again, or ask a system administrator to investigate.",
+			)
+			return &result, nil
+		}
+		csvTask.Succeed(
+			temporalsdk_workflow.Now(ctx),
+			fmt.Sprintf("AtoM CSV file %q written to the Enduro ingest bucket", createCSV.Key),
+		)
 	}
 
-	// Bag the SIP for Enduro processing.
+	// Write the descriptive metadata derived from the ContainerMetadata.xml
+	// file to the SIP metadata directory, so it is preserved in the AIP
+	// whether or not the SIP is part of a batch.
+	dcTask := result.NewTask(temporalsdk_workflow.Now(ctx), "Create Dublin Core metadata")
+
+	var createDC activities.CreateDCMetadataResult
+	err = temporalsdk_workflow.ExecuteActivity(
+		withActivityOpts(sessCtx, w.cfg.Activities, activities.CreateDCMetadataName, 1*time.Minute),
+		activities.CreateDCMetadataName,
+		&activities.CreateDCMetadataParams{Path: sipPath, PII: piiReport},
+	).Get(sessCtx, &createDC)
+	if err != nil {
+		failTask(
+			ctx,
+			&result,
+			dcTask,
+			err,
+			"An error occurred when creating the Dublin Core metadata file. Please try again, or ask a system administrator to investigate.",
+		)
+		return &result, nil
+	}
+	dcTask.Succeed(
+		temporalsdk_workflow.Now(ctx),
+		fmt.Sprintf("Dublin Core metadata written to %s", createDC.Path),
+	)
+
+	// Bag the SIP for Enduro processing. The bag creation timeout depends on
+	// the SIP size, and the activity heartbeats so a hung worker is detected
+	// without waiting for the timeout.
 	bagTask := result.NewTask(temporalsdk_workflow.Now(ctx), "Bag SIP")
 
-	var createBag bagcreate.Result
+	var createBag activities.CreateBagResult
 	err = temporalsdk_workflow.ExecuteActivity(
-		withFilesysOpts(ctx, 10*time.Minute),
-		bagcreate.Name,
-		&bagcreate.Params{
-			SourcePath: filepath.Join(w.cfg.SharedPath, params.RelativePath),
-		},
-	).Get(ctx, &createBag)
+		withHeartbeatActivityOpts(
+			sessCtx, w.cfg.Activities, activities.CreateBagName,
+			w.cfg.BagCreate.Timeout(validateStructure.Size),
+		),
+		activities.CreateBagName,
+		&activities.CreateBagParams{Path: sipPath, BatchID: params.BatchID},
+	).Get(sessCtx, &createBag)
 	if err != nil {
-		logger.Error("Task failed with error", "task", bagTask.Name, "error", err)
-		result.SystemError(
-			temporalsdk_workflow.Now(ctx),
+		failTask(
+			ctx,
+			&result,
 			bagTask,
+			err,
 			"An error occurred when bagging the SIP. Please try again, or ask a system administrator to investigate.",
 		)
 		return &result, nil
@@ -100,7 +482,7 @@ func (w *Preprocesssing) uploadContainerMDFile(
 	)
 	key := fmt.Sprintf("%s_ContainerMetadata.xml", params.SIPID)
 
-	fsCtx := withFilesysOpts(ctx, 10*time.Minute)
+	fsCtx := withActivityOpts(ctx, w.cfg.Activities, bucketupload.Name, 10*time.Minute)
 	var res bucketupload.Result
 	err := temporalsdk_workflow.ExecuteActivity(
 		fsCtx,
@@ -1,6 +1,7 @@
 package workflows_test
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -8,17 +9,19 @@ import (
 	"time"
 
 	"github.com/artefactual-sdps/enduro/pkg/childwf"
-	"github.com/artefactual-sdps/temporal-activities/bagcreate"
 	"github.com/artefactual-sdps/temporal-activities/bucketupload"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	"go.artefactual.dev/tools/bucket"
 	temporalsdk_activity "go.temporal.io/sdk/activity"
+	temporalsdk_converter "go.temporal.io/sdk/converter"
 	temporalsdk_testsuite "go.temporal.io/sdk/testsuite"
+	temporalsdk_worker "go.temporal.io/sdk/worker"
 	"gocloud.dev/blob"
 	_ "gocloud.dev/blob/memblob"
 
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
 	"github.com/artefactual-sdps/cva-enduro-workflows/internal/config"
 	"github.com/artefactual-sdps/cva-enduro-workflows/internal/workflows"
 )
@@ -56,20 +59,74 @@ type PreprocessingTestSuite struct {
 
 func (s *PreprocessingTestSuite) SetupWorkflowTest(cfg config.Config) {
 	s.env = s.NewTestWorkflowEnvironment()
+	s.env.SetWorkerOptions(temporalsdk_worker.Options{EnableSessionWorker: true})
 
 	b, err := bucket.NewWithConfig(s.T().Context(), cfg.IngestBucket)
 	s.Require().NoError(err)
 	s.bucket = b
 	s.startTime = s.env.Now().UTC()
 
+	s.env.RegisterActivityWithOptions(
+		activities.NewValidateStructure().Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.ValidateStructureName},
+	)
+
+	s.env.RegisterActivityWithOptions(
+		activities.NewValidateContainerMD(cfg.Preprocessing.ValidateContainerMD).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.ValidateContainerMDName},
+	)
+
+	s.env.RegisterActivityWithOptions(
+		activities.NewCreateDCMetadata(time.UTC, cfg.Preprocessing.DCMetadata).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CreateDCMetadataName},
+	)
+
+	s.env.RegisterActivityWithOptions(
+		activities.NewCreateSIPCSV(s.bucket, time.UTC, cfg.Postbatch.CreateCSV).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CreateSIPCSVName},
+	)
+
+	s.env.RegisterActivityWithOptions(
+		activities.NewCreateInventory(s.bucket).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CreateInventoryName},
+	)
+
 	s.env.RegisterActivityWithOptions(
 		bucketupload.New(s.bucket).Execute,
 		temporalsdk_activity.RegisterOptions{Name: bucketupload.Name},
 	)
 
 	s.env.RegisterActivityWithOptions(
-		bagcreate.New(cfg.Preprocessing.BagCreate).Execute,
-		temporalsdk_activity.RegisterOptions{Name: bagcreate.Name},
+		activities.NewCreateBag(time.UTC, cfg.Preprocessing.BagCreate).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CreateBagName},
+	)
+
+	s.env.RegisterActivityWithOptions(
+		activities.NewVerifyChecksums(cfg.Preprocessing.VerifyChecksums).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.VerifyChecksumsName},
+	)
+
+	s.env.RegisterActivityWithOptions(
+		activities.NewScanMalware(nil, cfg.Preprocessing.MalwareScan).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.ScanMalwareName},
+	)
+
+	s.env.RegisterActivityWithOptions(
+		activities.NewIdentifyFormats(nil, cfg.Preprocessing.IdentifyFormats).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.IdentifyFormatsName},
+	)
+
+	s.env.RegisterActivityWithOptions(
+		activities.NewScanPII(cfg.Preprocessing.ScanPII).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.ScanPIIName},
+	)
+	s.env.RegisterActivityWithOptions(
+		activities.NewCheckLegalHold(cfg.Preprocessing.SharedPath, cfg.Preprocessing.LegalHold).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CheckLegalHoldName},
+	)
+	s.env.RegisterActivityWithOptions(
+		activities.NewCheckRetention(time.UTC, cfg.Preprocessing.Retention).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CheckRetentionName},
 	)
 
 	s.workflow = workflows.NewPreprocessing(cfg.Preprocessing)
@@ -79,6 +136,70 @@ func (s *PreprocessingTestSuite) TearDownTest() {
 	s.bucket.Close()
 }
 
+// mockValidateStructure mocks a successful SIP structure validation that
+// takes one second to complete.
+func (s *PreprocessingTestSuite) mockValidateStructure(sipPath string) {
+	s.env.OnActivity(
+		activities.ValidateStructureName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.ValidateStructureParams{Path: sipPath},
+	).Return(
+		&activities.ValidateStructureResult{}, nil,
+	).After(time.Second)
+}
+
+// mockValidateContainerMD mocks a successful ContainerMetadata.xml validation
+// that takes one second to complete.
+func (s *PreprocessingTestSuite) mockValidateContainerMD(sipPath string) {
+	s.env.OnActivity(
+		activities.ValidateContainerMDName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.ValidateContainerMDParams{Path: sipPath},
+	).Return(
+		&activities.ValidateContainerMDResult{}, nil,
+	).After(time.Second)
+}
+
+// mockCreateSIPCSV mocks a successful single SIP AtoM CSV file creation that
+// takes one second to complete.
+func (s *PreprocessingTestSuite) mockCreateSIPCSV(sipPath string, sipID uuid.UUID) {
+	s.env.OnActivity(
+		activities.CreateSIPCSVName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.CreateSIPCSVParams{
+			Path:  sipPath,
+			SIPID: sipID,
+			Name:  filepath.Base(sipPath),
+		},
+	).Return(
+		&activities.CreateSIPCSVResult{Key: fmt.Sprintf("reports/sip_%s.csv", sipID)}, nil,
+	).After(time.Second)
+}
+
+// mockCreateDCMetadata mocks a successful Dublin Core metadata file creation
+// that takes one second to complete.
+func (s *PreprocessingTestSuite) mockCreateDCMetadata(sipPath string) {
+	s.env.OnActivity(
+		activities.CreateDCMetadataName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.CreateDCMetadataParams{Path: sipPath},
+	).Return(
+		&activities.CreateDCMetadataResult{Path: "metadata/metadata.csv"}, nil,
+	).After(time.Second)
+}
+
+// mockCreateBag mocks a successful bag creation that takes one second to
+// complete.
+func (s *PreprocessingTestSuite) mockCreateBag(sipPath string, batchID uuid.UUID) {
+	s.env.OnActivity(
+		activities.CreateBagName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.CreateBagParams{Path: sipPath, BatchID: batchID},
+	).Return(
+		&activities.CreateBagResult{Path: sipPath}, nil,
+	).After(time.Second)
+}
+
 func (s *PreprocessingTestSuite) TestBatchSuccess() {
 	sharedPath := s.T().TempDir()
 	relativePath := "SIP-01234"
@@ -95,12 +216,15 @@ func (s *PreprocessingTestSuite) TestBatchSuccess() {
 		Preprocessing: config.PreprocessingConfig{
 			WorkflowName: "preprocessing-test",
 			SharedPath:   sharedPath,
-			BagCreate: bagcreate.Config{
+			BagCreate: activities.CreateBagConfig{
 				ChecksumAlgorithm: "sha512",
 			},
 		},
 	})
 
+	s.mockValidateStructure(filepath.Join(sharedPath, relativePath))
+	s.mockValidateContainerMD(filepath.Join(sharedPath, relativePath))
+
 	s.env.OnActivity(
 		bucketupload.Name,
 		mock.AnythingOfType("*context.timerCtx"),
@@ -120,15 +244,24 @@ func (s *PreprocessingTestSuite) TestBatchSuccess() {
 	).After(time.Second)
 
 	s.env.OnActivity(
-		bagcreate.Name,
+		activities.CreateInventoryName,
 		mock.AnythingOfType("*context.timerCtx"),
-		&bagcreate.Params{
-			SourcePath: filepath.Join(sharedPath, relativePath),
+		&activities.CreateInventoryParams{
+			Path:  filepath.Join(sharedPath, relativePath),
+			SIPID: sipID,
 		},
 	).Return(
-		&bagcreate.Result{}, nil,
+		&activities.CreateInventoryResult{
+			Key:       fmt.Sprintf("%s_inventory.json", sipID),
+			Inventory: &activities.Inventory{Files: 2, Bytes: 2_500_000},
+		},
+		nil,
 	).After(time.Second)
 
+	s.mockCreateDCMetadata(filepath.Join(sharedPath, relativePath))
+
+	s.mockCreateBag(filepath.Join(sharedPath, relativePath), batchID)
+
 	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PreprocessingParams{
 		RelativePath: relativePath,
 		SIPID:        sipID,
@@ -145,19 +278,47 @@ func (s *PreprocessingTestSuite) TestBatchSuccess() {
 			RelativePath: relativePath,
 			Tasks: []*childwf.Task{
 				{
-					Name:        "Upload ContainerMetadata.xml",
+					Name:        "Validate SIP structure",
 					Outcome:     childwf.TaskOutcomeSuccess,
-					Message:     "ContainerMetadata.xml file uploaded to the Enduro ingest bucket",
+					Message:     "SIP structure is valid",
 					StartedAt:   s.startTime,
 					CompletedAt: s.startTime.Add(time.Second),
 				},
 				{
-					Name:        "Bag SIP",
+					Name:        "Validate ContainerMetadata.xml",
 					Outcome:     childwf.TaskOutcomeSuccess,
-					Message:     "SIP has been bagged",
+					Message:     "ContainerMetadata.xml is valid",
 					StartedAt:   s.startTime.Add(time.Second),
 					CompletedAt: s.startTime.Add(2 * time.Second),
 				},
+				{
+					Name:        "Upload ContainerMetadata.xml",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					Message:     "ContainerMetadata.xml file uploaded to the Enduro ingest bucket",
+					StartedAt:   s.startTime.Add(2 * time.Second),
+					CompletedAt: s.startTime.Add(3 * time.Second),
+				},
+				{
+					Name:        "Create file inventory",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					Message:     "File inventory of 2 files (2.5 MB) uploaded to the Enduro ingest bucket",
+					StartedAt:   s.startTime.Add(3 * time.Second),
+					CompletedAt: s.startTime.Add(4 * time.Second),
+				},
+				{
+					Name:        "Create Dublin Core metadata",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					Message:     "Dublin Core metadata written to metadata/metadata.csv",
+					StartedAt:   s.startTime.Add(4 * time.Second),
+					CompletedAt: s.startTime.Add(5 * time.Second),
+				},
+				{
+					Name:        "Bag SIP",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					Message:     "SIP has been bagged",
+					StartedAt:   s.startTime.Add(5 * time.Second),
+					CompletedAt: s.startTime.Add(6 * time.Second),
+				},
 			},
 		},
 		result,
@@ -183,6 +344,9 @@ func (s *PreprocessingTestSuite) TestBatchContainerMDUploadError() {
 		},
 	})
 
+	s.mockValidateStructure(filepath.Join(sharedPath, relativePath))
+	s.mockValidateContainerMD(filepath.Join(sharedPath, relativePath))
+
 	s.env.OnActivity(
 		bucketupload.Name,
 		mock.AnythingOfType("*context.timerCtx"),
@@ -215,12 +379,26 @@ func (s *PreprocessingTestSuite) TestBatchContainerMDUploadError() {
 		childwf.PreprocessingResult{
 			Outcome: childwf.OutcomeSystemError,
 			Tasks: []*childwf.Task{
+				{
+					Name:        "Validate SIP structure",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					Message:     "SIP structure is valid",
+					StartedAt:   s.startTime,
+					CompletedAt: s.startTime.Add(time.Second),
+				},
+				{
+					Name:        "Validate ContainerMetadata.xml",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					Message:     "ContainerMetadata.xml is valid",
+					StartedAt:   s.startTime.Add(time.Second),
+					CompletedAt: s.startTime.Add(2 * time.Second),
+				},
 				{
 					Name:        "Upload ContainerMetadata.xml",
 					Outcome:     childwf.TaskOutcomeSystemFailure,
 					Message:     "System error: An error occurred when uploading the ContainerMetadata.xml file to the Enduro ingest bucket. Please try again, or ask a system administrator to investigate.",
-					StartedAt:   s.startTime,
-					CompletedAt: s.startTime.Add(time.Second),
+					StartedAt:   s.startTime.Add(2 * time.Second),
+					CompletedAt: s.startTime.Add(3 * time.Second),
 				},
 			},
 		},
@@ -232,6 +410,7 @@ func (s *PreprocessingTestSuite) TestNoBatchSuccess() {
 	sharedPath := s.T().TempDir()
 	relativePath := "SIP-01234"
 	sipID := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	csvKey := fmt.Sprintf("reports/sip_F0000007_%s.csv", sipID)
 
 	if err := createSIP(sharedPath, relativePath); err != nil {
 		s.FailNow("Unable to create SIP for test", "error", err)
@@ -242,22 +421,31 @@ func (s *PreprocessingTestSuite) TestNoBatchSuccess() {
 		Preprocessing: config.PreprocessingConfig{
 			WorkflowName: "preprocessing-test",
 			SharedPath:   sharedPath,
-			BagCreate: bagcreate.Config{
+			BagCreate: activities.CreateBagConfig{
 				ChecksumAlgorithm: "sha512",
 			},
 		},
 	})
 
+	s.mockValidateStructure(filepath.Join(sharedPath, relativePath))
+	s.mockValidateContainerMD(filepath.Join(sharedPath, relativePath))
+
 	s.env.OnActivity(
-		bagcreate.Name,
+		activities.CreateSIPCSVName,
 		mock.AnythingOfType("*context.timerCtx"),
-		&bagcreate.Params{
-			SourcePath: filepath.Join(sharedPath, relativePath),
+		&activities.CreateSIPCSVParams{
+			Path:  filepath.Join(sharedPath, relativePath),
+			SIPID: sipID,
+			Name:  relativePath,
 		},
 	).Return(
-		&bagcreate.Result{}, nil,
+		&activities.CreateSIPCSVResult{Key: csvKey}, nil,
 	).After(time.Second)
 
+	s.mockCreateDCMetadata(filepath.Join(sharedPath, relativePath))
+
+	s.mockCreateBag(filepath.Join(sharedPath, relativePath), uuid.Nil)
+
 	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PreprocessingParams{
 		RelativePath: relativePath,
 		SIPID:        sipID,
@@ -272,13 +460,868 @@ func (s *PreprocessingTestSuite) TestNoBatchSuccess() {
 			Outcome:      childwf.OutcomeSuccess,
 			RelativePath: relativePath,
 			Tasks: []*childwf.Task{
+				{
+					Name:        "Validate SIP structure",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					Message:     "SIP structure is valid",
+					StartedAt:   s.startTime,
+					CompletedAt: s.startTime.Add(time.Second),
+				},
+				{
+					Name:        "Validate ContainerMetadata.xml",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					Message:     "ContainerMetadata.xml is valid",
+					StartedAt:   s.startTime.Add(time.Second),
+					CompletedAt: s.startTime.Add(2 * time.Second),
+				},
+				{
+					Name:        "Create AtoM CSV file",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					Message:     `AtoM CSV file "reports/sip_F0000007_123e4567-e89b-12d3-a456-426614174000.csv" written to the Enduro ingest bucket`,
+					StartedAt:   s.startTime.Add(2 * time.Second),
+					CompletedAt: s.startTime.Add(3 * time.Second),
+				},
+				{
+					Name:        "Create Dublin Core metadata",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					Message:     "Dublin Core metadata written to metadata/metadata.csv",
+					StartedAt:   s.startTime.Add(3 * time.Second),
+					CompletedAt: s.startTime.Add(4 * time.Second),
+				},
 				{
 					Name:        "Bag SIP",
 					Outcome:     childwf.TaskOutcomeSuccess,
 					Message:     "SIP has been bagged",
+					StartedAt:   s.startTime.Add(4 * time.Second),
+					CompletedAt: s.startTime.Add(5 * time.Second),
+				},
+			},
+		},
+		result,
+	)
+}
+
+func (s *PreprocessingTestSuite) TestStructureValidationError() {
+	sharedPath := s.T().TempDir()
+	relativePath := "SIP-01234"
+	sipID := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	batchID := uuid.MustParse("223e4567-e89b-12d3-a456-426614174000")
+
+	if err := createSIP(sharedPath, relativePath); err != nil {
+		s.FailNow("Unable to create SIP for test", "error", err)
+	}
+
+	s.SetupWorkflowTest(config.Config{
+		IngestBucket: &bucket.Config{URL: "mem://"},
+		Preprocessing: config.PreprocessingConfig{
+			WorkflowName: "preprocessing-test",
+			SharedPath:   sharedPath,
+		},
+	})
+
+	s.env.OnActivity(
+		activities.ValidateStructureName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.ValidateStructureParams{Path: filepath.Join(sharedPath, relativePath)},
+	).Return(
+		nil,
+		activities.NewContentError(
+			"The SIP structure is not valid",
+			`Content directory is empty: "content"`,
+			`Missing required file: "metadata/submissionDocumentation/ContainerMetadata.xml"`,
+		),
+	).After(time.Second)
+
+	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PreprocessingParams{
+		RelativePath: relativePath,
+		SIPID:        sipID,
+		BatchID:      batchID,
+	})
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var result childwf.PreprocessingResult
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(
+		childwf.PreprocessingResult{
+			Outcome: childwf.OutcomeContentError,
+			Tasks: []*childwf.Task{
+				{
+					Name:    "Validate SIP structure",
+					Outcome: childwf.TaskOutcomeValidationFailure,
+					Message: `Content error: The SIP structure is not valid:
+Content directory is empty: "content"
+Missing required file: "metadata/submissionDocumentation/ContainerMetadata.xml"`,
+					StartedAt:   s.startTime,
+					CompletedAt: s.startTime.Add(time.Second),
+				},
+			},
+		},
+		result,
+	)
+}
+
+func (s *PreprocessingTestSuite) TestContainerMDValidationError() {
+	sharedPath := s.T().TempDir()
+	relativePath := "SIP-01234"
+	sipID := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+
+	if err := createSIP(sharedPath, relativePath); err != nil {
+		s.FailNow("Unable to create SIP for test", "error", err)
+	}
+
+	s.SetupWorkflowTest(config.Config{
+		IngestBucket: &bucket.Config{URL: "mem://"},
+		Preprocessing: config.PreprocessingConfig{
+			WorkflowName: "preprocessing-test",
+			SharedPath:   sharedPath,
+		},
+	})
+
+	s.mockValidateStructure(filepath.Join(sharedPath, relativePath))
+
+	s.env.OnActivity(
+		activities.ValidateContainerMDName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.ValidateContainerMDParams{Path: filepath.Join(sharedPath, relativePath)},
+	).Return(
+		nil,
+		activities.NewContentError(
+			"The metadata/submissionDocumentation/ContainerMetadata.xml file is not valid",
+			`Missing required field: "RecordNumber"`,
+			`Invalid value for field "DateClosed": "2012-06-31" is not a valid date`,
+		),
+	).After(time.Second)
+
+	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PreprocessingParams{
+		RelativePath: relativePath,
+		SIPID:        sipID,
+	})
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var result childwf.PreprocessingResult
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(
+		childwf.PreprocessingResult{
+			Outcome: childwf.OutcomeContentError,
+			Tasks: []*childwf.Task{
+				{
+					Name:        "Validate SIP structure",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					Message:     "SIP structure is valid",
+					StartedAt:   s.startTime,
+					CompletedAt: s.startTime.Add(time.Second),
+				},
+				{
+					Name:    "Validate ContainerMetadata.xml",
+					Outcome: childwf.TaskOutcomeValidationFailure,
+					Message: `Content error: The metadata/submissionDocumentation/ContainerMetadata.xml file is not valid:
+Missing required field: "RecordNumber"
+Invalid value for field "DateClosed": "2012-06-31" is not a valid date`,
+					StartedAt:   s.startTime.Add(time.Second),
+					CompletedAt: s.startTime.Add(2 * time.Second),
+				},
+			},
+		},
+		result,
+	)
+}
+
+func (s *PreprocessingTestSuite) TestMalwareScan() {
+	sharedPath := s.T().TempDir()
+	relativePath := "SIP-01234"
+	sipID := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+
+	if err := createSIP(sharedPath, relativePath); err != nil {
+		s.FailNow("Unable to create SIP for test", "error", err)
+	}
+
+	s.SetupWorkflowTest(config.Config{
+		IngestBucket: &bucket.Config{URL: "mem://"},
+		Preprocessing: config.PreprocessingConfig{
+			WorkflowName: "preprocessing-test",
+			SharedPath:   sharedPath,
+			MalwareScan: activities.MalwareScanConfig{
+				Enabled: true,
+				Address: "tcp://clamav:3310",
+			},
+		},
+	})
+
+	s.mockValidateStructure(filepath.Join(sharedPath, relativePath))
+	s.mockValidateContainerMD(filepath.Join(sharedPath, relativePath))
+
+	s.env.OnActivity(
+		activities.ScanMalwareName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.ScanMalwareParams{Path: filepath.Join(sharedPath, relativePath)},
+	).Return(
+		nil,
+		activities.NewContentError(
+			"Malware was found in the SIP",
+			"content/invoice.pdf.exe: Eicar-Test-Signature",
+		),
+	).After(time.Second)
+
+	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PreprocessingParams{
+		RelativePath: relativePath,
+		SIPID:        sipID,
+	})
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var result childwf.PreprocessingResult
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(
+		childwf.PreprocessingResult{
+			Outcome: childwf.OutcomeContentError,
+			Tasks: []*childwf.Task{
+				{
+					Name:        "Validate SIP structure",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					Message:     "SIP structure is valid",
 					StartedAt:   s.startTime,
 					CompletedAt: s.startTime.Add(time.Second),
 				},
+				{
+					Name:        "Validate ContainerMetadata.xml",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					Message:     "ContainerMetadata.xml is valid",
+					StartedAt:   s.startTime.Add(time.Second),
+					CompletedAt: s.startTime.Add(2 * time.Second),
+				},
+				{
+					Name:    "Scan for malware",
+					Outcome: childwf.TaskOutcomeValidationFailure,
+					Message: `Content error: Malware was found in the SIP:
+content/invoice.pdf.exe: Eicar-Test-Signature`,
+					StartedAt:   s.startTime.Add(2 * time.Second),
+					CompletedAt: s.startTime.Add(3 * time.Second),
+				},
+			},
+		},
+		result,
+	)
+}
+
+func (s *PreprocessingTestSuite) TestMalwareScanClean() {
+	sharedPath := s.T().TempDir()
+	relativePath := "SIP-01234"
+	sipID := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+
+	if err := createSIP(sharedPath, relativePath); err != nil {
+		s.FailNow("Unable to create SIP for test", "error", err)
+	}
+
+	s.SetupWorkflowTest(config.Config{
+		IngestBucket: &bucket.Config{URL: "mem://"},
+		Preprocessing: config.PreprocessingConfig{
+			WorkflowName: "preprocessing-test",
+			SharedPath:   sharedPath,
+			MalwareScan: activities.MalwareScanConfig{
+				Enabled:        true,
+				Address:        "tcp://clamav:3310",
+				AllowUnscanned: true,
+			},
+		},
+	})
+
+	s.mockValidateStructure(filepath.Join(sharedPath, relativePath))
+	s.mockValidateContainerMD(filepath.Join(sharedPath, relativePath))
+
+	s.env.OnActivity(
+		activities.ScanMalwareName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.ScanMalwareParams{Path: filepath.Join(sharedPath, relativePath)},
+	).Return(
+		&activities.ScanMalwareResult{Files: 3, Unscanned: []string{"content/disk.iso"}}, nil,
+	).After(time.Second)
+
+	s.mockCreateSIPCSV(filepath.Join(sharedPath, relativePath), sipID)
+	s.mockCreateDCMetadata(filepath.Join(sharedPath, relativePath))
+	s.mockCreateBag(filepath.Join(sharedPath, relativePath), uuid.Nil)
+
+	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PreprocessingParams{
+		RelativePath: relativePath,
+		SIPID:        sipID,
+	})
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var result childwf.PreprocessingResult
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(childwf.OutcomeSuccess, result.Outcome)
+	s.Equal(
+		&childwf.Task{
+			Name:    "Scan for malware",
+			Outcome: childwf.TaskOutcomeSuccess,
+			Message: `No malware found in 3 files
+Files not scanned, larger than the ClamAV StreamMaxLength limit:
+content/disk.iso`,
+			StartedAt:   s.startTime.Add(2 * time.Second),
+			CompletedAt: s.startTime.Add(3 * time.Second),
+		},
+		result.Tasks[2],
+	)
+}
+
+func (s *PreprocessingTestSuite) TestIdentifyFormatsWarnings() {
+	sharedPath := s.T().TempDir()
+	relativePath := "SIP-01234"
+	sipID := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+
+	if err := createSIP(sharedPath, relativePath); err != nil {
+		s.FailNow("Unable to create SIP for test", "error", err)
+	}
+
+	s.SetupWorkflowTest(config.Config{
+		IngestBucket: &bucket.Config{URL: "mem://"},
+		Preprocessing: config.PreprocessingConfig{
+			WorkflowName: "preprocessing-test",
+			SharedPath:   sharedPath,
+			IdentifyFormats: activities.IdentifyFormatsConfig{
+				Enabled:     true,
+				Deny:        []string{"x-fmt/411"},
+				OnViolation: activities.OnFormatViolationWarn,
+			},
+		},
+	})
+
+	s.mockValidateStructure(filepath.Join(sharedPath, relativePath))
+	s.mockValidateContainerMD(filepath.Join(sharedPath, relativePath))
+
+	s.env.OnActivity(
+		activities.IdentifyFormatsName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.IdentifyFormatsParams{Path: filepath.Join(sharedPath, relativePath)},
+	).Return(
+		&activities.IdentifyFormatsResult{
+			Path:  "metadata/format-identification.json",
+			Files: 2,
+			Warnings: []string{
+				"content/setup.exe: x-fmt/411 (Windows Portable Executable) is not allowed",
+			},
+		},
+		nil,
+	).After(time.Second)
+
+	s.mockCreateSIPCSV(filepath.Join(sharedPath, relativePath), sipID)
+	s.mockCreateDCMetadata(filepath.Join(sharedPath, relativePath))
+	s.mockCreateBag(filepath.Join(sharedPath, relativePath), uuid.Nil)
+
+	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PreprocessingParams{
+		RelativePath: relativePath,
+		SIPID:        sipID,
+	})
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var result childwf.PreprocessingResult
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(childwf.OutcomeSuccess, result.Outcome)
+	s.Equal(
+		&childwf.Task{
+			Name:    "Identify file formats",
+			Outcome: childwf.TaskOutcomeSuccess,
+			Message: `Identified the format of 2 files, report written to metadata/format-identification.json
+Format policy warnings:
+content/setup.exe: x-fmt/411 (Windows Portable Executable) is not allowed`,
+			StartedAt:   s.startTime.Add(2 * time.Second),
+			CompletedAt: s.startTime.Add(3 * time.Second),
+		},
+		result.Tasks[2],
+	)
+}
+
+func (s *PreprocessingTestSuite) TestScanPIIFindings() {
+	sharedPath := s.T().TempDir()
+	relativePath := "SIP-01234"
+	sipID := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+
+	if err := createSIP(sharedPath, relativePath); err != nil {
+		s.FailNow("Unable to create SIP for test", "error", err)
+	}
+
+	s.SetupWorkflowTest(config.Config{
+		IngestBucket: &bucket.Config{URL: "mem://"},
+		Preprocessing: config.PreprocessingConfig{
+			WorkflowName: "preprocessing-test",
+			SharedPath:   sharedPath,
+			ScanPII:      activities.ScanPIIConfig{Enabled: true},
+		},
+	})
+
+	s.mockValidateStructure(filepath.Join(sharedPath, relativePath))
+	s.mockValidateContainerMD(filepath.Join(sharedPath, relativePath))
+
+	report := &activities.PIIReport{
+		Files: 12,
+		Hits: []activities.PIIHits{
+			{Name: "SIN", Matches: 2, Files: 1},
+			{Name: "Email address", Matches: 5, Files: 2},
+		},
+		Flagged: []activities.PIIFile{
+			{Path: "content/form.pdf", Hits: map[string]int{"SIN": 2, "Email address": 1}},
+			{Path: "content/contacts.csv", Hits: map[string]int{"Email address": 4}},
+		},
+	}
+	s.env.OnActivity(
+		activities.ScanPIIName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.ScanPIIParams{Path: filepath.Join(sharedPath, relativePath)},
+	).Return(
+		&activities.ScanPIIResult{Path: "metadata/pii-report.json", Report: report}, nil,
+	).After(time.Second)
+
+	s.env.OnActivity(
+		activities.CreateSIPCSVName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.CreateSIPCSVParams{
+			Path:  filepath.Join(sharedPath, relativePath),
+			SIPID: sipID,
+			Name:  relativePath,
+			PII:   report,
+		},
+	).Return(
+		&activities.CreateSIPCSVResult{Key: fmt.Sprintf("reports/sip_%s.csv", sipID)}, nil,
+	).After(time.Second)
+	s.env.OnActivity(
+		activities.CreateDCMetadataName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.CreateDCMetadataParams{Path: filepath.Join(sharedPath, relativePath), PII: report},
+	).Return(
+		&activities.CreateDCMetadataResult{Path: "metadata/metadata.csv"}, nil,
+	).After(time.Second)
+	s.mockCreateBag(filepath.Join(sharedPath, relativePath), uuid.Nil)
+
+	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PreprocessingParams{
+		RelativePath: relativePath,
+		SIPID:        sipID,
+	})
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var result childwf.PreprocessingResult
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(childwf.OutcomeSuccess, result.Outcome)
+	s.Equal(
+		&childwf.Task{
+			Name:    "Scan for personal information",
+			Outcome: childwf.TaskOutcomeSuccess,
+			Message: `Scanned 12 files for personal information, report written to metadata/pii-report.json
+Potential personal information found in 2 files: 2 SIN, 5 Email address`,
+			StartedAt:   s.startTime.Add(2 * time.Second),
+			CompletedAt: s.startTime.Add(3 * time.Second),
+		},
+		result.Tasks[2],
+	)
+}
+
+func (s *PreprocessingTestSuite) TestVerifyChecksumsMismatch() {
+	sharedPath := s.T().TempDir()
+	relativePath := "SIP-01234"
+	sipID := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+
+	if err := createSIP(sharedPath, relativePath); err != nil {
+		s.FailNow("Unable to create SIP for test", "error", err)
+	}
+
+	s.SetupWorkflowTest(config.Config{
+		IngestBucket: &bucket.Config{URL: "mem://"},
+		Preprocessing: config.PreprocessingConfig{
+			WorkflowName:    "preprocessing-test",
+			SharedPath:      sharedPath,
+			VerifyChecksums: activities.VerifyChecksumsConfig{Enabled: true},
+		},
+	})
+
+	s.mockValidateStructure(filepath.Join(sharedPath, relativePath))
+	s.mockValidateContainerMD(filepath.Join(sharedPath, relativePath))
+
+	s.env.OnActivity(
+		activities.VerifyChecksumsName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.VerifyChecksumsParams{Path: filepath.Join(sharedPath, relativePath)},
+	).Return(
+		nil,
+		activities.NewContentError(
+			"The SIP files don't match the checksum manifest",
+			`Checksum mismatch: "content/content.pdf" (metadata/checksum.sha256)`,
+			`Unlisted file: "content/extra.pdf" (metadata/checksum.sha256)`,
+		),
+	).After(time.Second)
+
+	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PreprocessingParams{
+		RelativePath: relativePath,
+		SIPID:        sipID,
+	})
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var result childwf.PreprocessingResult
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(
+		childwf.PreprocessingResult{
+			Outcome: childwf.OutcomeContentError,
+			Tasks: []*childwf.Task{
+				{
+					Name:        "Validate SIP structure",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					Message:     "SIP structure is valid",
+					StartedAt:   s.startTime,
+					CompletedAt: s.startTime.Add(time.Second),
+				},
+				{
+					Name:        "Validate ContainerMetadata.xml",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					Message:     "ContainerMetadata.xml is valid",
+					StartedAt:   s.startTime.Add(time.Second),
+					CompletedAt: s.startTime.Add(2 * time.Second),
+				},
+				{
+					Name:    "Verify checksums",
+					Outcome: childwf.TaskOutcomeValidationFailure,
+					Message: `Content error: The SIP files don't match the checksum manifest:
+Checksum mismatch: "content/content.pdf" (metadata/checksum.sha256)
+Unlisted file: "content/extra.pdf" (metadata/checksum.sha256)`,
+					StartedAt:   s.startTime.Add(2 * time.Second),
+					CompletedAt: s.startTime.Add(3 * time.Second),
+				},
+			},
+		},
+		result,
+	)
+}
+
+func (s *PreprocessingTestSuite) TestTimeoutsFromSIPSize() {
+	sharedPath := s.T().TempDir()
+	relativePath := "SIP-01234"
+	sipPath := filepath.Join(sharedPath, relativePath)
+	sipID := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+
+	if err := createSIP(sharedPath, relativePath); err != nil {
+		s.FailNow("Unable to create SIP for test", "error", err)
+	}
+
+	s.SetupWorkflowTest(config.Config{
+		IngestBucket: &bucket.Config{URL: "mem://"},
+		Preprocessing: config.PreprocessingConfig{
+			WorkflowName: "preprocessing-test",
+			SharedPath:   sharedPath,
+			BagCreate: activities.CreateBagConfig{
+				ChecksumAlgorithm: "sha512",
+				TimeoutPerGiB:     2 * time.Minute,
+			},
+			VerifyChecksums: activities.VerifyChecksumsConfig{
+				Enabled:       true,
+				TimeoutPerGiB: time.Minute,
+			},
+			MalwareScan: activities.MalwareScanConfig{
+				Enabled:       true,
+				Address:       "tcp://clamav:3310",
+				TimeoutPerGiB: 4 * time.Minute,
+			},
+			IdentifyFormats: activities.IdentifyFormatsConfig{
+				Enabled: true,
+			},
+			ScanPII: activities.ScanPIIConfig{
+				Enabled:       true,
+				TimeoutPerGiB: 3 * time.Minute,
+			},
+		},
+	})
+
+	// A 5 GiB SIP is allowed 20 minutes to bag, 15 minutes to verify and
+	// identify, 30 minutes to scan for malware and 25 minutes to scan for
+	// personal information.
+	s.env.OnActivity(
+		activities.ValidateStructureName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.ValidateStructureParams{Path: sipPath},
+	).Return(
+		&activities.ValidateStructureResult{Size: 5 << 30}, nil,
+	)
+	s.env.OnActivity(
+		activities.VerifyChecksumsName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.VerifyChecksumsParams{Path: sipPath},
+	).Return(
+		&activities.VerifyChecksumsResult{Manifests: []string{}}, nil,
+	)
+	s.env.OnActivity(
+		activities.ScanMalwareName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.ScanMalwareParams{Path: sipPath},
+	).Return(
+		&activities.ScanMalwareResult{Files: 3, Unscanned: []string{}}, nil,
+	)
+	s.env.OnActivity(
+		activities.IdentifyFormatsName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.IdentifyFormatsParams{Path: sipPath},
+	).Return(
+		&activities.IdentifyFormatsResult{Path: "metadata/format-identification.json"}, nil,
+	)
+	s.env.OnActivity(
+		activities.ScanPIIName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.ScanPIIParams{Path: sipPath},
+	).Return(
+		&activities.ScanPIIResult{
+			Path:   "metadata/pii-report.json",
+			Report: &activities.PIIReport{Files: 3},
+		}, nil,
+	)
+	s.env.OnActivity(
+		activities.ValidateContainerMDName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.ValidateContainerMDParams{Path: sipPath},
+	).Return(
+		&activities.ValidateContainerMDResult{}, nil,
+	)
+	s.env.OnActivity(
+		activities.CreateSIPCSVName,
+		mock.AnythingOfType("*context.timerCtx"),
+		mock.AnythingOfType("*activities.CreateSIPCSVParams"),
+	).Return(
+		&activities.CreateSIPCSVResult{Key: "reports/sip.csv"}, nil,
+	)
+	s.env.OnActivity(
+		activities.CreateDCMetadataName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.CreateDCMetadataParams{Path: sipPath},
+	).Return(
+		&activities.CreateDCMetadataResult{Path: "metadata/metadata.csv"}, nil,
+	)
+	s.env.OnActivity(
+		activities.CreateBagName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.CreateBagParams{Path: sipPath},
+	).Return(
+		&activities.CreateBagResult{Path: sipPath}, nil,
+	)
+
+	infos := map[string]*temporalsdk_activity.Info{}
+	s.env.SetOnActivityStartedListener(
+		func(info *temporalsdk_activity.Info, _ context.Context, _ temporalsdk_converter.EncodedValues) {
+			infos[info.ActivityType.Name] = info
+		},
+	)
+
+	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PreprocessingParams{
+		RelativePath: relativePath,
+		SIPID:        sipID,
+	})
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var result childwf.PreprocessingResult
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(childwf.OutcomeSuccess, result.Outcome)
+
+	for name, timeout := range map[string]time.Duration{
+		activities.CreateBagName:       20 * time.Minute,
+		activities.VerifyChecksumsName: 15 * time.Minute,
+		activities.ScanMalwareName:     30 * time.Minute,
+		activities.IdentifyFormatsName: 15 * time.Minute,
+		activities.ScanPIIName:         25 * time.Minute,
+	} {
+		info := infos[name]
+		s.Require().NotNil(info, name)
+		s.Equal(timeout, info.Deadline.Sub(info.ScheduledTime), name)
+		s.Equal(time.Minute, info.HeartbeatTimeout, name)
+	}
+}
+
+func (s *PreprocessingTestSuite) TestSessionCreationError() {
+	sharedPath := s.T().TempDir()
+	relativePath := "SIP-01234"
+
+	if err := createSIP(sharedPath, relativePath); err != nil {
+		s.FailNow("Unable to create SIP for test", "error", err)
+	}
+
+	s.SetupWorkflowTest(config.Config{
+		IngestBucket: &bucket.Config{URL: "mem://"},
+		Preprocessing: config.PreprocessingConfig{
+			WorkflowName: "preprocessing-test",
+			SharedPath:   sharedPath,
+		},
+	})
+
+	// No worker accepts sessions.
+	s.env.SetWorkerOptions(temporalsdk_worker.Options{EnableSessionWorker: false})
+
+	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PreprocessingParams{
+		RelativePath: relativePath,
+		SIPID:        uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+	})
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+
+	var result childwf.PreprocessingResult
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(childwf.OutcomeSystemError, result.Outcome)
+	s.Len(result.Tasks, 1)
+	s.Equal("Start preprocessing session", result.Tasks[0].Name)
+	s.Equal(childwf.TaskOutcomeSystemFailure, result.Tasks[0].Outcome)
+	s.Equal(
+		"System error: An error occurred when starting the preprocessing session. Please try again, or ask a system administrator to investigate.",
+		result.Tasks[0].Message,
+	)
+}
+
+func (s *PreprocessingTestSuite) TestLegalHoldQuarantine() {
+	sharedPath := s.T().TempDir()
+	relativePath := "SIP-01234"
+	sipID := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	quarantinePath := filepath.Join(sharedPath, "quarantine", relativePath+"_"+sipID.String())
+
+	if err := createSIP(sharedPath, relativePath); err != nil {
+		s.FailNow("Unable to create SIP for test", "error", err)
+	}
+
+	s.SetupWorkflowTest(config.Config{
+		IngestBucket: &bucket.Config{URL: "mem://"},
+		Preprocessing: config.PreprocessingConfig{
+			WorkflowName: "preprocessing-test",
+			SharedPath:   sharedPath,
+			LegalHold: activities.LegalHoldConfig{
+				Enabled: true,
+				Action:  activities.LegalHoldActionQuarantine,
+			},
+			VerifyChecksums: activities.VerifyChecksumsConfig{Enabled: true},
+			MalwareScan: activities.MalwareScanConfig{
+				Enabled: true,
+				Address: "tcp://clamav:3310",
+			},
+			IdentifyFormats: activities.IdentifyFormatsConfig{Enabled: true},
+			ScanPII:         activities.ScanPIIConfig{Enabled: true},
+		},
+	})
+
+	s.env.OnActivity(
+		activities.CheckLegalHoldName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.CheckLegalHoldParams{Path: filepath.Join(sharedPath, relativePath), SIPID: sipID},
+	).Return(
+		&activities.CheckLegalHoldResult{
+			Reasons:        []string{"HasHolds is true"},
+			QuarantinePath: quarantinePath,
+		},
+		nil,
+	).After(time.Second)
+
+	// The SIP on hold is not validated, read or changed by the other steps.
+	for _, name := range []string{
+		activities.ValidateStructureName,
+		activities.ValidateContainerMDName,
+		activities.VerifyChecksumsName,
+		activities.ScanMalwareName,
+		activities.IdentifyFormatsName,
+		activities.ScanPIIName,
+	} {
+		s.env.OnActivity(name, mock.Anything, mock.Anything).Never()
+	}
+
+	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PreprocessingParams{
+		RelativePath: relativePath,
+		SIPID:        sipID,
+	})
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var result childwf.PreprocessingResult
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(
+		childwf.PreprocessingResult{
+			Outcome: childwf.OutcomeContentError,
+			Tasks: []*childwf.Task{
+				{
+					Name:    "Check legal holds",
+					Outcome: childwf.TaskOutcomeValidationFailure,
+					Message: "Content error: The SIP is on a legal hold and was moved to " + quarantinePath +
+						" for review:\nHasHolds is true",
+					StartedAt:   s.startTime,
+					CompletedAt: s.startTime.Add(time.Second),
+				},
+			},
+		},
+		result,
+	)
+	s.env.AssertExpectations(s.T())
+}
+
+func (s *PreprocessingTestSuite) TestRetentionViolation() {
+	sharedPath := s.T().TempDir()
+	relativePath := "SIP-01234"
+	sipID := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+
+	if err := createSIP(sharedPath, relativePath); err != nil {
+		s.FailNow("Unable to create SIP for test", "error", err)
+	}
+
+	s.SetupWorkflowTest(config.Config{
+		IngestBucket: &bucket.Config{URL: "mem://"},
+		Preprocessing: config.PreprocessingConfig{
+			WorkflowName: "preprocessing-test",
+			SharedPath:   sharedPath,
+			Retention:    activities.RetentionConfig{Enabled: true},
+		},
+	})
+
+	s.mockValidateStructure(filepath.Join(sharedPath, relativePath))
+	s.mockValidateContainerMD(filepath.Join(sharedPath, relativePath))
+
+	s.env.OnActivity(
+		activities.CheckRetentionName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.CheckRetentionParams{Path: filepath.Join(sharedPath, relativePath)},
+	).Return(
+		nil,
+		activities.NewContentError(
+			`The SIP is not due for permanent archival under retention schedule "ARCS 100-01"`,
+			`Disposition "Destruction" is not a permanent archival disposition`,
+		),
+	).After(time.Second)
+
+	s.env.ExecuteWorkflow(s.workflow.Execute, &childwf.PreprocessingParams{
+		RelativePath: relativePath,
+		SIPID:        sipID,
+	})
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var result childwf.PreprocessingResult
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(
+		childwf.PreprocessingResult{
+			Outcome: childwf.OutcomeContentError,
+			Tasks: []*childwf.Task{
+				{
+					Name:        "Validate SIP structure",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					Message:     "SIP structure is valid",
+					StartedAt:   s.startTime,
+					CompletedAt: s.startTime.Add(time.Second),
+				},
+				{
+					Name:        "Validate ContainerMetadata.xml",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					Message:     "ContainerMetadata.xml is valid",
+					StartedAt:   s.startTime.Add(time.Second),
+					CompletedAt: s.startTime.Add(2 * time.Second),
+				},
+				{
+					Name:    "Check retention schedule",
+					Outcome: childwf.TaskOutcomeValidationFailure,
+					Message: `Content error: The SIP is not due for permanent archival under retention schedule "ARCS 100-01":
+Disposition "Destruction" is not a permanent archival disposition`,
+					StartedAt:   s.startTime.Add(2 * time.Second),
+					CompletedAt: s.startTime.Add(3 * time.Second),
+				},
 			},
 		},
 		result,
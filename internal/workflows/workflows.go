@@ -1,17 +1,101 @@
 package workflows
 
 import (
+	"errors"
 	"time"
 
+	"github.com/artefactual-sdps/enduro/pkg/childwf"
 	temporalsdk_temporal "go.temporal.io/sdk/temporal"
 	temporalsdk_workflow "go.temporal.io/sdk/workflow"
+
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/activities"
+	"github.com/artefactual-sdps/cva-enduro-workflows/internal/config"
 )
 
-func withFilesysOpts(ctx temporalsdk_workflow.Context, d time.Duration) temporalsdk_workflow.Context {
-	return temporalsdk_workflow.WithActivityOptions(ctx, temporalsdk_workflow.ActivityOptions{
+// withActivityOpts returns a context with the activity options of the named
+// activity. Options that are not set in cfgs use the defaults: a
+// ScheduleToCloseTimeout of d and a single attempt.
+func withActivityOpts(
+	ctx temporalsdk_workflow.Context,
+	cfgs config.ActivitiesConfig,
+	name string,
+	d time.Duration,
+) temporalsdk_workflow.Context {
+	return temporalsdk_workflow.WithActivityOptions(ctx, activityOpts(cfgs[name], d))
+}
+
+// withHeartbeatActivityOpts is like withActivityOpts for the activities that
+// record heartbeats, with a default HeartbeatTimeout of heartbeatTimeout so a
+// hung worker is detected without waiting for the activity timeout d.
+func withHeartbeatActivityOpts(
+	ctx temporalsdk_workflow.Context,
+	cfgs config.ActivitiesConfig,
+	name string,
+	d time.Duration,
+) temporalsdk_workflow.Context {
+	opts := activityOpts(cfgs[name], d)
+	if opts.HeartbeatTimeout == 0 {
+		opts.HeartbeatTimeout = heartbeatTimeout
+	}
+
+	return temporalsdk_workflow.WithActivityOptions(ctx, opts)
+}
+
+// activityOpts returns the Temporal activity options for cfg, using d as the
+// default ScheduleToCloseTimeout and a single attempt as the default retry
+// policy.
+func activityOpts(cfg config.ActivityConfig, d time.Duration) temporalsdk_workflow.ActivityOptions {
+	opts := temporalsdk_workflow.ActivityOptions{
 		ScheduleToCloseTimeout: d,
+		StartToCloseTimeout:    cfg.StartToCloseTimeout,
+		HeartbeatTimeout:       cfg.HeartbeatTimeout,
 		RetryPolicy: &temporalsdk_temporal.RetryPolicy{
-			MaximumAttempts: 1,
+			InitialInterval:        cfg.Retry.InitialInterval,
+			BackoffCoefficient:     cfg.Retry.BackoffCoefficient,
+			MaximumInterval:        cfg.Retry.MaximumInterval,
+			MaximumAttempts:        1,
+			NonRetryableErrorTypes: cfg.Retry.NonRetryableErrorTypes,
 		},
-	})
+	}
+	if cfg.ScheduleToCloseTimeout > 0 {
+		opts.ScheduleToCloseTimeout = cfg.ScheduleToCloseTimeout
+	}
+	if cfg.Retry.MaximumAttempts > 0 {
+		opts.RetryPolicy.MaximumAttempts = cfg.Retry.MaximumAttempts
+	}
+
+	return opts
+}
+
+// failTask completes task as failed and sets the result outcome accordingly.
+//
+// If err is a content error returned by an activity (see
+// activities.NewContentError) the task fails with a content error using the
+// activity's message and list of failures, so the user knows the SIP must be
+// fixed. Any other error is reported as a system error using sysErrMsg.
+func failTask(
+	ctx temporalsdk_workflow.Context,
+	result *childwf.PreprocessingResult,
+	task *childwf.Task,
+	err error,
+	sysErrMsg string,
+) {
+	logger := temporalsdk_workflow.GetLogger(ctx)
+
+	var appErr *temporalsdk_temporal.ApplicationError
+	if errors.As(err, &appErr) && appErr.Type() == activities.ContentErrorType {
+		var failures []string
+		if appErr.HasDetails() {
+			if dErr := appErr.Details(&failures); dErr != nil {
+				logger.Error("Unable to decode content error details", "task", task.Name, "error", dErr)
+			}
+		}
+
+		logger.Info("Task failed with content error", "task", task.Name, "error", err)
+		result.ValidationError(temporalsdk_workflow.Now(ctx), task, appErr.Message(), failures)
+		return
+	}
+
+	logger.Error("Task failed with error", "task", task.Name, "error", err)
+	result.SystemError(temporalsdk_workflow.Now(ctx), task, sysErrMsg)
 }